@@ -9,15 +9,23 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/go-chi/chi/v5"
 
 	"github.com/cgund98/go-postgres-api-template/internal/config"
+	"github.com/cgund98/go-postgres-api-template/internal/domain"
+	userEvents "github.com/cgund98/go-postgres-api-template/internal/domain/user/events"
 	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/aws"
 	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/db/postgres"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/asyncapi"
 	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/publisher"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/scheduler"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/schema"
 	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/serializer"
 	"github.com/cgund98/go-postgres-api-template/internal/observability"
 	"github.com/cgund98/go-postgres-api-template/internal/presentation"
 	presentationuser "github.com/cgund98/go-postgres-api-template/internal/presentation/user"
+	"github.com/cgund98/go-postgres-api-template/resources/db/migrations"
 )
 
 var logger = observability.Logger
@@ -31,13 +39,48 @@ func main() {
 	}
 
 	// Initialize database
-	dbPool, err := postgres.NewPool(cfg.Database.URL)
+	dbPool, err := postgres.NewPool(cfg.Database.URL, postgres.PoolOptionsFromConfig(cfg.Database, "api"))
 	if err != nil {
 		logger.Error("Failed to connect to database", "error", err)
 		os.Exit(1)
 	}
 	defer dbPool.Close()
 
+	// Apply pending migrations. Replicas starting together wait on an advisory lock.
+	if cfg.Database.AutoMigrate {
+		migrator, err := postgres.NewMigrator(dbPool.Pool(), migrations.FS)
+		if err != nil {
+			logger.Error("Failed to load migrations", "error", err)
+			os.Exit(1)
+		}
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			logger.Error("Failed to apply migrations", "error", err)
+			os.Exit(1)
+		}
+		logger.Info("Applied migrations", "count", applied)
+	}
+
+	// Read replicas serve read-only transactions, falling back to the primary when none is healthy.
+	// Each replica's pool is named replica-<n>, and its application name gets a -replica-<n> suffix, such as api-replica-0.
+	txOptions := postgres.TransactionManagerOptionsFromConfig(cfg.Database)
+	if len(cfg.Database.ReplicaURLs) > 0 {
+		replicas, err := postgres.NewReplicaSet(cfg.Database.ReplicaURLs, postgres.PoolOptionsFromConfig(cfg.Database, "api"), postgres.ReplicaSetOptions{})
+		if err != nil {
+			logger.Error("Failed to initialize read replicas", "error", err)
+			os.Exit(1)
+		}
+		defer replicas.Close()
+
+		replicasCtx, stopReplicas := context.WithCancel(context.Background())
+		defer stopReplicas()
+		replicas.Start(replicasCtx)
+		txOptions.Replicas = replicas
+	}
+
+	// Create PostgreSQL transaction manager, shared by the services and the delayed publisher
+	txManager := postgres.NewTransactionManager(dbPool.Pool(), txOptions)
+
 	// Initialize AWS clients
 	awsSession, err := aws.NewSession(cfg.AWS)
 	if err != nil {
@@ -45,17 +88,58 @@ func main() {
 		os.Exit(1)
 	}
 	snsClient := sns.New(awsSession)
+	sqsClient := sqs.New(awsSession)
+
+	// Register event types from every domain
+	eventRegistry, err := domain.NewEventRegistry()
+	if err != nil {
+		logger.Error("Failed to register event types", "error", err)
+		os.Exit(1)
+	}
 
 	// Initialize event publisher
-	serializer := serializer.NewJSONSerializer()
-	eventPub := publisher.NewSNSPublisher(cfg.Events.TopicARN, serializer, snsClient)
-	logger.Info("event publisher initialized", "topic_arn", cfg.Events.TopicARN)
+	// Delayed events go to the delay queue, if one is configured, or the scheduled_events table and are published by the worker
+	// Events are routed to a topic by event type, and validated against their schema first if enabled
+	var eventSerializer serializer.Serializer = serializer.NewJSONSerializer()
+	if cfg.Events.Validation.Publish {
+		eventSerializer = serializer.NewValidatingSerializer(eventSerializer, schema.NewValidator(eventRegistry, schema.ValidatorOptions{}))
+	}
+	eventRoutes := routerOptions(cfg.Events)
+	snsPub, err := publisher.NewRoutingPublisher(eventRoutes, func(topicARN string) publisher.Publisher {
+		return publisher.NewSNSPublisher(topicARN, eventSerializer, snsClient)
+	})
+	if err != nil {
+		logger.Error("Failed to initialize event publisher", "error", err)
+		os.Exit(1)
+	}
+	eventPub := publisher.NewDelayedPublisher(
+		snsPub,
+		eventSerializer,
+		sqsClient,
+		cfg.Events.DelayQueueURL,
+		scheduler.NewPostgresStore(),
+		txManager,
+	)
+	logger.Info("event publisher initialized", "routes", eventRoutes.Routes, "default_topic_arn", cfg.Events.TopicARN, "delay_queue_url", cfg.Events.DelayQueueURL, "validate", cfg.Events.Validation.Publish)
+
+	// Decide whether user events carry snapshots, and how PII appears in them
+	snapshots, err := userEvents.NewSnapshotPolicy(cfg.Events.Snapshots.Enabled, cfg.Events.Snapshots.Fields)
+	if err != nil {
+		logger.Error("Invalid event snapshot policy", "error", err)
+		os.Exit(1)
+	}
 
 	// Initialize dependencies
-	deps := presentation.NewDependencies(dbPool, eventPub)
+	deps := presentation.NewDependencies(txManager, eventPub, snapshots)
 
 	// Setup router with Chi and Huma
-	router := presentation.NewRouter()
+	// Clients' reads go to the primary for a while after they write, so they don't read stale replicas
+	routerOptions := presentation.RouterOptions{}
+	if cfg.Database.ReadYourWritesSeconds > 0 {
+		window := time.Duration(cfg.Database.ReadYourWritesSeconds) * time.Second
+		routerOptions.ReadYourWrites = &window
+	}
+	router := presentation.NewRouter(routerOptions)
 
 	// Register API v1 routes
 	userController := presentationuser.NewUserController(deps.UserService)
@@ -69,11 +153,38 @@ func main() {
 		}
 	})
 
+	// AsyncAPI document describing the published events, alongside Huma's /openapi.json
+	eventRouter, err := publisher.NewRouter(eventRoutes)
+	if err != nil {
+		logger.Error("Failed to initialize event routes", "error", err)
+		os.Exit(1)
+	}
+	asyncAPIHandler, err := asyncapi.Handler(asyncapi.Generate(eventRegistry, eventRouter, asyncapi.Info{
+		Title:   "My API Events",
+		Version: "1.0.0",
+	}))
+	if err != nil {
+		logger.Error("Failed to generate AsyncAPI document", "error", err)
+		os.Exit(1)
+	}
+	router.ChiRouter().Get("/asyncapi.json", asyncAPIHandler)
+
 	server := &http.Server{
 		Addr:    ":" + cfg.Server.Port,
 		Handler: router,
 	}
 
+	// Serve operational endpoints on a separate port, so they aren't exposed with the API
+	adminRouter := chi.NewRouter()
+	adminRouter.Handle("/metrics", observability.MetricsHandler())
+	// Connection pool statistics, for sizing the pool across replicas
+	adminRouter.Get("/debug/db", dbPool.StatsHandler())
+
+	adminServer := &http.Server{
+		Addr:    ":" + cfg.Server.AdminPort,
+		Handler: adminRouter,
+	}
+
 	// Start server in a goroutine
 	go func() {
 		logger.Info("Server starting on port", "port", cfg.Server.Port)
@@ -83,6 +194,14 @@ func main() {
 		}
 	}()
 
+	go func() {
+		logger.Info("Admin server starting on port", "port", cfg.Server.AdminPort)
+		if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("Admin server failed to start", "error", err)
+			os.Exit(1)
+		}
+	}()
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -93,6 +212,9 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if err := adminServer.Shutdown(ctx); err != nil {
+		logger.Error("Admin server forced to shutdown", "error", err)
+	}
 	if err := server.Shutdown(ctx); err != nil {
 		logger.Error("Server forced to shutdown", "error", err)
 		os.Exit(1)
@@ -100,3 +222,13 @@ func main() {
 
 	logger.Info("Server exited")
 }
+
+// routerOptions maps the routes in the events config to publisher routes.
+// Without routes, every event goes to the default topic.
+func routerOptions(cfg config.EventsConfig) publisher.RouterOptions {
+	routes := make([]publisher.Route, len(cfg.Routes))
+	for i, route := range cfg.Routes {
+		routes[i] = publisher.Route{Pattern: route.Pattern, TopicARN: route.TopicARN}
+	}
+	return publisher.RouterOptions{Routes: routes, DefaultTopicARN: &cfg.TopicARN}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/cgund98/go-postgres-api-template/internal/config"
+	"github.com/cgund98/go-postgres-api-template/internal/domain"
+	awsUtils "github.com/cgund98/go-postgres-api-template/internal/infrastructure/aws"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/provision"
+	"github.com/cgund98/go-postgres-api-template/internal/observability"
+)
+
+var logger = observability.Logger
+
+const usage = `Usage: infra <command>
+
+Provisions the SNS topic, SQS queues, dead-letter queues and subscriptions
+derived from the event registry and the subscriptions in the config file.
+
+Commands:
+  plan    Show the changes needed, without making them
+  apply   Make the changes needed. Safe to run repeatedly.
+`
+
+func main() {
+	if len(os.Args) != 2 || (os.Args[1] != "plan" && os.Args[1] != "apply") {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command := os.Args[1]
+
+	// Load configuration
+	cfg, err := config.LoadSettings()
+	if err != nil {
+		logger.Error("Failed to load settings", "error", err)
+		os.Exit(1)
+	}
+
+	// Register event types from every domain
+	eventRegistry, err := domain.NewEventRegistry()
+	if err != nil {
+		logger.Error("Failed to register event types", "error", err)
+		os.Exit(1)
+	}
+
+	// Derive the infrastructure from the config and registry
+	spec, err := provision.NewSpec(cfg.Events, eventRegistry)
+	if err != nil {
+		logger.Error("Failed to build infrastructure spec", "error", err)
+		os.Exit(1)
+	}
+
+	// Initialize AWS clients
+	awsSession, err := awsUtils.NewSession(cfg.AWS)
+	if err != nil {
+		logger.Error("Failed to initialize AWS session", "error", err)
+		os.Exit(1)
+	}
+	provisioner := provision.NewProvisioner(sqs.New(awsSession), sns.New(awsSession))
+
+	ctx := context.Background()
+
+	plan, err := provisioner.Plan(ctx, spec)
+	if err != nil {
+		logger.Error("Failed to plan infrastructure changes", "error", err)
+		os.Exit(1)
+	}
+	if err := plan.Write(os.Stdout); err != nil {
+		logger.Error("Failed to write plan", "error", err)
+		os.Exit(1)
+	}
+
+	if command == "plan" || plan.Empty() {
+		return
+	}
+
+	if err := provisioner.Apply(ctx, plan); err != nil {
+		logger.Error("Failed to apply infrastructure changes", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("Infrastructure is up to date")
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/cgund98/go-postgres-api-template/internal/config"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/db/postgres"
+	"github.com/cgund98/go-postgres-api-template/internal/observability"
+	"github.com/cgund98/go-postgres-api-template/resources/db/migrations"
+)
+
+var logger = observability.Logger
+
+const usage = `Usage: migrate <command> [args]
+
+Applies the SQL migrations embedded from resources/db/migrations to DATABASE_URL.
+An advisory lock keeps concurrent runs, including the API's auto-migrate, from racing.
+
+Commands:
+  up                Apply every pending migration
+  down [n]          Roll back the last n migrations (default 1)
+  version           Show the current migration version
+  force <version>   Record the version after fixing a failed migration by hand (-1 for none)
+  create <name>     Create the next pair of up and down files in resources/db/migrations
+`
+
+// migrationsDir is where create writes new migrations, relative to the repository root
+const migrationsDir = "resources/db/migrations"
+
+func main() {
+	if len(os.Args) < 2 {
+		exitWithUsage()
+	}
+	command, args := os.Args[1], os.Args[2:]
+
+	if command == "create" {
+		if len(args) != 1 {
+			exitWithUsage()
+		}
+		if err := create(migrationsDir, args[0]); err != nil {
+			logger.Error("Failed to create migration", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Load configuration
+	cfg, err := config.LoadSettings()
+	if err != nil {
+		logger.Error("Failed to load settings", "error", err)
+		os.Exit(1)
+	}
+
+	// Initialize database
+	dbPool, err := postgres.NewPool(cfg.Database.URL, postgres.PoolOptionsFromConfig(cfg.Database, "migrate"))
+	if err != nil {
+		logger.Error("Failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+	defer dbPool.Close()
+
+	migrator, err := postgres.NewMigrator(dbPool.Pool(), migrations.FS)
+	if err != nil {
+		logger.Error("Failed to load migrations", "error", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	switch {
+	case command == "up" && len(args) == 0:
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			logger.Error("Failed to apply migrations", "error", err)
+			os.Exit(1)
+		}
+		logger.Info("Applied migrations", "count", applied)
+
+	case command == "down" && len(args) <= 1:
+		steps := 1
+		if len(args) == 1 {
+			if steps, err = strconv.Atoi(args[0]); err != nil || steps < 1 {
+				exitWithUsage()
+			}
+		}
+		rolledBack, err := migrator.Down(ctx, steps)
+		if err != nil {
+			logger.Error("Failed to roll back migrations", "error", err)
+			os.Exit(1)
+		}
+		logger.Info("Rolled back migrations", "count", rolledBack)
+
+	case command == "version" && len(args) == 0:
+		version, dirty, err := migrator.Version(ctx)
+		if err != nil {
+			logger.Error("Failed to read migration version", "error", err)
+			os.Exit(1)
+		}
+		if dirty {
+			fmt.Printf("%d (dirty)\n", version)
+		} else {
+			fmt.Println(version)
+		}
+
+	case command == "force" && len(args) == 1:
+		version, err := strconv.Atoi(args[0])
+		if err != nil || version < postgres.NoVersion {
+			exitWithUsage()
+		}
+		if err := migrator.Force(ctx, version); err != nil {
+			logger.Error("Failed to force migration version", "error", err)
+			os.Exit(1)
+		}
+		logger.Info("Forced migration version", "version", version)
+
+	default:
+		exitWithUsage()
+	}
+}
+
+func exitWithUsage() {
+	fmt.Fprint(os.Stderr, usage)
+	os.Exit(2)
+}
+
+// migrationVersion matches the version prefix of a migration file name
+var migrationVersion = regexp.MustCompile(`^(\d+)_`)
+
+// create writes empty up and down files numbered after the highest existing version
+func create(dir, name string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	next, width := 1, 3
+	for _, entry := range entries {
+		match := migrationVersion.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return err
+		}
+		next = max(next, version+1)
+		width = max(width, len(match[1]))
+	}
+
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(dir, fmt.Sprintf("%0*d_%s.%s.sql", width, next, name, direction))
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			return err
+		}
+		fmt.Println(path)
+	}
+	return nil
+}
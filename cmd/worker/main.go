@@ -2,20 +2,28 @@ package main
 
 import (
 	"context"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
 	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/go-chi/chi/v5"
 
 	"github.com/cgund98/go-postgres-api-template/internal/config"
-	userEvents "github.com/cgund98/go-postgres-api-template/internal/domain/user/events"
-	"github.com/cgund98/go-postgres-api-template/internal/domain/user/events/handlers"
+	"github.com/cgund98/go-postgres-api-template/internal/domain"
 	awsUtils "github.com/cgund98/go-postgres-api-template/internal/infrastructure/aws"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/db/postgres"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
 	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/consumer"
-	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/deserializer"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/publisher"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/scheduler"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/schema"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/serializer"
 	"github.com/cgund98/go-postgres-api-template/internal/observability"
+	"github.com/cgund98/go-postgres-api-template/internal/presentation/worker"
 )
 
 var logger = observability.Logger
@@ -31,6 +39,21 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Register event types from every domain
+	eventRegistry, err := domain.NewEventRegistry()
+	if err != nil {
+		logger.Error("Failed to register event types", "error", err)
+		os.Exit(1)
+	}
+
+	// Initialize database
+	dbPool, err := postgres.NewPool(cfg.Database.URL, postgres.PoolOptionsFromConfig(cfg.Database, "worker"))
+	if err != nil {
+		logger.Error("Failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+	defer dbPool.Close()
+
 	// Initialize AWS clients
 	awsSession, err := awsUtils.NewSession(cfg.AWS)
 	if err != nil {
@@ -38,39 +61,122 @@ func main() {
 		os.Exit(1)
 	}
 	sqsClient := sqs.New(awsSession)
+	snsClient := sns.New(awsSession)
 
-	// Register event handlers
-	userCreatedHandler := handlers.NewUserCreatedHandler()
-	userUpdatedHandler := handlers.NewUserUpdatedHandler()
-	userDeletedHandler := handlers.NewUserDeletedHandler()
+	// Generate schemas for event payload validation, switched on per direction in the config.
+	// Consumed events may carry fields added by a newer producer, published ones must match their schema exactly.
+	var consumeValidator *schema.Validator
+	if cfg.Events.Validation.Consume {
+		consumeValidator = schema.NewValidator(eventRegistry, schema.ValidatorOptions{AllowUnknownFields: true})
+	}
+	var eventSerializer serializer.Serializer = serializer.NewJSONSerializer()
+	if cfg.Events.Validation.Publish {
+		eventSerializer = serializer.NewValidatingSerializer(eventSerializer, schema.NewValidator(eventRegistry, schema.ValidatorOptions{}))
+	}
 
-	// Create consumers
-	userCreatedConsumer := consumer.NewSQSConsumer[*userEvents.UserCreatedEvent](sqsClient, consumer.SQSConsumerOptions{
-		QueueURL:            cfg.Events.QueueURLUserCreated,
-		MaxNumberOfMessages: aws.Int64(1),
-	})
-	userUpdatedConsumer := consumer.NewSQSConsumer[*userEvents.UserUpdatedEvent](sqsClient, consumer.SQSConsumerOptions{
-		QueueURL:            cfg.Events.QueueURLUserUpdated,
-		MaxNumberOfMessages: aws.Int64(1),
-	})
-	userDeletedConsumer := consumer.NewSQSConsumer[*userEvents.UserDeletedEvent](sqsClient, consumer.SQSConsumerOptions{
-		QueueURL:            cfg.Events.QueueURLUserDeleted,
-		MaxNumberOfMessages: aws.Int64(1),
+	// Initialize event publisher, used to deliver delayed events once they are due
+	eventPub, err := publisher.NewRoutingPublisher(routerOptions(cfg.Events), func(topicARN string) publisher.Publisher {
+		return publisher.NewSNSPublisher(topicARN, eventSerializer, snsClient)
 	})
+	if err != nil {
+		logger.Error("Failed to initialize event publisher", "error", err)
+		os.Exit(1)
+	}
+	txManager := postgres.NewTransactionManager(dbPool.Pool(), postgres.TransactionManagerOptionsFromConfig(cfg.Database))
+
+	// Register event handlers by name so subscriptions can refer to them
+	handlerRegistry, err := domain.NewHandlerRegistry()
+	if err != nil {
+		logger.Error("Failed to register event handlers", "error", err)
+		os.Exit(1)
+	}
+
+	// Register event filters by name so subscriptions can refer to them
+	filterRegistry, err := domain.NewFilterRegistry()
+	if err != nil {
+		logger.Error("Failed to register event filters", "error", err)
+		os.Exit(1)
+	}
+
+	// Create a consumer for each subscription in the config file
+	subscriptions, err := newSubscriptions(cfg.Events.Subscriptions, sqsClient, eventRegistry, handlerRegistry, filterRegistry, consumeValidator, txManager)
+	if err != nil {
+		logger.Error("Failed to create subscriptions", "error", err)
+		os.Exit(1)
+	}
 
-	// Create deserializers
-	userCreatedDeserializer := deserializer.NewJSONDeserializer[*userEvents.UserCreatedEvent]()
-	userUpdatedDeserializer := deserializer.NewJSONDeserializer[*userEvents.UserUpdatedEvent]()
-	userDeletedDeserializer := deserializer.NewJSONDeserializer[*userEvents.UserDeletedEvent]()
+	// Create an SNS HTTP/S endpoint for each push subscription, accepting messages from the topics events are routed to.
+	// LocalStack doesn't sign messages with AWS certificates, so signatures are only verified against AWS.
+	eventRouter, err := publisher.NewRouter(routerOptions(cfg.Events))
+	if err != nil {
+		logger.Error("Failed to initialize event routes", "error", err)
+		os.Exit(1)
+	}
+	pushSubscriptions, err := newPushSubscriptions(cfg.Events.PushSubscriptions, eventRouter.TopicARNs(), !cfg.AWS.UseLocalstack, eventRegistry, handlerRegistry, consumeValidator)
+	if err != nil {
+		logger.Error("Failed to create push subscriptions", "error", err)
+		os.Exit(1)
+	}
+
+	if len(subscriptions) == 0 && len(pushSubscriptions) == 0 {
+		logger.Warn("No event subscriptions configured")
+	}
+
+	// Track consumers so the admin endpoints can inspect and control them
+	consumers := consumer.NewGroup()
+	for _, sub := range subscriptions {
+		if err := consumers.Add(sub.controllers()...); err != nil {
+			logger.Error("Failed to register consumers", "error", err)
+			os.Exit(1)
+		}
+	}
 
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	// Start consuming messages
-	userCreatedConsumer.Start(ctx, userCreatedDeserializer, userCreatedHandler)
-	userUpdatedConsumer.Start(ctx, userUpdatedDeserializer, userUpdatedHandler)
-	userDeletedConsumer.Start(ctx, userDeletedDeserializer, userDeletedHandler)
+	for _, sub := range subscriptions {
+		sub.start(ctx)
+	}
+
+	// Start delivering delayed events
+	scheduler.NewPromoter(scheduler.NewPostgresStore(), eventPub, txManager, scheduler.PromoterOptions{}).Start(ctx)
+	if cfg.Events.DelayQueueURL != "" {
+		delayConsumer := consumer.NewSQSConsumer[*events.RawEvent](sqsClient, consumer.SQSConsumerOptions{
+			Name:     "event-delay",
+			QueueURL: cfg.Events.DelayQueueURL,
+		})
+		if err := consumers.Add(delayConsumer); err != nil {
+			logger.Error("Failed to register consumers", "error", err)
+			os.Exit(1)
+		}
+		delayConsumer.Start(ctx, scheduler.NewEnvelopeDeserializer(), scheduler.NewForwardHandler(eventPub))
+	}
+
+	// Serve operational endpoints (metrics, probes and consumer admin) over HTTP
+	router := chi.NewRouter()
+	router.Handle("/metrics", observability.MetricsHandler())
+	router.Get("/debug/db", dbPool.StatsHandler())
+	for _, sub := range pushSubscriptions {
+		router.Method(http.MethodPost, sub.path, sub.handler)
+	}
+
+	maxPollAge := time.Duration(cfg.Worker.ReadinessMaxPollAgeSeconds) * time.Second
+	worker.NewController(consumers, worker.ControllerOptions{MaxPollAge: &maxPollAge}).RegisterRoutes(router)
+
+	server := &http.Server{
+		Addr:    ":" + cfg.Worker.Port,
+		Handler: router,
+	}
+
+	go func() {
+		logger.Info("Worker HTTP server starting on port", "port", cfg.Worker.Port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("Worker HTTP server failed to start", "error", err)
+			os.Exit(1)
+		}
+	}()
 
 	// Wait for interrupt signal to gracefully shutdown
 	quit := make(chan os.Signal, 1)
@@ -78,4 +184,21 @@ func main() {
 	<-quit
 
 	logger.Info("Shutting down worker...")
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Worker HTTP server forced to shutdown", "error", err)
+	}
+}
+
+// routerOptions maps the routes in the events config to publisher routes.
+// Without routes, every event goes to the default topic.
+func routerOptions(cfg config.EventsConfig) publisher.RouterOptions {
+	routes := make([]publisher.Route, len(cfg.Routes))
+	for i, route := range cfg.Routes {
+		routes[i] = publisher.Route{Pattern: route.Pattern, TopicARN: route.TopicARN}
+	}
+	return publisher.RouterOptions{Routes: routes, DefaultTopicARN: &cfg.TopicARN}
 }
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cgund98/go-postgres-api-template/internal/config"
+	awsUtils "github.com/cgund98/go-postgres-api-template/internal/infrastructure/aws"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/db"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/consumer"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/deserializer"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/ordering"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/provision"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/schema"
+)
+
+// subscription is a consumer wired from a subscription in the config file.
+// Subscriptions with weighted queues are consumed by a priority consumer instead.
+type subscription struct {
+	consumer     *consumer.SQSConsumer[events.Event]
+	priority     *consumer.PriorityConsumer[events.Event]
+	deserializer deserializer.Deserializer[events.Event]
+	handler      events.Handler[events.Event]
+}
+
+// newSubscriptions builds a consumer for each configured subscription.
+// Every subscription is validated before any consumer is started, so a typo in
+// the config file fails the worker at startup instead of leaving a queue unconsumed.
+// Non-retryable failures, such as payloads rejected by the validator, go to the queue's
+// dead-letter queue. A nil validator disables schema validation.
+// Ordered subscriptions track the sequence numbers they handled in the database through the transaction manager.
+func newSubscriptions(
+	cfgs []config.SubscriptionConfig,
+	sqsClient awsUtils.SQSClientInterface,
+	eventRegistry *events.Registry,
+	handlerRegistry *events.HandlerRegistry,
+	filterRegistry *events.FilterRegistry,
+	validator *schema.Validator,
+	txManager db.TransactionManager,
+) ([]*subscription, error) {
+	subscriptions := make([]*subscription, 0, len(cfgs))
+
+	for _, cfg := range cfgs {
+		if err := cfg.Validate(); err != nil {
+			return nil, err
+		}
+
+		handler, eventDeserializer, err := resolveHandler(cfg.Handler, cfg.EventTypes, eventRegistry, handlerRegistry, validator)
+		if err != nil {
+			return nil, fmt.Errorf("subscription %s: %w", cfg.Name, err)
+		}
+
+		if cfg.Ordered {
+			var gapTimeout *time.Duration
+			if cfg.GapTimeoutSeconds > 0 {
+				duration := time.Duration(cfg.GapTimeoutSeconds) * time.Second
+				gapTimeout = &duration
+			}
+			handler = ordering.NewHandler(handler, ordering.NewPostgresTracker(txManager, cfg.Name), ordering.HandlerOptions{
+				GapTimeout: gapTimeout,
+			})
+		}
+
+		var filter events.Filter
+		if cfg.Filter != "" {
+			filter, err = filterRegistry.Lookup(cfg.Filter)
+			if err != nil {
+				return nil, fmt.Errorf("subscription %s: %w", cfg.Name, err)
+			}
+		}
+
+		// Label queue-level metrics with the event type when the queue carries a single type
+		var eventType string
+		if len(cfg.EventTypes) == 1 {
+			eventType = cfg.EventTypes[0]
+		}
+
+		options := consumer.SQSConsumerOptions{
+			Name:                cfg.Name,
+			QueueURL:            cfg.QueueURL,
+			EventType:           eventType,
+			Concurrency:         positiveOrNil(cfg.Concurrency),
+			MaxNumberOfMessages: positiveOrNil(cfg.BatchSize),
+			VisibilityTimeout:   positiveOrNil(cfg.VisibilityTimeout),
+			WaitTimeSeconds:     positiveOrNil(cfg.WaitTimeSeconds),
+			MaxReceiveCount:     positiveOrNil(cfg.MaxReceiveCount),
+			DeadLetterQueueURL:  provision.DeadLetterQueueURL(cfg.QueueURL),
+			Filter:              filter,
+			Autoscaling:         autoscalingOptions(cfg.Autoscaling),
+		}
+
+		sub := &subscription{
+			deserializer: eventDeserializer,
+			handler:      handler,
+		}
+		if len(cfg.Queues) == 0 {
+			sub.consumer = consumer.NewSQSConsumer[events.Event](sqsClient, options)
+		} else {
+			sub.priority, err = newPriorityConsumer(cfg, options, sqsClient)
+			if err != nil {
+				return nil, fmt.Errorf("subscription %s: %w", cfg.Name, err)
+			}
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+
+	return subscriptions, nil
+}
+
+// newPriorityConsumer builds a consumer polling the subscription's weighted queues.
+// Each queue's consumer is named after its queue and otherwise shares the subscription's options.
+func newPriorityConsumer(
+	cfg config.SubscriptionConfig,
+	options consumer.SQSConsumerOptions,
+	sqsClient awsUtils.SQSClientInterface,
+) (*consumer.PriorityConsumer[events.Event], error) {
+	queues := make([]consumer.PriorityQueue, len(cfg.Queues))
+	for i, queue := range cfg.Queues {
+		queueOptions := options
+		queueOptions.Name = ""
+		queueOptions.QueueURL = queue.QueueURL
+		queueOptions.DeadLetterQueueURL = provision.DeadLetterQueueURL(queue.QueueURL)
+		if queue.BatchSize > 0 {
+			queueOptions.MaxNumberOfMessages = &queue.BatchSize
+		}
+		queues[i] = consumer.PriorityQueue{Weight: queue.Weight, Options: queueOptions}
+	}
+
+	var maxStarvation *time.Duration
+	if cfg.MaxStarvationSeconds > 0 {
+		duration := time.Duration(cfg.MaxStarvationSeconds) * time.Second
+		maxStarvation = &duration
+	}
+
+	return consumer.NewPriorityConsumer[events.Event](sqsClient, consumer.PriorityConsumerOptions{
+		Queues:        queues,
+		Concurrency:   positiveOrNil(cfg.Concurrency),
+		MaxStarvation: maxStarvation,
+	})
+}
+
+// autoscalingOptions converts the autoscaling config, or returns nil when autoscaling is disabled
+func autoscalingOptions(cfg config.AutoscalingConfig) *consumer.AutoscalingOptions {
+	if !cfg.Enabled() {
+		return nil
+	}
+
+	options := &consumer.AutoscalingOptions{
+		MinConcurrency: cfg.MinConcurrency,
+		MaxConcurrency: cfg.MaxConcurrency,
+	}
+	if cfg.TargetDrainSeconds > 0 {
+		drainTime := time.Duration(cfg.TargetDrainSeconds) * time.Second
+		options.TargetDrainTime = &drainTime
+	}
+	return options
+}
+
+// pushSubscription is an SNS HTTP/S endpoint wired from a push subscription in the config file
+type pushSubscription struct {
+	path    string
+	handler http.Handler
+}
+
+// newPushSubscriptions builds an SNS HTTP/S endpoint for each configured push subscription.
+// Only messages from the given topics are accepted. A nil validator disables schema validation.
+func newPushSubscriptions(
+	cfgs []config.PushSubscriptionConfig,
+	topicARNs []string,
+	verifySignatures bool,
+	eventRegistry *events.Registry,
+	handlerRegistry *events.HandlerRegistry,
+	validator *schema.Validator,
+) ([]*pushSubscription, error) {
+	subscriptions := make([]*pushSubscription, 0, len(cfgs))
+
+	for _, cfg := range cfgs {
+		if err := cfg.Validate(); err != nil {
+			return nil, err
+		}
+
+		handler, eventDeserializer, err := resolveHandler(cfg.Handler, cfg.EventTypes, eventRegistry, handlerRegistry, validator)
+		if err != nil {
+			return nil, fmt.Errorf("push subscription %s: %w", cfg.Name, err)
+		}
+
+		subscriptions = append(subscriptions, &pushSubscription{
+			path: cfg.Path,
+			handler: consumer.NewSNSHTTPHandler(eventDeserializer, handler, consumer.SNSHTTPHandlerOptions{
+				Name:             cfg.Name,
+				TopicARNs:        topicARNs,
+				VerifySignatures: &verifySignatures,
+			}),
+		})
+	}
+
+	return subscriptions, nil
+}
+
+// resolveHandler looks up the named handler and builds a deserializer for the event types,
+// making sure every event type is registered and accepted by the handler.
+// The deserializer validates payloads first when a validator is given.
+func resolveHandler(
+	name string,
+	eventTypes []string,
+	eventRegistry *events.Registry,
+	handlerRegistry *events.HandlerRegistry,
+	validator *schema.Validator,
+) (events.Handler[events.Event], deserializer.Deserializer[events.Event], error) {
+	registrations := make([]events.Registration, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		registration, err := eventRegistry.Lookup(eventType)
+		if err != nil {
+			return nil, nil, err
+		}
+		registrations = append(registrations, registration)
+	}
+	if err := handlerRegistry.Validate(name, registrations...); err != nil {
+		return nil, nil, err
+	}
+
+	handler, err := handlerRegistry.Lookup(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	registryDeserializer, err := deserializer.NewRegistryDeserializer[events.Event](eventRegistry, eventTypes...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if validator != nil {
+		return handler, deserializer.NewValidatingDeserializer[events.Event](registryDeserializer, validator), nil
+	}
+	return handler, registryDeserializer, nil
+}
+
+// start starts consuming the subscription's queues
+func (s *subscription) start(ctx context.Context) {
+	if s.priority != nil {
+		s.priority.Start(ctx, s.deserializer, s.handler)
+		return
+	}
+	s.consumer.Start(ctx, s.deserializer, s.handler)
+}
+
+// controllers returns the consumer of each of the subscription's queues
+func (s *subscription) controllers() []consumer.Controller {
+	if s.priority == nil {
+		return []consumer.Controller{s.consumer}
+	}
+	var controllers []consumer.Controller
+	for _, queueConsumer := range s.priority.Consumers() {
+		controllers = append(controllers, queueConsumer)
+	}
+	return controllers
+}
+
+// positiveOrNil returns a pointer to the value, or nil so the consumer default is used when it is not set
+func positiveOrNil[V int | int64](value V) *V {
+	if value <= 0 {
+		return nil
+	}
+	return &value
+}
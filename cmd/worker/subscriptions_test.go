@@ -0,0 +1,233 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+
+	"github.com/cgund98/go-postgres-api-template/internal/config"
+	"github.com/cgund98/go-postgres-api-template/internal/domain"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/ordering"
+)
+
+const (
+	testQueueURL     = "https://sqs.us-east-1.amazonaws.com/123456789/user-created"
+	testBulkQueueURL = "https://sqs.us-east-1.amazonaws.com/123456789/user-created-bulk"
+)
+
+func TestNewSubscriptions(t *testing.T) {
+	tests := []struct {
+		name              string
+		cfg               config.SubscriptionConfig
+		expectedNames     []string
+		expectedQueueURLs []string
+		expectedEventType string
+		expectedOrdered   bool
+		expectedError     string
+	}{
+		{
+			name: "maps a subscription to a consumer of its queue",
+			cfg: config.SubscriptionConfig{
+				Name:        "user-created",
+				QueueURL:    testQueueURL,
+				EventTypes:  []string{"user.created"},
+				Handler:     "user-created",
+				Concurrency: 4,
+				BatchSize:   10,
+			},
+			expectedNames:     []string{"user-created"},
+			expectedQueueURLs: []string{testQueueURL},
+			expectedEventType: "user.created",
+		},
+		{
+			name: "maps weighted queues to a priority consumer named after each queue",
+			cfg: config.SubscriptionConfig{
+				Name:       "user-created",
+				EventTypes: []string{"user.created"},
+				Handler:    "user-created",
+				Queues: []config.PriorityQueueConfig{
+					{QueueURL: testQueueURL, Priority: "default", Weight: 4},
+					{QueueURL: testBulkQueueURL, Priority: "bulk", Weight: 1, BatchSize: 1},
+				},
+			},
+			expectedNames:     []string{"user-created", "user-created-bulk"},
+			expectedQueueURLs: []string{testQueueURL, testBulkQueueURL},
+			expectedEventType: "user.created",
+		},
+		{
+			name: "resolves the filter by name",
+			cfg: config.SubscriptionConfig{
+				Name:       "user-email-changed",
+				QueueURL:   testQueueURL,
+				EventTypes: []string{"user.updated"},
+				Handler:    "user-updated",
+				Filter:     "user-email-changed",
+			},
+			expectedNames:     []string{"user-email-changed"},
+			expectedQueueURLs: []string{testQueueURL},
+			expectedEventType: "user.updated",
+		},
+		{
+			name: "wraps the handler of ordered subscriptions",
+			cfg: config.SubscriptionConfig{
+				Name:       "user-created",
+				QueueURL:   testQueueURL,
+				EventTypes: []string{"user.created"},
+				Handler:    "user-created",
+				Ordered:    true,
+			},
+			expectedNames:     []string{"user-created"},
+			expectedQueueURLs: []string{testQueueURL},
+			expectedEventType: "user.created",
+			expectedOrdered:   true,
+		},
+		{
+			name: "rejects a subscription without a queue",
+			cfg: config.SubscriptionConfig{
+				Name:       "user-created",
+				EventTypes: []string{"user.created"},
+				Handler:    "user-created",
+			},
+			expectedError: "missing a queue_url",
+		},
+		{
+			name: "rejects an unknown event type",
+			cfg: config.SubscriptionConfig{
+				Name:       "user-created",
+				QueueURL:   testQueueURL,
+				EventTypes: []string{"user.renamed"},
+				Handler:    "user-created",
+			},
+			expectedError: events.ErrEventTypeNotRegistered.Error(),
+		},
+		{
+			name: "rejects an unknown handler",
+			cfg: config.SubscriptionConfig{
+				Name:       "user-created",
+				QueueURL:   testQueueURL,
+				EventTypes: []string{"user.created"},
+				Handler:    "user-renamed",
+			},
+			expectedError: events.ErrHandlerNotRegistered.Error(),
+		},
+		{
+			name: "rejects a handler that doesn't accept the event types",
+			cfg: config.SubscriptionConfig{
+				Name:       "user-created",
+				QueueURL:   testQueueURL,
+				EventTypes: []string{"user.created", "user.deleted"},
+				Handler:    "user-created",
+			},
+			expectedError: events.ErrHandlerTypeMismatch.Error(),
+		},
+		{
+			name: "rejects an unknown filter",
+			cfg: config.SubscriptionConfig{
+				Name:       "user-created",
+				QueueURL:   testQueueURL,
+				EventTypes: []string{"user.created"},
+				Handler:    "user-created",
+				Filter:     "user-renamed",
+			},
+			expectedError: events.ErrFilterNotRegistered.Error(),
+		},
+	}
+
+	eventRegistry, err := domain.NewEventRegistry()
+	if err != nil {
+		t.Fatalf("failed to register event types: %v", err)
+	}
+	handlerRegistry, err := domain.NewHandlerRegistry()
+	if err != nil {
+		t.Fatalf("failed to register handlers: %v", err)
+	}
+	filterRegistry, err := domain.NewFilterRegistry()
+	if err != nil {
+		t.Fatalf("failed to register filters: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subscriptions, err := newSubscriptions([]config.SubscriptionConfig{tt.cfg}, nil, eventRegistry, handlerRegistry, filterRegistry, nil, nil)
+
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Errorf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(subscriptions) != 1 {
+				t.Fatalf("expected 1 subscription, got %d", len(subscriptions))
+			}
+
+			sub := subscriptions[0]
+			var names, queueURLs []string
+			for _, controller := range sub.controllers() {
+				status := controller.Status()
+				names = append(names, status.Name)
+				queueURLs = append(queueURLs, status.QueueURL)
+				if status.EventType != tt.expectedEventType {
+					t.Errorf("expected event type %q, got %q", tt.expectedEventType, status.EventType)
+				}
+			}
+			if !slices.Equal(names, tt.expectedNames) {
+				t.Errorf("expected consumers %v, got %v", tt.expectedNames, names)
+			}
+			if !slices.Equal(queueURLs, tt.expectedQueueURLs) {
+				t.Errorf("expected queues %v, got %v", tt.expectedQueueURLs, queueURLs)
+			}
+
+			_, ordered := sub.handler.(*ordering.Handler[events.Event])
+			if ordered != tt.expectedOrdered {
+				t.Errorf("expected ordered %t, got %t", tt.expectedOrdered, ordered)
+			}
+		})
+	}
+}
+
+func TestPositiveOrNil(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    int64
+		expected *int64
+	}{
+		{
+			name:  "returns nil for zero so the default is used",
+			value: 0,
+		},
+		{
+			name:  "returns nil for negative values",
+			value: -5,
+		},
+		{
+			name:     "returns positive values",
+			value:    20,
+			expected: aws.Int64(20),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := positiveOrNil(tt.value)
+			switch {
+			case tt.expected == nil && actual != nil:
+				t.Errorf("expected nil, got %d", *actual)
+			case tt.expected != nil && actual == nil:
+				t.Errorf("expected %d, got nil", *tt.expected)
+			case tt.expected != nil && *actual != *tt.expected:
+				t.Errorf("expected %d, got %d", *tt.expected, *actual)
+			}
+
+			// The same applies to int fields such as concurrency
+			if tt.expected == nil && positiveOrNil(int(tt.value)) != nil {
+				t.Errorf("expected nil for int %d", tt.value)
+			}
+		})
+	}
+}
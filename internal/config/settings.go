@@ -13,6 +13,9 @@ import (
 
 var logger = observability.Logger
 
+// defaultConfigFile is the config file loaded from the working directory when CONFIG_FILE is not set
+const defaultConfigFile = "config.yaml"
+
 // Config holds the application configuration
 type Config struct {
 	Database DatabaseConfig `mapstructure:"database"`
@@ -23,11 +26,54 @@ type Config struct {
 
 	Server ServerConfig `mapstructure:"server"`
 
+	Worker WorkerConfig `mapstructure:"worker"`
+
 	Environment string `mapstructure:"environment"`
 }
 
+// DatabaseConfig configures the Postgres connection pool.
+// Zero values fall back to the pool defaults.
 type DatabaseConfig struct {
 	URL string `mapstructure:"url"`
+
+	// MaxOpenConns caps the connections of each process. Multiply by the number of API
+	// and worker replicas to check the total stays below the server's max_connections.
+	MaxOpenConns int `mapstructure:"max_open_conns"`
+
+	// MinIdleConns keeps connections open ahead of bursts. Defaults to none.
+	MinIdleConns int `mapstructure:"min_idle_conns"`
+
+	ConnMaxLifetimeSeconds int `mapstructure:"conn_max_lifetime_seconds"`
+	ConnMaxIdleTimeSeconds int `mapstructure:"conn_max_idle_time_seconds"`
+	ConnectTimeoutSeconds  int `mapstructure:"connect_timeout_seconds"`
+
+	// ApplicationName is shown in pg_stat_activity. Defaults to the name of the binary (api or worker).
+	ApplicationName string `mapstructure:"application_name"`
+
+	// SlowQueryMilliseconds is how long a statement runs before it is logged as slow
+	SlowQueryMilliseconds int `mapstructure:"slow_query_milliseconds"`
+
+	// ExplainSlowQueries logs the plan of slow statements by running them again under EXPLAIN ANALYZE.
+	// For development only.
+	ExplainSlowQueries bool `mapstructure:"explain_slow_queries"`
+
+	// ExplainSlowWrites extends ExplainSlowQueries to INSERT, UPDATE and DELETE statements and data-modifying WITH queries.
+	// Replaying a write consumes sequence values, waits on row locks and can hit unique violations. For development only.
+	ExplainSlowWrites bool `mapstructure:"explain_slow_writes"`
+
+	// ReplicaURLs are read replicas that read-only transactions are sent to. Writes always go to URL.
+	ReplicaURLs []string `mapstructure:"replica_urls"`
+
+	// ReadYourWritesSeconds sends a client's reads to the primary for this long after it writes,
+	// so it doesn't read stale data from a lagging replica. 0 disables it.
+	ReadYourWritesSeconds int `mapstructure:"read_your_writes_seconds"`
+
+	// TxMaxAttempts is how many times a transaction is run when it hits a serialization failure or deadlock.
+	// 1 disables retries.
+	TxMaxAttempts int `mapstructure:"tx_max_attempts"`
+
+	// AutoMigrate applies pending migrations when the API starts
+	AutoMigrate bool `mapstructure:"auto_migrate"`
 }
 
 type AWSConfig struct {
@@ -37,14 +83,238 @@ type AWSConfig struct {
 }
 
 type EventsConfig struct {
-	TopicARN            string `mapstructure:"events_topic_arn"`
-	QueueURLUserCreated string `mapstructure:"queue_url_user_created"`
-	QueueURLUserUpdated string `mapstructure:"queue_url_user_updated"`
-	QueueURLUserDeleted string `mapstructure:"queue_url_user_deleted"`
+	// TopicARN is the default topic, used for every event when no routes are configured
+	TopicARN string `mapstructure:"events_topic_arn"`
+
+	// Routes map event type patterns to topics, loaded from the config file
+	Routes []RouteConfig `mapstructure:"routes"`
+
+	// DelayQueueURL is the SQS queue used for events delayed up to 15 minutes.
+	// When it is empty, every delayed event goes to the scheduled_events table instead.
+	DelayQueueURL string `mapstructure:"delay_queue_url"`
+
+	// Subscriptions declares the queues the worker consumes, loaded from the config file
+	Subscriptions []SubscriptionConfig `mapstructure:"subscriptions"`
+
+	// Validation checks event payloads against the schemas generated from the event structs
+	Validation ValidationConfig `mapstructure:"validation"`
+
+	// Snapshots controls the user state carried by user events
+	Snapshots SnapshotConfig `mapstructure:"snapshots"`
+
+	// PushSubscriptions declares the SNS HTTP/S subscriptions the worker serves, loaded from the config file
+	PushSubscriptions []PushSubscriptionConfig `mapstructure:"push_subscriptions"`
+}
+
+// RouteConfig sends events whose type matches Pattern (e.g. "user.*") to a topic
+type RouteConfig struct {
+	Pattern string `mapstructure:"pattern"`
+
+	// TopicARN may reference environment variables, e.g. ${EVENTS_TOPIC_ARN}
+	TopicARN string `mapstructure:"topic_arn"`
+}
+
+// SubscriptionConfig wires an SQS queue to a named event handler.
+// Zero values fall back to the consumer defaults.
+type SubscriptionConfig struct {
+	// Name identifies the consumer in logs, metrics and the worker admin endpoints
+	Name string `mapstructure:"name"`
+
+	// QueueURL may reference environment variables, e.g. ${EVENTS_QUEUE_URL_USER_CREATED}
+	QueueURL string `mapstructure:"queue_url"`
+
+	// Queues replaces QueueURL with several queues carrying the same events, polled by weight
+	// so traffic on a low-priority queue can't delay a high-priority one
+	Queues []PriorityQueueConfig `mapstructure:"queues"`
+
+	// MaxStarvationSeconds is the longest a weighted queue goes without being polled. Only used with Queues.
+	MaxStarvationSeconds int `mapstructure:"max_starvation_seconds"`
+
+	// EventTypes are the registered event types delivered to the queue
+	EventTypes []string `mapstructure:"event_types"`
+
+	// Handler is the name the handler was registered under in the handler registry
+	Handler string `mapstructure:"handler"`
+
+	// Filter is the name of a registered filter. Events it rejects are acked without being handled.
+	Filter string `mapstructure:"filter"`
+
+	// Ordered hands the handler each aggregate's events in sequence order, tracked per subscription
+	// in the processed_event_sequences table. Events after a gap are retried until the missing ones
+	// are handled, so the subscription must receive every event type of its aggregates.
+	Ordered bool `mapstructure:"ordered"`
+
+	// GapTimeoutSeconds is how long an ordered subscription waits for missing events before skipping them.
+	// Defaults to ordering.DefaultGapTimeout.
+	GapTimeoutSeconds int `mapstructure:"gap_timeout_seconds"`
+
+	Concurrency       int   `mapstructure:"concurrency"`
+	BatchSize         int64 `mapstructure:"batch_size"`
+	VisibilityTimeout int64 `mapstructure:"visibility_timeout"`
+	WaitTimeSeconds   int64 `mapstructure:"wait_time_seconds"`
+	MaxReceiveCount   int64 `mapstructure:"max_receive_count"`
+
+	// Autoscaling replaces Concurrency with a pool scaled between a min and a max with the queue backlog
+	Autoscaling AutoscalingConfig `mapstructure:"autoscaling"`
+}
+
+// Validate checks that the subscription has the fields needed to start a consumer
+func (s SubscriptionConfig) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("subscription is missing a name")
+	}
+	if s.QueueURL == "" && len(s.Queues) == 0 {
+		return fmt.Errorf("subscription %s is missing a queue_url", s.Name)
+	}
+	if s.QueueURL != "" && len(s.Queues) > 0 {
+		return fmt.Errorf("subscription %s sets both queue_url and queues", s.Name)
+	}
+	priorities := map[string]bool{}
+	for _, queue := range s.Queues {
+		if queue.QueueURL == "" {
+			return fmt.Errorf("subscription %s has a queue without a queue_url", s.Name)
+		}
+		if queue.Priority == "" {
+			return fmt.Errorf("subscription %s has a queue without a priority", s.Name)
+		}
+		if priorities[queue.Priority] {
+			return fmt.Errorf("subscription %s has several queues for priority %s", s.Name, queue.Priority)
+		}
+		priorities[queue.Priority] = true
+		if queue.Weight < 0 {
+			return fmt.Errorf("subscription %s queue weights must be positive", s.Name)
+		}
+		if queue.BatchSize < 0 || queue.BatchSize > 10 {
+			return fmt.Errorf("subscription %s queue batch_size must be between 1 and 10", s.Name)
+		}
+	}
+	if len(s.EventTypes) == 0 {
+		return fmt.Errorf("subscription %s has no event_types", s.Name)
+	}
+	if s.Handler == "" {
+		return fmt.Errorf("subscription %s is missing a handler", s.Name)
+	}
+	if s.BatchSize < 0 || s.BatchSize > 10 {
+		return fmt.Errorf("subscription %s batch_size must be between 1 and 10", s.Name)
+	}
+	if s.Autoscaling.Enabled() {
+		if len(s.Queues) > 0 {
+			return fmt.Errorf("subscription %s can't autoscale weighted queues", s.Name)
+		}
+		if s.Autoscaling.MinConcurrency > s.Autoscaling.MaxConcurrency {
+			return fmt.Errorf("subscription %s autoscaling min_concurrency is greater than max_concurrency", s.Name)
+		}
+	}
+	return nil
+}
+
+// AutoscalingConfig scales a subscription's consumer with the queue backlog and handler latency.
+// Autoscaling is enabled by setting MaxConcurrency.
+type AutoscalingConfig struct {
+	MinConcurrency int `mapstructure:"min_concurrency"`
+	MaxConcurrency int `mapstructure:"max_concurrency"`
+
+	// TargetDrainSeconds is how quickly the pool should be able to work through the backlog. Defaults to 30.
+	TargetDrainSeconds int `mapstructure:"target_drain_seconds"`
+}
+
+// Enabled reports whether autoscaling is configured
+func (a AutoscalingConfig) Enabled() bool {
+	return a.MaxConcurrency > 0
+}
+
+// PriorityQueueConfig is one of the weighted queues of a subscription.
+// Zero values fall back to the consumer defaults.
+type PriorityQueueConfig struct {
+	// QueueURL may reference environment variables, e.g. ${EVENTS_QUEUE_URL_USER_CREATED_BULK}
+	QueueURL string `mapstructure:"queue_url"`
+
+	// Priority is the event priority delivered to the queue, e.g. default or bulk. Every event goes to the
+	// one queue of the subscription matching the priority it was published with; see events.WithPriority.
+	Priority string `mapstructure:"priority"`
+
+	// Weight is the queue's share of polls while every queue has messages. Defaults to 1.
+	Weight int `mapstructure:"weight"`
+
+	// BatchSize bounds how long one batch from the queue holds a poller; keep it small for low-priority queues
+	BatchSize int64 `mapstructure:"batch_size"`
+}
+
+// QueueURLs returns the URL of every queue the subscription consumes
+func (s SubscriptionConfig) QueueURLs() []string {
+	if len(s.Queues) == 0 {
+		return []string{s.QueueURL}
+	}
+	urls := make([]string, len(s.Queues))
+	for i, queue := range s.Queues {
+		urls[i] = queue.QueueURL
+	}
+	return urls
+}
+
+// ValidationConfig switches schema validation of event payloads on for each direction
+type ValidationConfig struct {
+	// Publish rejects events that don't match their schema before they are published
+	Publish bool `mapstructure:"publish"`
+
+	// Consume sends consumed events that don't match their schema to the dead-letter queue
+	Consume bool `mapstructure:"consume"`
+}
+
+// SnapshotConfig enables event-carried state transfer: user events carry a snapshot of the user
+type SnapshotConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// Fields maps PII fields (email, first_name, last_name) to omit, hash or include. Unlisted fields are omitted.
+	Fields map[string]string `mapstructure:"fields"`
+}
+
+// PushSubscriptionConfig wires an SNS HTTP/S subscription endpoint on the worker to a named event handler
+type PushSubscriptionConfig struct {
+	// Name identifies the subscription in logs and metrics
+	Name string `mapstructure:"name"`
+
+	// Path is where the worker serves the endpoint, e.g. /sns/user-created
+	Path string `mapstructure:"path"`
+
+	// EventTypes are the registered event types delivered to the endpoint
+	EventTypes []string `mapstructure:"event_types"`
+
+	// Handler is the name the handler was registered under in the handler registry
+	Handler string `mapstructure:"handler"`
+}
+
+// Validate checks that the push subscription has the fields needed to serve it
+func (s PushSubscriptionConfig) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("push subscription is missing a name")
+	}
+	if !strings.HasPrefix(s.Path, "/") {
+		return fmt.Errorf("push subscription %s path must start with /", s.Name)
+	}
+	if len(s.EventTypes) == 0 {
+		return fmt.Errorf("push subscription %s has no event_types", s.Name)
+	}
+	if s.Handler == "" {
+		return fmt.Errorf("push subscription %s is missing a handler", s.Name)
+	}
+	return nil
 }
 
 type ServerConfig struct {
 	Port string `mapstructure:"port"`
+
+	// AdminPort is where the API serves its operational HTTP endpoints (e.g. /metrics, /debug/db).
+	// Keep it off the public ingress: the endpoints are unauthenticated.
+	AdminPort string `mapstructure:"admin_port"`
+}
+
+type WorkerConfig struct {
+	// Port is where the worker serves its operational HTTP endpoints (e.g. /metrics)
+	Port string `mapstructure:"port"`
+
+	// ReadinessMaxPollAgeSeconds is how long a consumer may go without a successful poll before /readyz fails
+	ReadinessMaxPollAgeSeconds int `mapstructure:"readiness_max_poll_age_seconds"`
 }
 
 // LoadConfig loads configuration from file, environment variables, or defaults
@@ -84,14 +354,24 @@ func LoadConfig() (*Config, error) {
 	if err := viper.BindEnv("events.events_topic_arn", "EVENTS_TOPIC_ARN"); err != nil {
 		return nil, fmt.Errorf("error binding env var EVENTS_TOPIC_ARN: %w", err)
 	}
-	if err := viper.BindEnv("events.queue_url_user_created", "EVENTS_QUEUE_URL_USER_CREATED"); err != nil {
-		return nil, fmt.Errorf("error binding env var EVENTS_QUEUE_URL_USER_CREATED: %w", err)
+	if err := viper.BindEnv("events.delay_queue_url", "EVENTS_DELAY_QUEUE_URL"); err != nil {
+		return nil, fmt.Errorf("error binding env var EVENTS_DELAY_QUEUE_URL: %w", err)
 	}
-	if err := viper.BindEnv("events.queue_url_user_updated", "EVENTS_QUEUE_URL_USER_UPDATED"); err != nil {
-		return nil, fmt.Errorf("error binding env var EVENTS_QUEUE_URL_USER_UPDATED: %w", err)
+
+	// Load the config file (if it exists) for structured settings that don't fit in
+	// environment variables, such as event subscriptions. CONFIG_FILE overrides the path.
+	configFile := defaultConfigFile
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		configFile = path
 	}
-	if err := viper.BindEnv("events.queue_url_user_deleted", "EVENTS_QUEUE_URL_USER_DELETED"); err != nil {
-		return nil, fmt.Errorf("error binding env var EVENTS_QUEUE_URL_USER_DELETED: %w", err)
+	if _, err := os.Stat(configFile); err == nil {
+		viper.SetConfigFile(configFile)
+		if err := viper.MergeInConfig(); err != nil {
+			return nil, fmt.Errorf("error loading config file %s: %w", configFile, err)
+		}
+		logger.Info("loaded config file", "path", configFile)
+	} else if configFile != defaultConfigFile {
+		return nil, fmt.Errorf("config file %s not found: %w", configFile, err)
 	}
 
 	var config Config
@@ -99,6 +379,21 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("error unmarshaling config: %w", err)
 	}
 
+	// Expand environment variables in replica URLs, route topics and subscription queue URLs,
+	// so credentials, ARNs and URLs can stay in .env files
+	for i := range config.Database.ReplicaURLs {
+		config.Database.ReplicaURLs[i] = os.ExpandEnv(config.Database.ReplicaURLs[i])
+	}
+	for i := range config.Events.Routes {
+		config.Events.Routes[i].TopicARN = os.ExpandEnv(config.Events.Routes[i].TopicARN)
+	}
+	for i := range config.Events.Subscriptions {
+		config.Events.Subscriptions[i].QueueURL = os.ExpandEnv(config.Events.Subscriptions[i].QueueURL)
+		for j := range config.Events.Subscriptions[i].Queues {
+			config.Events.Subscriptions[i].Queues[j].QueueURL = os.ExpandEnv(config.Events.Subscriptions[i].Queues[j].QueueURL)
+		}
+	}
+
 	return &config, nil
 }
 
@@ -106,6 +401,19 @@ func LoadConfig() (*Config, error) {
 func setDefaults() {
 	// Database defaults
 	viper.SetDefault("database.url", "")
+	viper.SetDefault("database.max_open_conns", 10)
+	viper.SetDefault("database.min_idle_conns", 0)
+	viper.SetDefault("database.conn_max_lifetime_seconds", 1800)
+	viper.SetDefault("database.conn_max_idle_time_seconds", 300)
+	viper.SetDefault("database.connect_timeout_seconds", 5)
+	viper.SetDefault("database.application_name", "")
+	viper.SetDefault("database.slow_query_milliseconds", 200)
+	viper.SetDefault("database.explain_slow_queries", false)
+	viper.SetDefault("database.explain_slow_writes", false)
+	viper.SetDefault("database.replica_urls", []string{})
+	viper.SetDefault("database.read_your_writes_seconds", 0)
+	viper.SetDefault("database.tx_max_attempts", 3)
+	viper.SetDefault("database.auto_migrate", false)
 
 	// AWS defaults
 	viper.SetDefault("aws.region", "us-east-1")
@@ -114,12 +422,18 @@ func setDefaults() {
 
 	// Events defaults
 	viper.SetDefault("events.events_topic_arn", "")
-	viper.SetDefault("events.queue_url_user_created", "")
-	viper.SetDefault("events.queue_url_user_updated", "")
-	viper.SetDefault("events.queue_url_user_deleted", "")
+	viper.SetDefault("events.delay_queue_url", "")
+	viper.SetDefault("events.validation.publish", false)
+	viper.SetDefault("events.validation.consume", false)
+	viper.SetDefault("events.snapshots.enabled", false)
 
 	// Server defaults
 	viper.SetDefault("server.port", "8080")
+	viper.SetDefault("server.admin_port", "8082")
+
+	// Worker defaults
+	viper.SetDefault("worker.port", "8081")
+	viper.SetDefault("worker.readiness_max_poll_age_seconds", 60)
 
 	// Environment defaults
 	viper.SetDefault("environment", "development")
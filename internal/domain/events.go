@@ -0,0 +1,43 @@
+package domain
+
+import (
+	userEvents "github.com/cgund98/go-postgres-api-template/internal/domain/user/events"
+	userHandlers "github.com/cgund98/go-postgres-api-template/internal/domain/user/events/handlers"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+)
+
+// NewEventRegistry creates an event registry with the events of every domain registered
+// Add new domains here as they are introduced
+func NewEventRegistry() (*events.Registry, error) {
+	registry := events.NewRegistry()
+
+	if err := userEvents.RegisterEvents(registry); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+// NewHandlerRegistry creates a handler registry with the event handlers of every domain registered
+// Subscriptions in the config file refer to these handlers by name
+func NewHandlerRegistry() (*events.HandlerRegistry, error) {
+	registry := events.NewHandlerRegistry()
+
+	if err := userHandlers.RegisterHandlers(registry); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+// NewFilterRegistry creates a filter registry with the event filters of every domain registered
+// Subscriptions in the config file refer to these filters by name
+func NewFilterRegistry() (*events.FilterRegistry, error) {
+	registry := events.NewFilterRegistry()
+
+	if err := userEvents.RegisterFilters(registry); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
@@ -0,0 +1,35 @@
+package events
+
+import (
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+)
+
+// Filter names referenced by subscriptions in the config file
+const (
+	FilterEmailChanged = "user-email-changed"
+)
+
+// ChangesAny accepts user updated events that change any of the given fields.
+// Other events are rejected.
+func ChangesAny(fields ...string) events.Filter {
+	return func(_ map[string]string, event events.Event) bool {
+		updated, ok := event.(*UserUpdatedEvent)
+		if !ok {
+			return false
+		}
+		for _, field := range fields {
+			if _, ok := updated.Changes[field]; ok {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// RegisterFilters registers the user domain event filters with the filter registry
+func RegisterFilters(registry *events.FilterRegistry) error {
+	return registry.Register(FilterEmailChanged, events.MatchAll(
+		events.MatchEventTypes(EventTypeUserUpdated),
+		ChangesAny("email"),
+	))
+}
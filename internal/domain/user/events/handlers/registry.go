@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+)
+
+// Handler names referenced by subscriptions in the config file
+const (
+	HandlerUserCreated = "user-created"
+	HandlerUserUpdated = "user-updated"
+	HandlerUserDeleted = "user-deleted"
+)
+
+// RegisterHandlers registers the user domain event handlers with the handler registry
+func RegisterHandlers(registry *events.HandlerRegistry) error {
+	return errors.Join(
+		events.RegisterHandler(registry, HandlerUserCreated, NewUserCreatedHandler()),
+		events.RegisterHandler(registry, HandlerUserUpdated, NewUserUpdatedHandler()),
+		events.RegisterHandler(registry, HandlerUserDeleted, NewUserDeletedHandler()),
+	)
+}
@@ -0,0 +1,35 @@
+package events
+
+import (
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+)
+
+// domainName identifies the user domain in event registry metadata
+const domainName = "user"
+
+// RegisterEvents registers the user domain events with the event registry
+func RegisterEvents(registry *events.Registry) error {
+	return registry.Register(
+		events.Registration{
+			Type:        EventTypeUserCreated,
+			New:         func() events.Event { return &UserCreatedEvent{} },
+			Version:     1,
+			Description: "Emitted after a user has been created.",
+			Metadata:    map[string]string{"domain": domainName},
+		},
+		events.Registration{
+			Type:        EventTypeUserUpdated,
+			New:         func() events.Event { return &UserUpdatedEvent{} },
+			Version:     1,
+			Description: "Emitted after one or more fields of a user have changed.",
+			Metadata:    map[string]string{"domain": domainName},
+		},
+		events.Registration{
+			Type:        EventTypeUserDeleted,
+			New:         func() events.Event { return &UserDeletedEvent{} },
+			Version:     1,
+			Description: "Emitted when a user is deleted.",
+			Metadata:    map[string]string{"domain": domainName},
+		},
+	)
+}
@@ -1,6 +1,8 @@
 package events
 
 import (
+	"github.com/danielgtaylor/huma/v2"
+
 	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
 )
 
@@ -10,7 +12,15 @@ import (
 type UserCreatedEvent struct {
 	events.EventMetadata
 	UserID string `json:"user_id"`
-	Email  string `json:"email"`
+
+	// Email is hashed or left empty when snapshots are enabled and the field policy redacts it
+	Email string `json:"email"`
+
+	// Redacted lists the PII fields of the event that were omitted or hashed
+	Redacted map[string]FieldPolicy `json:"redacted,omitempty"`
+
+	// After is the user as created. Only set when snapshots are enabled.
+	After *UserSnapshot `json:"after,omitempty"`
 }
 
 // Type implements events.Event interface
@@ -38,6 +48,9 @@ type UserUpdatedEvent struct {
 	events.EventMetadata
 	UserID  string         `json:"user_id"`
 	Changes map[string]any `json:"changes"`
+
+	// After is the user with the changes applied. Only set when snapshots are enabled.
+	After *UserSnapshot `json:"after,omitempty"`
 }
 
 // Type implements events.Event interface
@@ -55,8 +68,41 @@ func (e *UserUpdatedEvent) AggregateID() string {
 	return e.UserID
 }
 
-// Make sure the event implements the events.Event interface
+// TransformSchema implements huma.SchemaTransformer. It describes the shape of Changes,
+// which can't be derived from its map type: each changed field maps to its old and new value,
+// and to the policy that redacted them if they are PII that was omitted or hashed.
+func (e *UserUpdatedEvent) TransformSchema(_ huma.Registry, s *huma.Schema) *huma.Schema {
+	change := func() *huma.Schema {
+		return &huma.Schema{
+			Type: huma.TypeObject,
+			Properties: map[string]*huma.Schema{
+				"old":      {Type: huma.TypeString},
+				"new":      {Type: huma.TypeString},
+				"redacted": {Type: huma.TypeString, Enum: []any{string(FieldOmit), string(FieldHash)}},
+			},
+			Required:             []string{"old", "new"},
+			AdditionalProperties: false,
+		}
+	}
+
+	minProperties := 1
+	s.Properties["changes"] = &huma.Schema{
+		Type:        huma.TypeObject,
+		Description: "Changed fields, each with its old and new value",
+		Properties: map[string]*huma.Schema{
+			"email":      change(),
+			"first_name": change(),
+			"last_name":  change(),
+		},
+		MinProperties:        &minProperties,
+		AdditionalProperties: false,
+	}
+	return s
+}
+
+// Make sure the event implements the events.Event and huma.SchemaTransformer interfaces
 var _ events.Event = &UserUpdatedEvent{}
+var _ huma.SchemaTransformer = &UserUpdatedEvent{}
 
 /** -------------------------------- UserDeletedEvent -------------------------------- */
 
@@ -64,6 +110,9 @@ var _ events.Event = &UserUpdatedEvent{}
 type UserDeletedEvent struct {
 	events.EventMetadata
 	UserID string `json:"user_id"`
+
+	// Before is the user as it was before being deleted. Only set when snapshots are enabled.
+	Before *UserSnapshot `json:"before,omitempty"`
 }
 
 // Type implements events.Event interface
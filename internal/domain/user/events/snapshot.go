@@ -0,0 +1,163 @@
+package events
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cgund98/go-postgres-api-template/internal/domain/user/model"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+)
+
+// SnapshotVersion is the schema version of UserSnapshot.
+// Bump it when a field is removed or its meaning changes; adding a field does not need a new version.
+const SnapshotVersion = 1
+
+// FieldPolicy controls how a PII field appears in events
+type FieldPolicy string
+
+const (
+	// FieldOmit leaves the field out of the snapshot. This is the default for every PII field.
+	FieldOmit FieldPolicy = "omit"
+
+	// FieldHash replaces the value with its hex encoded SHA-256 hash, so consumers can match on it without seeing it.
+	// This is pseudonymisation, not anonymisation: low-entropy values such as emails can be guessed and checked.
+	FieldHash FieldPolicy = "hash"
+
+	// FieldInclude copies the value as is
+	FieldInclude FieldPolicy = "include"
+)
+
+// PIIFields are the fields governed by the field policy, in snapshots and in the rest of the event.
+// Other fields are always included.
+var PIIFields = []string{"email", "first_name", "last_name"}
+
+// SnapshotPolicy decides whether user events carry snapshots, and how PII fields appear in them.
+// With snapshots enabled, the field policy applies to the whole event, not only the snapshot; see Redact.
+type SnapshotPolicy struct {
+	// Enabled adds snapshots to user events
+	Enabled bool
+
+	// Fields maps PII field names to their policy. Fields that aren't listed are omitted.
+	Fields map[string]FieldPolicy
+}
+
+// NewSnapshotPolicy creates a snapshot policy from field names and policy names,
+// returning an error for unknown fields or policies so config typos fail at startup
+func NewSnapshotPolicy(enabled bool, fields map[string]string) (SnapshotPolicy, error) {
+	policy := SnapshotPolicy{Enabled: enabled, Fields: map[string]FieldPolicy{}}
+
+	for field, name := range fields {
+		if !slices.Contains(PIIFields, field) {
+			return SnapshotPolicy{}, fmt.Errorf("unknown snapshot field %q, expected one of %s", field, strings.Join(PIIFields, ", "))
+		}
+
+		fieldPolicy := FieldPolicy(name)
+		switch fieldPolicy {
+		case FieldOmit, FieldHash, FieldInclude:
+			policy.Fields[field] = fieldPolicy
+		default:
+			return SnapshotPolicy{}, fmt.Errorf("unknown policy %q for snapshot field %s, expected omit, hash or include", name, field)
+		}
+	}
+
+	return policy, nil
+}
+
+// UserSnapshot is the state of a user carried by user events, so consumers can
+// maintain local replicas without calling back into the API
+type UserSnapshot struct {
+	SchemaVersion int       `json:"schema_version"`
+	ID            string    `json:"id"`
+	Email         string    `json:"email,omitempty"`
+	FirstName     string    `json:"first_name,omitempty"`
+	LastName      string    `json:"last_name,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	// Redacted lists the PII fields that were omitted or hashed, so consumers can tell them apart from empty values
+	Redacted map[string]FieldPolicy `json:"redacted,omitempty"`
+}
+
+// Snapshot returns a snapshot of the user with the policy applied, or nil if snapshots are disabled
+func (p SnapshotPolicy) Snapshot(user *model.User) *UserSnapshot {
+	if !p.Enabled || user == nil {
+		return nil
+	}
+
+	snapshot := &UserSnapshot{
+		SchemaVersion: SnapshotVersion,
+		ID:            user.ID,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}
+	snapshot.Email = p.apply(snapshot, "email", user.Email)
+	snapshot.FirstName = p.apply(snapshot, "first_name", user.FirstName)
+	snapshot.LastName = p.apply(snapshot, "last_name", user.LastName)
+
+	return snapshot
+}
+
+// apply returns the value of a PII field under the policy, recording it in Redacted unless it is included
+func (p SnapshotPolicy) apply(snapshot *UserSnapshot, field, value string) string {
+	value, fieldPolicy := p.redact(field, value)
+	if fieldPolicy != FieldInclude {
+		if snapshot.Redacted == nil {
+			snapshot.Redacted = map[string]FieldPolicy{}
+		}
+		snapshot.Redacted[field] = fieldPolicy
+	}
+	return value
+}
+
+// Redact applies the field policy to the PII carried outside the snapshots of an event:
+// the email of a created user, and the old and new values of changed fields.
+// Without snapshots the event is left as is, so consumers that don't opt in keep the existing payloads.
+func (p SnapshotPolicy) Redact(event events.Event) {
+	if !p.Enabled {
+		return
+	}
+
+	switch e := event.(type) {
+	case *UserCreatedEvent:
+		var fieldPolicy FieldPolicy
+		e.Email, fieldPolicy = p.redact("email", e.Email)
+		if fieldPolicy != FieldInclude {
+			e.Redacted = map[string]FieldPolicy{"email": fieldPolicy}
+		}
+	case *UserUpdatedEvent:
+		for field, change := range e.Changes {
+			values, ok := change.(map[string]any)
+			if !ok || !slices.Contains(PIIFields, field) {
+				continue
+			}
+			oldValue, fieldPolicy := p.redact(field, fmt.Sprint(values["old"]))
+			if fieldPolicy == FieldInclude {
+				continue
+			}
+			newValue, _ := p.redact(field, fmt.Sprint(values["new"]))
+			e.Changes[field] = map[string]any{"old": oldValue, "new": newValue, "redacted": fieldPolicy}
+		}
+	}
+}
+
+// redact returns the value of a PII field under the policy, along with the policy applied
+func (p SnapshotPolicy) redact(field, value string) (string, FieldPolicy) {
+	fieldPolicy, ok := p.Fields[field]
+	if !ok {
+		fieldPolicy = FieldOmit
+	}
+
+	switch fieldPolicy {
+	case FieldInclude:
+		return value, fieldPolicy
+	case FieldHash:
+		sum := sha256.Sum256([]byte(value))
+		return hex.EncodeToString(sum[:]), fieldPolicy
+	default:
+		return "", fieldPolicy
+	}
+}
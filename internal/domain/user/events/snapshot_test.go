@@ -0,0 +1,229 @@
+package events
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cgund98/go-postgres-api-template/internal/domain/user/model"
+)
+
+func TestSnapshotPolicy_Snapshot(t *testing.T) {
+	user := &model.User{
+		ID:        "user-123",
+		Email:     "test@example.com",
+		FirstName: "Test",
+		LastName:  "User",
+		CreatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedAt: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name             string
+		enabled          bool
+		fields           map[string]string
+		expectedNil      bool
+		expectedEmail    string
+		expectedName     string
+		expectedRedacted int
+		expectedError    bool
+	}{
+		{
+			name:        "returns no snapshot when disabled",
+			enabled:     false,
+			expectedNil: true,
+		},
+		{
+			name:             "omits PII fields by default",
+			enabled:          true,
+			expectedRedacted: 3,
+		},
+		{
+			name:             "hashes and includes fields by policy",
+			enabled:          true,
+			fields:           map[string]string{"email": "hash", "first_name": "include", "last_name": "omit"},
+			expectedEmail:    "973dfe463ec85785f5f95af5ba3906eedb2d931c24e69824a89ea65dba4e813b",
+			expectedName:     "Test",
+			expectedRedacted: 2,
+		},
+		{
+			name:          "rejects unknown fields",
+			enabled:       true,
+			fields:        map[string]string{"id": "omit"},
+			expectedError: true,
+		},
+		{
+			name:          "rejects unknown policies",
+			enabled:       true,
+			fields:        map[string]string{"email": "encrypt"},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewSnapshotPolicy(tt.enabled, tt.fields)
+			if tt.expectedError {
+				if err == nil {
+					t.Error("expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			snapshot := policy.Snapshot(user)
+			if tt.expectedNil {
+				if snapshot != nil {
+					t.Errorf("expected no snapshot, got %+v", snapshot)
+				}
+				return
+			}
+
+			if snapshot.SchemaVersion != SnapshotVersion || snapshot.ID != user.ID || !snapshot.UpdatedAt.Equal(user.UpdatedAt) {
+				t.Errorf("unexpected snapshot: %+v", snapshot)
+			}
+			if snapshot.Email != tt.expectedEmail {
+				t.Errorf("expected email %q, got %q", tt.expectedEmail, snapshot.Email)
+			}
+			if snapshot.FirstName != tt.expectedName {
+				t.Errorf("expected first name %q, got %q", tt.expectedName, snapshot.FirstName)
+			}
+			if len(snapshot.Redacted) != tt.expectedRedacted {
+				t.Errorf("expected %d redacted fields, got %v", tt.expectedRedacted, snapshot.Redacted)
+			}
+
+			// Omitted fields must not appear in the payload at all
+			data, err := json.Marshal(snapshot)
+			if err != nil {
+				t.Fatalf("failed to marshal snapshot: %v", err)
+			}
+			if strings.Contains(string(data), "example.com") || strings.Contains(string(data), `"User"`) {
+				t.Errorf("expected PII to be left out of the payload, got %s", data)
+			}
+		})
+	}
+}
+
+func TestSnapshotPolicy_Redact(t *testing.T) {
+	user := &model.User{ID: "user-123", Email: "new@example.com", FirstName: "Test", LastName: "User"}
+	changes := func() map[string]any {
+		return map[string]any{
+			"email":      map[string]any{"old": "old@example.com", "new": "new@example.com"},
+			"first_name": map[string]any{"old": "Old", "new": "Test"},
+		}
+	}
+
+	tests := []struct {
+		name             string
+		fields           map[string]string
+		expectedRaw      bool
+		expectedRedacted FieldPolicy
+	}{
+		{
+			name:             "omits PII by default",
+			expectedRedacted: FieldOmit,
+		},
+		{
+			name:             "hashes PII",
+			fields:           map[string]string{"email": "hash", "first_name": "hash"},
+			expectedRedacted: FieldHash,
+		},
+		{
+			name:        "includes PII",
+			fields:      map[string]string{"email": "include", "first_name": "include", "last_name": "include"},
+			expectedRaw: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewSnapshotPolicy(true, tt.fields)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			created := NewUserCreatedEvent(user.ID, user.Email)
+			created.After = policy.Snapshot(user)
+			policy.Redact(created)
+
+			updated := NewUserUpdatedEvent(user.ID, changes())
+			updated.After = policy.Snapshot(user)
+			policy.Redact(updated)
+
+			for _, event := range []any{created, updated} {
+				data, err := json.Marshal(event)
+				if err != nil {
+					t.Fatalf("failed to marshal event: %v", err)
+				}
+				raw := strings.Contains(string(data), "example.com") || strings.Contains(string(data), `"Old"`)
+				if raw != tt.expectedRaw {
+					t.Errorf("expected raw PII in the payload to be %v, got %s", tt.expectedRaw, data)
+				}
+			}
+
+			// Changed PII fields are kept, so filters on them still match
+			for _, field := range []string{"email", "first_name"} {
+				change, ok := updated.Changes[field].(map[string]any)
+				if !ok {
+					t.Fatalf("expected the %s change to be kept, got %v", field, updated.Changes)
+				}
+				if redacted, _ := change["redacted"].(FieldPolicy); redacted != tt.expectedRedacted {
+					t.Errorf("expected %s change redacted by %q, got %q", field, tt.expectedRedacted, redacted)
+				}
+			}
+			if created.Redacted["email"] != tt.expectedRedacted {
+				t.Errorf("expected email redacted by %q, got %v", tt.expectedRedacted, created.Redacted)
+			}
+		})
+	}
+}
+
+func TestSnapshotPolicy_Redact_disabled(t *testing.T) {
+	policy, err := NewSnapshotPolicy(false, map[string]string{"email": "hash", "first_name": "omit"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	user := &model.User{ID: "user-123", Email: "new@example.com", FirstName: "Test", LastName: "User"}
+	changes := func() map[string]any {
+		return map[string]any{
+			"email":      map[string]any{"old": "old@example.com", "new": "new@example.com"},
+			"first_name": map[string]any{"old": "Old", "new": "Test"},
+		}
+	}
+
+	// Build the events the way the user service does, and the events it built before snapshots existed
+	created := NewUserCreatedEvent(user.ID, user.Email)
+	created.After = policy.Snapshot(user)
+	policy.Redact(created)
+	baselineCreated := NewUserCreatedEvent(user.ID, user.Email)
+	baselineCreated.EventMetadata = created.EventMetadata
+
+	updated := NewUserUpdatedEvent(user.ID, changes())
+	updated.After = policy.Snapshot(user)
+	policy.Redact(updated)
+	baselineUpdated := NewUserUpdatedEvent(user.ID, changes())
+	baselineUpdated.EventMetadata = updated.EventMetadata
+
+	deleted := NewUserDeletedEvent(user.ID)
+	deleted.Before = policy.Snapshot(user)
+	policy.Redact(deleted)
+	baselineDeleted := NewUserDeletedEvent(user.ID)
+	baselineDeleted.EventMetadata = deleted.EventMetadata
+
+	for _, pair := range [][2]any{{created, baselineCreated}, {updated, baselineUpdated}, {deleted, baselineDeleted}} {
+		actual, err := json.Marshal(pair[0])
+		if err != nil {
+			t.Fatalf("failed to marshal event: %v", err)
+		}
+		expected, err := json.Marshal(pair[1])
+		if err != nil {
+			t.Fatalf("failed to marshal event: %v", err)
+		}
+		if string(actual) != string(expected) {
+			t.Errorf("expected the baseline event %s, got %s", expected, actual)
+		}
+	}
+}
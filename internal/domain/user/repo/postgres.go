@@ -2,11 +2,12 @@ package repo
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 
 	"github.com/cgund98/go-postgres-api-template/internal/domain"
 	"github.com/cgund98/go-postgres-api-template/internal/domain/user/model"
@@ -14,6 +15,12 @@ import (
 	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/db/postgres"
 )
 
+// userConstraints names the fields protected by the users table's constraints, for postgres.TranslateError
+var userConstraints = postgres.Constraints{
+	"users_pkey":      "id",
+	"users_email_key": "email",
+}
+
 // PostgresRepository implements the Repository interface for PostgreSQL.
 // It extracts the database context from context.Context internally using
 // postgres.GetDBFromContext(), which must match the key used by postgres.TransactionManager.
@@ -48,7 +55,7 @@ func (r *PostgresRepository) Create(ctx context.Context, u *model.UserCreate) (*
 		RETURNING id, email, first_name, last_name, created_at, updated_at
 	`
 
-	err := tx.QueryRowContext(ctx, query,
+	err := tx.QueryRow(ctx, query,
 		newUser.ID,
 		newUser.Email,
 		newUser.FirstName,
@@ -65,7 +72,7 @@ func (r *PostgresRepository) Create(ctx context.Context, u *model.UserCreate) (*
 	)
 
 	if err != nil {
-		return nil, err
+		return nil, postgres.TranslateError(err, userConstraints)
 	}
 
 	return newUser, nil
@@ -84,7 +91,7 @@ func (r *PostgresRepository) GetByID(ctx context.Context, id string) (*model.Use
 		WHERE id = $1
 	`
 
-	err := tx.QueryRowContext(ctx, query, id).Scan(
+	err := tx.QueryRow(ctx, query, id).Scan(
 		&u.ID,
 		&u.Email,
 		&u.FirstName,
@@ -93,11 +100,11 @@ func (r *PostgresRepository) GetByID(ctx context.Context, id string) (*model.Use
 		&u.UpdatedAt,
 	)
 
-	if err == sql.ErrNoRows {
+	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, domain.ErrNotFound
 	}
 	if err != nil {
-		return nil, err
+		return nil, postgres.TranslateError(err, userConstraints)
 	}
 
 	return u, nil
@@ -116,7 +123,7 @@ func (r *PostgresRepository) GetByEmail(ctx context.Context, email string) (*mod
 		WHERE email = $1
 	`
 
-	err := tx.QueryRowContext(ctx, query, email).Scan(
+	err := tx.QueryRow(ctx, query, email).Scan(
 		&u.ID,
 		&u.Email,
 		&u.FirstName,
@@ -125,11 +132,11 @@ func (r *PostgresRepository) GetByEmail(ctx context.Context, email string) (*mod
 		&u.UpdatedAt,
 	)
 
-	if err == sql.ErrNoRows {
+	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, domain.ErrNotFound
 	}
 	if err != nil {
-		return nil, err
+		return nil, postgres.TranslateError(err, userConstraints)
 	}
 
 	return u, nil
@@ -166,7 +173,7 @@ func (r *PostgresRepository) Update(ctx context.Context, id string, u *model.Use
 	args = append(args, id)
 
 	updatedUser := &model.User{}
-	err := tx.QueryRowContext(ctx, query, args...).Scan(
+	err := tx.QueryRow(ctx, query, args...).Scan(
 		&updatedUser.ID,
 		&updatedUser.Email,
 		&updatedUser.FirstName,
@@ -175,11 +182,11 @@ func (r *PostgresRepository) Update(ctx context.Context, id string, u *model.Use
 		&updatedUser.UpdatedAt,
 	)
 
-	if err == sql.ErrNoRows {
+	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, domain.ErrNotFound
 	}
 	if err != nil {
-		return nil, err
+		return nil, postgres.TranslateError(err, userConstraints)
 	}
 
 	return updatedUser, nil
@@ -192,9 +199,9 @@ func (r *PostgresRepository) Delete(ctx context.Context, id string) error {
 		return db.ErrNoDBContext
 	}
 	query := `DELETE FROM users WHERE id = $1`
-	_, err := tx.ExecContext(ctx, query, id)
+	_, err := tx.Exec(ctx, query, id)
 	if err != nil {
-		return err
+		return postgres.TranslateError(err, userConstraints)
 	}
 
 	// Verify deletion by checking if user still exists
@@ -223,9 +230,9 @@ func (r *PostgresRepository) List(ctx context.Context, limit, offset int) ([]*mo
 		LIMIT $1 OFFSET $2
 	`
 
-	rows, err := tx.QueryContext(ctx, query, limit, offset)
+	rows, err := tx.Query(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return nil, postgres.TranslateError(err, userConstraints)
 	}
 	defer rows.Close()
 
@@ -241,13 +248,13 @@ func (r *PostgresRepository) List(ctx context.Context, limit, offset int) ([]*mo
 			&u.UpdatedAt,
 		)
 		if err != nil {
-			return nil, err
+			return nil, postgres.TranslateError(err, userConstraints)
 		}
 		users = append(users, u)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, postgres.TranslateError(err, userConstraints)
 	}
 
 	return users, nil
@@ -261,9 +268,9 @@ func (r *PostgresRepository) Count(ctx context.Context) (int, error) {
 	}
 	query := `SELECT COUNT(*) FROM users`
 	var count int
-	err := tx.QueryRowContext(ctx, query).Scan(&count)
+	err := tx.QueryRow(ctx, query).Scan(&count)
 	if err != nil {
-		return 0, err
+		return 0, postgres.TranslateError(err, userConstraints)
 	}
 	return count, nil
 }
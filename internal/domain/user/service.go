@@ -7,12 +7,18 @@ import (
 	"github.com/cgund98/go-postgres-api-template/internal/domain/user/events"
 	"github.com/cgund98/go-postgres-api-template/internal/domain/user/model"
 	"github.com/cgund98/go-postgres-api-template/internal/domain/user/repo"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/db"
+	baseEvents "github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
 	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/publisher"
 )
 
-// TransactionManager defines the interface for managing transactions
+// TransactionManager defines the interface for managing transactions.
+// Options state the consistency a use case needs, such as a read-only or serializable transaction.
+// Writes publish events from inside the transaction, so a failed publish rolls the write back rather than
+// losing the event. They are marked db.NonRetryable: running them again after a serialization failure
+// would publish the events twice.
 type TransactionManager interface {
-	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error, opts ...db.TxOption) error
 }
 
 // Service handles user business logic
@@ -20,6 +26,8 @@ type Service struct {
 	repo           repo.Repository
 	txManager      TransactionManager
 	eventPublisher publisher.Publisher
+	sequencer      baseEvents.Sequencer
+	snapshots      events.SnapshotPolicy
 	// Add other service dependencies here (e.g., invoice service)
 }
 
@@ -28,11 +36,15 @@ func NewService(
 	repo repo.Repository,
 	txManager TransactionManager,
 	eventPublisher publisher.Publisher,
+	sequencer baseEvents.Sequencer,
+	snapshots events.SnapshotPolicy,
 ) *Service {
 	return &Service{
 		repo:           repo,
 		txManager:      txManager,
 		eventPublisher: eventPublisher,
+		sequencer:      sequencer,
+		snapshots:      snapshots,
 	}
 }
 
@@ -59,17 +71,15 @@ func (s *Service) CreateUser(ctx context.Context, email, firstName, lastName str
 		}
 		createdUser = user
 
-		// Publish event after successful transaction
-		if createdUser != nil {
-			event := events.NewUserCreatedEvent(createdUser.ID, createdUser.Email)
-			err := s.eventPublisher.Publish(ctx, event)
-			if err != nil {
-				return err
-			}
+		// Publish the event in the transaction, so its sequence number is assigned with the write
+		event := events.NewUserCreatedEvent(createdUser.ID, createdUser.Email)
+		event.After = s.snapshots.Snapshot(createdUser)
+		s.snapshots.Redact(event)
+		if err := baseEvents.AssignSequence(txCtx, s.sequencer, event); err != nil {
+			return err
 		}
-
-		return nil
-	})
+		return s.eventPublisher.Publish(ctx, event)
+	}, db.NonRetryable())
 
 	if err != nil {
 		return nil, err
@@ -89,7 +99,7 @@ func (s *Service) GetUser(ctx context.Context, userID string) (*model.User, erro
 		}
 		user = u
 		return nil
-	})
+	}, db.ReadOnly())
 
 	return user, err
 }
@@ -97,7 +107,6 @@ func (s *Service) GetUser(ctx context.Context, userID string) (*model.User, erro
 // PatchUser performs a partial update of a user
 func (s *Service) PatchUser(ctx context.Context, userID string, update *model.UserUpdate) (*model.User, error) {
 	var updatedUser *model.User
-	var changes Changes
 
 	err := s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
 		// Validate request and get existing user
@@ -107,7 +116,7 @@ func (s *Service) PatchUser(ctx context.Context, userID string, update *model.Us
 		}
 
 		// Generate changes dictionary for event
-		changes = GenerateUserChanges(update, existing)
+		changes := GenerateUserChanges(update, existing)
 
 		// Check if there are any fields to update
 		if len(changes) == 0 {
@@ -122,17 +131,15 @@ func (s *Service) PatchUser(ctx context.Context, userID string, update *model.Us
 		}
 		updatedUser = updated
 
-		// Publish event if there were changes
-		if len(changes) > 0 && updatedUser != nil {
-			event := events.NewUserUpdatedEvent(updatedUser.ID, changes)
-			err := s.eventPublisher.Publish(ctx, event)
-			if err != nil {
-				return err
-			}
+		// Publish the event in the transaction, so its sequence number is assigned with the write
+		event := events.NewUserUpdatedEvent(updatedUser.ID, changes)
+		event.After = s.snapshots.Snapshot(updatedUser)
+		s.snapshots.Redact(event)
+		if err := baseEvents.AssignSequence(txCtx, s.sequencer, event); err != nil {
+			return err
 		}
-
-		return nil
-	})
+		return s.eventPublisher.Publish(ctx, event)
+	}, db.NonRetryable())
 
 	if err != nil {
 		return nil, err
@@ -146,6 +153,7 @@ func (s *Service) ListUsers(ctx context.Context, limit, offset int) ([]*model.Us
 	var users []*model.User
 	var total int
 
+	// Repeatable read so the page and the total come from the same snapshot
 	err := s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
 		u, err := s.repo.List(txCtx, limit, offset)
 		if err != nil {
@@ -160,42 +168,38 @@ func (s *Service) ListUsers(ctx context.Context, limit, offset int) ([]*model.Us
 		total = count
 
 		return nil
-	})
+	}, db.ReadOnly(), db.WithIsolation(db.RepeatableRead))
 
 	return users, total, err
 }
 
 // DeleteUser deletes a user by ID
 func (s *Service) DeleteUser(ctx context.Context, userID string) error {
-	var deletedUserID string
-
-	err := s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+	return s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
 		// Validate request and get user
 		user, err := s.validateDeleteUserRequest(txCtx, userID)
 		if err != nil {
 			return err
 		}
-		deletedUserID = user.ID
 
 		// TODO: Delete associated resources (e.g., invoices) if needed
-		// This would call other services' internal methods to avoid nested transactions
+		// Other services can be called directly: their transactions join this one through a savepoint
 
-		// Publish event
-		event := events.NewUserDeletedEvent(deletedUserID)
-		err = s.eventPublisher.Publish(ctx, event)
-		if err != nil {
+		// Build the event in the transaction, so its sequence number is assigned with the delete
+		event := events.NewUserDeletedEvent(user.ID)
+		event.Before = s.snapshots.Snapshot(user)
+		if err := baseEvents.AssignSequence(txCtx, s.sequencer, event); err != nil {
 			return err
 		}
 
 		// Delete the user
-		return s.repo.Delete(txCtx, userID)
-	})
-
-	if err != nil {
-		return err
-	}
+		if err := s.repo.Delete(txCtx, userID); err != nil {
+			return err
+		}
 
-	return nil
+		// Publish the event in the transaction, so a failed publish rolls the delete back
+		return s.eventPublisher.Publish(ctx, event)
+	}, db.NonRetryable())
 }
 
 // validateCreateUserRequest validates create user request
@@ -209,7 +213,8 @@ func (s *Service) validateCreateUserRequest(ctx context.Context, email, firstNam
 		return err
 	}
 
-	// Check email uniqueness
+	// Check email uniqueness up front. Concurrent creates can both pass this check,
+	// but the loser's insert fails on users_email_key, which the repository reports as domain.ErrAlreadyExists.
 	existing, err := s.repo.GetByEmail(ctx, email)
 	if err != nil && err != domain.ErrNotFound {
 		return err
@@ -1,7 +1,19 @@
 package user
 
 import (
+	"context"
+	"errors"
 	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/cgund98/go-postgres-api-template/internal/domain"
+	"github.com/cgund98/go-postgres-api-template/internal/domain/user/events"
+	"github.com/cgund98/go-postgres-api-template/internal/domain/user/model"
+	"github.com/cgund98/go-postgres-api-template/internal/domain/user/repo"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/db"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/db/postgres"
+	baseEvents "github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
 )
 
 // Example unit test structure
@@ -16,3 +28,191 @@ func TestService_Update(t *testing.T) {
 	// TODO: Implement test with mocked repository
 	t.Skip("Test not yet implemented")
 }
+
+// serializationFailure is the error Postgres returns when a transaction conflicts with a concurrent one
+var serializationFailure = &pgconn.PgError{Code: "40001", Message: "could not serialize access"}
+
+// retryingTxManager runs fn again after a serialization failure unless it is marked db.NonRetryable,
+// like postgres.TransactionManager
+type retryingTxManager struct {
+	attempts int
+}
+
+func (m *retryingTxManager) WithTransaction(ctx context.Context, fn func(ctx context.Context) error, opts ...db.TxOption) error {
+	options := db.NewTxOptions(opts...)
+	for {
+		m.attempts++
+		err := fn(ctx)
+		if err == nil || options.NonRetryable || !postgres.IsRetryable(err) || m.attempts >= 3 {
+			return err
+		}
+	}
+}
+
+// mockRepository holds one user, and fails the next writes with a serialization failure
+type mockRepository struct {
+	repo.Repository
+	user     *model.User
+	failures int
+}
+
+func (m *mockRepository) write() error {
+	if m.failures > 0 {
+		m.failures--
+		return serializationFailure
+	}
+	return nil
+}
+
+func (m *mockRepository) Create(_ context.Context, u *model.UserCreate) (*model.User, error) {
+	if err := m.write(); err != nil {
+		return nil, err
+	}
+	return &model.User{ID: "user-123", Email: u.Email, FirstName: u.FirstName, LastName: u.LastName}, nil
+}
+
+func (m *mockRepository) GetByID(_ context.Context, _ string) (*model.User, error) {
+	return m.user, nil
+}
+
+func (m *mockRepository) GetByEmail(_ context.Context, _ string) (*model.User, error) {
+	return nil, domain.ErrNotFound
+}
+
+func (m *mockRepository) Update(_ context.Context, _ string, u *model.UserUpdate) (*model.User, error) {
+	if err := m.write(); err != nil {
+		return nil, err
+	}
+	updated := *m.user
+	updated.FirstName = *u.FirstName
+	return &updated, nil
+}
+
+func (m *mockRepository) Delete(_ context.Context, _ string) error {
+	return m.write()
+}
+
+// mockPublisher records published events, and fails them with err when it is set
+type mockPublisher struct {
+	published []baseEvents.Event
+	err       error
+}
+
+func (m *mockPublisher) Publish(_ context.Context, event baseEvents.Event) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.published = append(m.published, event)
+	return nil
+}
+
+func (m *mockPublisher) PublishBatch(_ context.Context, batch []baseEvents.Event) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.published = append(m.published, batch...)
+	return nil
+}
+
+// mockSequencer counts up from 1 for every aggregate
+type mockSequencer struct {
+	next int64
+}
+
+func (m *mockSequencer) Next(_ context.Context, _ string) (int64, error) {
+	m.next++
+	return m.next, nil
+}
+
+func TestService_publishesWritesInTheTransaction(t *testing.T) {
+	firstName := "Grace"
+	errPublish := errors.New("publish failed")
+
+	writes := []struct {
+		name         string
+		write        func(ctx context.Context, s *Service) error
+		expectedType string
+	}{
+		{
+			name: "create",
+			write: func(ctx context.Context, s *Service) error {
+				_, err := s.CreateUser(ctx, "test@example.com", "Ada", "Lovelace")
+				return err
+			},
+			expectedType: "user.created",
+		},
+		{
+			name: "patch",
+			write: func(ctx context.Context, s *Service) error {
+				_, err := s.PatchUser(ctx, "user-123", &model.UserUpdate{FirstName: &firstName})
+				return err
+			},
+			expectedType: "user.updated",
+		},
+		{
+			name: "delete",
+			write: func(ctx context.Context, s *Service) error {
+				return s.DeleteUser(ctx, "user-123")
+			},
+			expectedType: "user.deleted",
+		},
+	}
+
+	tests := []struct {
+		name              string
+		failures          int
+		publishErr        error
+		expectedError     error
+		expectedPublished int
+	}{
+		{
+			name:              "publishes the event in the transaction",
+			expectedPublished: 1,
+		},
+		{
+			name:          "doesn't retry a write after a serialization failure",
+			failures:      1,
+			expectedError: serializationFailure,
+		},
+		{
+			name:          "fails the transaction when the event isn't published",
+			publishErr:    errPublish,
+			expectedError: errPublish,
+		},
+	}
+
+	for _, write := range writes {
+		for _, tt := range tests {
+			t.Run(write.name+"/"+tt.name, func(t *testing.T) {
+				repository := &mockRepository{
+					user:     &model.User{ID: "user-123", Email: "test@example.com", FirstName: "Ada", LastName: "Lovelace"},
+					failures: tt.failures,
+				}
+				txManager := &retryingTxManager{}
+				pub := &mockPublisher{err: tt.publishErr}
+				service := NewService(repository, txManager, pub, &mockSequencer{}, events.SnapshotPolicy{})
+
+				err := write.write(context.Background(), service)
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				// Writes publish from inside the transaction, so they must never run twice
+				if txManager.attempts != 1 {
+					t.Errorf("expected the write to run once, got %d attempts", txManager.attempts)
+				}
+				if len(pub.published) != tt.expectedPublished {
+					t.Fatalf("expected %d published events, got %v", tt.expectedPublished, pub.published)
+				}
+				if tt.expectedPublished == 0 {
+					return
+				}
+				if pub.published[0].Type() != write.expectedType {
+					t.Errorf("expected a %s event, got %s", write.expectedType, pub.published[0].Type())
+				}
+				if sequenced := pub.published[0].(baseEvents.Sequenced); sequenced.SequenceNumber() == 0 {
+					t.Error("expected the event to carry the sequence assigned in the transaction")
+				}
+			})
+		}
+	}
+}
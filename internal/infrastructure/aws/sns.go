@@ -0,0 +1,14 @@
+package aws
+
+import "github.com/aws/aws-sdk-go/service/sns"
+
+// SNSAdminClientInterface defines the SNS operations used to provision topics and subscriptions
+// We define an interface so we can mock the SNS client in tests
+type SNSAdminClientInterface interface {
+	CreateTopic(input *sns.CreateTopicInput) (*sns.CreateTopicOutput, error)
+	GetTopicAttributes(input *sns.GetTopicAttributesInput) (*sns.GetTopicAttributesOutput, error)
+	ListSubscriptionsByTopicPages(input *sns.ListSubscriptionsByTopicInput, fn func(*sns.ListSubscriptionsByTopicOutput, bool) bool) error
+	Subscribe(input *sns.SubscribeInput) (*sns.SubscribeOutput, error)
+	GetSubscriptionAttributes(input *sns.GetSubscriptionAttributesInput) (*sns.GetSubscriptionAttributesOutput, error)
+	SetSubscriptionAttributes(input *sns.SetSubscriptionAttributesInput) (*sns.SetSubscriptionAttributesOutput, error)
+}
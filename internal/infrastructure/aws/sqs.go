@@ -8,4 +8,14 @@ type SQSClientInterface interface {
 	ReceiveMessage(input *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error)
 	DeleteMessage(input *sqs.DeleteMessageInput) (*sqs.DeleteMessageOutput, error)
 	DeleteMessageBatch(input *sqs.DeleteMessageBatchInput) (*sqs.DeleteMessageBatchOutput, error)
+	GetQueueAttributes(input *sqs.GetQueueAttributesInput) (*sqs.GetQueueAttributesOutput, error)
+	SendMessage(input *sqs.SendMessageInput) (*sqs.SendMessageOutput, error)
+}
+
+// SQSAdminClientInterface defines the SQS operations used to provision queues
+type SQSAdminClientInterface interface {
+	CreateQueue(input *sqs.CreateQueueInput) (*sqs.CreateQueueOutput, error)
+	GetQueueUrl(input *sqs.GetQueueUrlInput) (*sqs.GetQueueUrlOutput, error)
+	GetQueueAttributes(input *sqs.GetQueueAttributesInput) (*sqs.GetQueueAttributesOutput, error)
+	SetQueueAttributes(input *sqs.SetQueueAttributesInput) (*sqs.SetQueueAttributesOutput, error)
 }
@@ -2,7 +2,8 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
+
+	"github.com/jackc/pgx/v5"
 )
 
 // txKey is an unexported type used as a key for storing transactions in context.
@@ -18,17 +19,23 @@ import (
 // See TestTransactionKeyConsistency for a test that verifies this coupling.
 var txKey = &struct{ name string }{"postgres_tx"}
 
+// txStateKey stores the transactionState of the outermost transaction, shared with nested transactions
+var txStateKey = &struct{ name string }{"postgres_tx_state"}
+
+// writeTrackerKey stores the writeTracker used for read-your-writes, see WithReadYourWrites
+var writeTrackerKey = &struct{ name string }{"postgres_write_tracker"}
+
 // Context implements the db.DB interface for PostgreSQL
-// It wraps a sql.Tx transaction
+// It wraps a pgx.Tx transaction
 type Context struct {
-	Tx *sql.Tx // The transaction
+	Tx pgx.Tx // The transaction
 }
 
 // GetTXFromContext extracts the DB context from a transaction context.
 // Returns a Context wrapping the transaction if in a transaction, otherwise returns nil.
 // This is a package-level function that repositories can use.
-func GetTXFromContext(ctx context.Context) *sql.Tx {
-	if tx, ok := ctx.Value(txKey).(*sql.Tx); ok {
+func GetTXFromContext(ctx context.Context) pgx.Tx {
+	if tx, ok := ctx.Value(txKey).(pgx.Tx); ok {
 		// We're in a transaction - return a wrapper that adapts Tx to Context
 		return tx
 	}
@@ -2,16 +2,22 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
 	"testing"
+
+	"github.com/jackc/pgx/v5"
 )
 
+// fakeTx stands in for a pgx transaction; only its identity matters here
+type fakeTx struct {
+	pgx.Tx
+}
+
 // TestTransactionKeyConsistency verifies that TransactionManager and GetTXFromContext
 // use the same key. This test ensures that repositories can find transactions stored
 // by the transaction manager.
 func TestTransactionKeyConsistency(t *testing.T) {
 	// Create a mock transaction
-	mockTx := &sql.Tx{} // In real tests, you'd use a proper mock or test DB
+	mockTx := &fakeTx{} // In real tests, you'd use a proper mock or test DB
 
 	// Store transaction using the same mechanism TransactionManager uses
 	ctx := context.WithValue(context.Background(), txKey, mockTx)
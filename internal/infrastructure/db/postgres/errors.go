@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/cgund98/go-postgres-api-template/internal/domain"
+)
+
+// SQLSTATE codes of integrity violations, which are caused by the input rather than the server
+const (
+	notNullViolation    = "23502"
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+	checkViolation      = "23514"
+	stringTooLong       = "22001"
+)
+
+// Constraints maps the constraints of a repository's tables to the field each one protects,
+// so violations are reported in terms of the field rather than the constraint name
+type Constraints map[string]string
+
+// ConstraintError is an integrity violation translated into a domain error.
+// errors.Is matches both the domain error and the original *pgconn.PgError.
+type ConstraintError struct {
+	// Err is the domain error, domain.ErrAlreadyExists or domain.ErrInvalidInput
+	Err error
+
+	// Constraint is the violated constraint, if Postgres reported one
+	Constraint string
+
+	// Field is the field the constraint protects, if known
+	Field string
+
+	// reason explains the violation without naming tables or constraints, since it is shown to clients
+	reason string
+
+	cause *pgconn.PgError
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Err, e.reason)
+}
+
+func (e *ConstraintError) Unwrap() []error {
+	return []error{e.Err, e.cause}
+}
+
+// TranslateError turns integrity violations into domain errors, using constraints to name the field involved.
+// A unique violation on users_email_key becomes domain.ErrAlreadyExists for email, and foreign key,
+// check, not null and length violations become domain.ErrInvalidInput.
+// Other errors, including serialization failures that the transaction manager retries, are returned as they are.
+func TranslateError(err error, constraints Constraints) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	field := constraints[pgErr.ConstraintName]
+	if field == "" {
+		field = pgErr.ColumnName
+	}
+	subject := field
+	if subject == "" {
+		subject = "value"
+	}
+
+	translated := &ConstraintError{Constraint: pgErr.ConstraintName, Field: field, cause: pgErr}
+	switch pgErr.Code {
+	case uniqueViolation:
+		translated.Err = domain.ErrAlreadyExists
+		translated.reason = subject + " is already in use"
+	case foreignKeyViolation:
+		translated.Err = domain.ErrInvalidInput
+		if strings.HasPrefix(pgErr.Message, "update or delete") {
+			translated.reason = "resource is still referenced by " + subject
+		} else {
+			translated.reason = subject + " refers to a resource that does not exist"
+		}
+	case checkViolation:
+		translated.Err = domain.ErrInvalidInput
+		translated.reason = subject + " is not valid"
+	case notNullViolation:
+		translated.Err = domain.ErrInvalidInput
+		translated.reason = subject + " is required"
+	case stringTooLong:
+		translated.Err = domain.ErrInvalidInput
+		translated.reason = subject + " is too long"
+	default:
+		return err
+	}
+	return translated
+}
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/cgund98/go-postgres-api-template/internal/domain"
+)
+
+func TestTranslateError(t *testing.T) {
+	constraints := Constraints{"users_email_key": "email"}
+	serializationFailure := &pgconn.PgError{Code: "40001"}
+	other := errors.New("connection reset")
+
+	tests := []struct {
+		name            string
+		err             error
+		expectedErr     error
+		expectedField   string
+		expectedMessage string
+	}{
+		{
+			name:            "unique violation on a known constraint",
+			err:             &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"},
+			expectedErr:     domain.ErrAlreadyExists,
+			expectedField:   "email",
+			expectedMessage: "resource already exists: email is already in use",
+		},
+		{
+			name:            "wrapped unique violation on an unknown constraint",
+			err:             fmt.Errorf("failed to create user: %w", &pgconn.PgError{Code: "23505", ConstraintName: "users_pkey"}),
+			expectedErr:     domain.ErrAlreadyExists,
+			expectedMessage: "resource already exists: value is already in use",
+		},
+		{
+			name:            "foreign key violation on insert",
+			err:             &pgconn.PgError{Code: "23503", ConstraintName: "invoices_user_id_fkey", Message: `insert or update on table "invoices" violates foreign key constraint "invoices_user_id_fkey"`},
+			expectedErr:     domain.ErrInvalidInput,
+			expectedMessage: "invalid input: value refers to a resource that does not exist",
+		},
+		{
+			name:            "foreign key violation on delete",
+			err:             &pgconn.PgError{Code: "23503", Message: `update or delete on table "users" violates foreign key constraint "invoices_user_id_fkey" on table "invoices"`},
+			expectedErr:     domain.ErrInvalidInput,
+			expectedMessage: "invalid input: resource is still referenced by value",
+		},
+		{
+			name:            "not null violation uses the column",
+			err:             &pgconn.PgError{Code: "23502", ColumnName: "first_name"},
+			expectedErr:     domain.ErrInvalidInput,
+			expectedField:   "first_name",
+			expectedMessage: "invalid input: first_name is required",
+		},
+		{
+			name:            "check violation",
+			err:             &pgconn.PgError{Code: "23514", ConstraintName: "users_email_key"},
+			expectedErr:     domain.ErrInvalidInput,
+			expectedField:   "email",
+			expectedMessage: "invalid input: email is not valid",
+		},
+		{
+			name:            "serialization failures are left for retries",
+			err:             serializationFailure,
+			expectedErr:     serializationFailure,
+			expectedMessage: ":  (SQLSTATE 40001)",
+		},
+		{
+			name:            "other errors are unchanged",
+			err:             other,
+			expectedErr:     other,
+			expectedMessage: "connection reset",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := TranslateError(tt.err, constraints)
+
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected %v, got %v", tt.expectedErr, err)
+			}
+			if err.Error() != tt.expectedMessage {
+				t.Errorf("expected message %q, got %q", tt.expectedMessage, err.Error())
+			}
+
+			var constraintErr *ConstraintError
+			if errors.As(err, &constraintErr) {
+				if constraintErr.Field != tt.expectedField {
+					t.Errorf("expected field %q, got %q", tt.expectedField, constraintErr.Field)
+				}
+				var pgErr *pgconn.PgError
+				if !errors.As(err, &pgErr) {
+					t.Error("expected the original *pgconn.PgError to be kept")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/cgund98/go-postgres-api-template/internal/observability"
+)
+
+const metricsSubsystem = "db_pool"
+
+// replicaHealthy tracks the health check result of each read replica
+var replicaHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: observability.MetricsNamespace,
+	Subsystem: metricsSubsystem,
+	Name:      "replica_healthy",
+	Help:      "Whether the read replica passed its latest health check (1) or not (0).",
+}, []string{"pool"})
+
+// queryDuration tracks the latency of statements, named after the function that ran them
+var queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: observability.MetricsNamespace,
+	Subsystem: "db",
+	Name:      "query_duration_seconds",
+	Help:      "Time taken to run a statement, by the function that ran it and whether it failed.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"pool", "query", "outcome"})
+
+// register registers a collector. Collectors shared by every pool, such as queryDuration, are only
+// registered by the first pool. Any other conflict, such as a second pool with the same name, is returned
+// rather than leaving the pool out of the metrics.
+func register(collector prometheus.Collector) error {
+	err := observability.Registry.Register(collector)
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if errors.As(err, &alreadyRegistered) && alreadyRegistered.ExistingCollector == collector {
+		return nil
+	}
+	return err
+}
+
+// poolCollector exports the statistics of a pgxpool.Pool, read on every scrape.
+// Every series is labelled with the pool's name.
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	maxConns                *prometheus.Desc
+	totalConns              *prometheus.Desc
+	acquiredConns           *prometheus.Desc
+	idleConns               *prometheus.Desc
+	constructingConns       *prometheus.Desc
+	acquireCount            *prometheus.Desc
+	acquireDuration         *prometheus.Desc
+	emptyAcquireCount       *prometheus.Desc
+	emptyAcquireWaitTime    *prometheus.Desc
+	canceledAcquireCount    *prometheus.Desc
+	newConnsCount           *prometheus.Desc
+	maxIdleDestroyCount     *prometheus.Desc
+	maxLifetimeDestroyCount *prometheus.Desc
+}
+
+func newPoolCollector(pool *pgxpool.Pool, poolName string) *poolCollector {
+	labels := prometheus.Labels{"pool": poolName}
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(observability.MetricsNamespace, metricsSubsystem, name), help, nil, labels)
+	}
+
+	return &poolCollector{
+		pool:                    pool,
+		maxConns:                desc("max_conns", "Maximum number of connections in the pool."),
+		totalConns:              desc("conns", "Number of connections in the pool, including those being established."),
+		acquiredConns:           desc("acquired_conns", "Number of connections currently in use."),
+		idleConns:               desc("idle_conns", "Number of idle connections."),
+		constructingConns:       desc("constructing_conns", "Number of connections being established."),
+		acquireCount:            desc("acquires_total", "Number of connections acquired from the pool."),
+		acquireDuration:         desc("acquire_duration_seconds_total", "Time spent acquiring connections from the pool."),
+		emptyAcquireCount:       desc("empty_acquires_total", "Number of acquires that had to wait for a connection because none was idle."),
+		emptyAcquireWaitTime:    desc("empty_acquire_wait_seconds_total", "Time spent waiting for a connection because none was idle."),
+		canceledAcquireCount:    desc("canceled_acquires_total", "Number of acquires canceled by their context."),
+		newConnsCount:           desc("new_conns_total", "Number of connections opened."),
+		maxIdleDestroyCount:     desc("max_idle_closed_total", "Number of connections closed for exceeding the max idle time."),
+		maxLifetimeDestroyCount: desc("max_lifetime_closed_total", "Number of connections closed for exceeding the max lifetime."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxConns
+	ch <- c.totalConns
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.constructingConns
+	ch <- c.acquireCount
+	ch <- c.acquireDuration
+	ch <- c.emptyAcquireCount
+	ch <- c.emptyAcquireWaitTime
+	ch <- c.canceledAcquireCount
+	ch <- c.newConnsCount
+	ch <- c.maxIdleDestroyCount
+	ch <- c.maxLifetimeDestroyCount
+}
+
+// Collect implements prometheus.Collector
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	gauge := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+	}
+	counter := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value)
+	}
+
+	gauge(c.maxConns, float64(stat.MaxConns()))
+	gauge(c.totalConns, float64(stat.TotalConns()))
+	gauge(c.acquiredConns, float64(stat.AcquiredConns()))
+	gauge(c.idleConns, float64(stat.IdleConns()))
+	gauge(c.constructingConns, float64(stat.ConstructingConns()))
+	counter(c.acquireCount, float64(stat.AcquireCount()))
+	counter(c.acquireDuration, stat.AcquireDuration().Seconds())
+	counter(c.emptyAcquireCount, float64(stat.EmptyAcquireCount()))
+	counter(c.emptyAcquireWaitTime, stat.EmptyAcquireWaitTime().Seconds())
+	counter(c.canceledAcquireCount, float64(stat.CanceledAcquireCount()))
+	counter(c.newConnsCount, float64(stat.NewConnsCount()))
+	counter(c.maxIdleDestroyCount, float64(stat.MaxIdleDestroyCount()))
+	counter(c.maxLifetimeDestroyCount, float64(stat.MaxLifetimeDestroyCount()))
+}
+
+var _ prometheus.Collector = &poolCollector{}
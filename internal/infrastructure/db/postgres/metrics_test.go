@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/cgund98/go-postgres-api-template/internal/observability"
+)
+
+func TestRegister(t *testing.T) {
+	shared := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: observability.MetricsNamespace,
+		Subsystem: "db_test",
+		Name:      "shared_total",
+		Help:      "Counter shared by every pool.",
+	}, []string{"pool"})
+
+	tests := []struct {
+		name          string
+		first         prometheus.Collector
+		second        prometheus.Collector
+		expectedError bool
+	}{
+		{
+			name:   "registers a collector shared by every pool once",
+			first:  shared,
+			second: shared,
+		},
+		{
+			name:   "registers pools with different names",
+			first:  newPoolCollector(nil, "test-primary"),
+			second: newPoolCollector(nil, "test-replica-0"),
+		},
+		{
+			name:          "rejects a second pool with the same name",
+			first:         newPoolCollector(nil, "test-primary"),
+			second:        newPoolCollector(nil, "test-primary"),
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() {
+				observability.Registry.Unregister(tt.first)
+				observability.Registry.Unregister(tt.second)
+			})
+
+			if err := register(tt.first); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			err := register(tt.second)
+			if tt.expectedError && err == nil {
+				t.Error("expected an error but got nil")
+			}
+			if !tt.expectedError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
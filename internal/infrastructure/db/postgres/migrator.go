@@ -0,0 +1,307 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// migrationsTable is golang-migrate's version table, so databases migrated with its CLI carry on where they left off
+	migrationsTable = "schema_migrations"
+
+	// migrationLockID is the advisory lock held while migrating, so replicas starting together apply each migration once
+	migrationLockID int64 = 7_214_933_361
+
+	// NoVersion is the version of a database without any migration applied
+	NoVersion = -1
+)
+
+var (
+	// ErrDirty is returned when a previous migration failed part way. Fix the schema by hand,
+	// then use Force to record the version it is actually at.
+	ErrDirty = errors.New("database is dirty, a previous migration failed")
+
+	// ErrNoDownMigration is returned when rolling back a migration without a down file
+	ErrNoDownMigration = errors.New("migration has no down file")
+)
+
+// migrationFileName matches <version>_<title>.<up|down>.sql
+var migrationFileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// migration is one versioned schema change
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+
+	// hasUp and hasDown tell an empty file from a missing one
+	hasUp   bool
+	hasDown bool
+}
+
+// Migrator applies SQL migrations to the database
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []migration
+}
+
+// NewMigrator creates a migrator for the migrations in source, usually the embedded resources/db/migrations
+func NewMigrator(pool *pgxpool.Pool, source fs.FS) (*Migrator, error) {
+	migrations, err := loadMigrations(source)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{pool: pool, migrations: migrations}, nil
+}
+
+// loadMigrations reads the up and down files in the root of source, sorted by version
+func loadMigrations(source fs.FS) ([]migration, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := map[int]*migration{}
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		body, err := fs.ReadFile(source, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: match[2]}
+			byVersion[version] = m
+		}
+		if m.name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, m.name, match[2])
+		}
+		if match[3] == "up" {
+			m.up = string(body)
+			m.hasUp = true
+		} else {
+			m.down = string(body)
+			m.hasDown = true
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if !m.hasUp {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.version, m.name)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b migration) int { return a.version - b.version })
+	return migrations, nil
+}
+
+// pending returns the migrations after the current version, in the order they are applied
+func (m *Migrator) pending(current int) []migration {
+	var pending []migration
+	for _, migration := range m.migrations {
+		if migration.version > current {
+			pending = append(pending, migration)
+		}
+	}
+	return pending
+}
+
+// rollbacks returns up to steps applied migrations in the order they are rolled back,
+// along with the version the database is at after each one
+func (m *Migrator) rollbacks(current, steps int) ([]migration, []int) {
+	var applied []migration
+	for _, migration := range m.migrations {
+		if migration.version <= current {
+			applied = append(applied, migration)
+		}
+	}
+
+	var rollbacks []migration
+	var versions []int
+	for i := len(applied) - 1; i >= 0 && len(rollbacks) < steps; i-- {
+		rollbacks = append(rollbacks, applied[i])
+		if i > 0 {
+			versions = append(versions, applied[i-1].version)
+		} else {
+			versions = append(versions, NoVersion)
+		}
+	}
+	return rollbacks, versions
+}
+
+/** -------------------------------- Commands -------------------------------- */
+
+// Up applies every pending migration and returns how many were applied
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		current, dirty, err := m.version(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("%w at version %d", ErrDirty, current)
+		}
+
+		for _, migration := range m.pending(current) {
+			logger.Info("applying migration", "version", migration.version, "name", migration.name)
+			if err := m.run(ctx, conn, migration.up, migration.version); err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %w", migration.version, migration.name, err)
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back up to steps migrations and returns how many were rolled back
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	rolledBack := 0
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		current, dirty, err := m.version(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("%w at version %d", ErrDirty, current)
+		}
+
+		rollbacks, versions := m.rollbacks(current, steps)
+		for i, migration := range rollbacks {
+			if !migration.hasDown {
+				return fmt.Errorf("%w: %d_%s", ErrNoDownMigration, migration.version, migration.name)
+			}
+			logger.Info("rolling back migration", "version", migration.version, "name", migration.name)
+			if err := m.run(ctx, conn, migration.down, versions[i]); err != nil {
+				return fmt.Errorf("failed to roll back migration %d_%s: %w", migration.version, migration.name, err)
+			}
+			rolledBack++
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Version returns the version the database is at, or NoVersion, and whether the last migration failed part way.
+// It only reads the version table, so it neither waits for a running migration nor needs rights to create the table.
+func (m *Migrator) Version(ctx context.Context) (int, bool, error) {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	var exists bool
+	if err := conn.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, migrationsTable).Scan(&exists); err != nil {
+		return 0, false, fmt.Errorf("failed to look up %s table: %w", migrationsTable, err)
+	}
+	if !exists {
+		return NoVersion, false, nil
+	}
+	return m.version(ctx, conn)
+}
+
+// Force records the database as clean at the given version without running any migration.
+// Use it after fixing a failed migration by hand. NoVersion clears the version.
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	return m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		return m.setVersion(ctx, conn, version, false)
+	})
+}
+
+/** -------------------------------- Internals -------------------------------- */
+
+// withLock runs fn on a single connection holding the migration advisory lock,
+// creating the version table first if it doesn't exist
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		// The lock is released with the session if this fails, so only log it
+		if _, err := conn.Exec(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrationLockID); err != nil {
+			logger.Error("failed to release migration lock", "error", err)
+		}
+	}()
+
+	query := `CREATE TABLE IF NOT EXISTS ` + migrationsTable + ` (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`
+	if _, err := conn.Exec(ctx, query); err != nil {
+		return fmt.Errorf("failed to create %s table: %w", migrationsTable, err)
+	}
+
+	return fn(conn)
+}
+
+// version reads the version table, which holds at most one row
+func (m *Migrator) version(ctx context.Context, conn *pgxpool.Conn) (int, bool, error) {
+	var version int
+	var dirty bool
+	err := conn.QueryRow(ctx, `SELECT version, dirty FROM `+migrationsTable+` LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return NoVersion, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read migration version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+// run executes a migration the way golang-migrate does: the version it leads to is marked dirty while the SQL runs,
+// so a failure part way is detected on the next run instead of being retried on a half-migrated schema.
+// The file is sent as a single simple-protocol query, which Postgres runs as one implicit transaction when it
+// holds several statements. Statements that can't run in a transaction, like CREATE INDEX CONCURRENTLY,
+// must be the only statement of their file.
+func (m *Migrator) run(ctx context.Context, conn *pgxpool.Conn, sql string, targetVersion int) error {
+	if err := m.setVersion(ctx, conn, targetVersion, true); err != nil {
+		return err
+	}
+	// Without arguments, Exec uses the simple protocol, which allows several statements per file
+	if _, err := conn.Exec(ctx, sql); err != nil {
+		return err
+	}
+	return m.setVersion(ctx, conn, targetVersion, false)
+}
+
+// setVersion replaces the row of the version table. NoVersion clears it, unless it is dirty,
+// so a failed rollback of the first migration is still detected.
+func (m *Migrator) setVersion(ctx context.Context, conn *pgxpool.Conn, version int, dirty bool) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `TRUNCATE `+migrationsTable); err != nil {
+			return fmt.Errorf("failed to clear migration version: %w", err)
+		}
+		if version == NoVersion && !dirty {
+			return nil
+		}
+		query := `INSERT INTO ` + migrationsTable + ` (version, dirty) VALUES ($1, $2)`
+		if _, err := tx.Exec(ctx, query, version, dirty); err != nil {
+			return fmt.Errorf("failed to record migration version: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/cgund98/go-postgres-api-template/resources/db/migrations"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	file := func(body string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(body)}
+	}
+
+	tests := []struct {
+		name             string
+		source           fstest.MapFS
+		expectedVersions string
+		expectedError    string
+	}{
+		{
+			name: "sorts migrations by version",
+			source: fstest.MapFS{
+				"010_add_index.up.sql":    file("CREATE INDEX"),
+				"002_add_column.up.sql":   file("ALTER TABLE"),
+				"002_add_column.down.sql": file("ALTER TABLE"),
+				"001_create.up.sql":       file("CREATE TABLE"),
+			},
+			expectedVersions: "1,2,10",
+		},
+		{
+			name: "ignores other files",
+			source: fstest.MapFS{
+				"001_create.up.sql": file("CREATE TABLE"),
+				"migrations.go":     file("package migrations"),
+				"README.md":         file("notes"),
+			},
+			expectedVersions: "1",
+		},
+		{
+			name: "accepts an empty up file",
+			source: fstest.MapFS{
+				"001_create.up.sql":   file(""),
+				"001_create.down.sql": file(""),
+			},
+			expectedVersions: "1",
+		},
+		{
+			name: "rejects a version used twice",
+			source: fstest.MapFS{
+				"001_create.up.sql": file("CREATE TABLE"),
+				"001_other.up.sql":  file("CREATE TABLE"),
+			},
+			expectedError: "is used by both",
+		},
+		{
+			name: "rejects a migration without an up file",
+			source: fstest.MapFS{
+				"001_create.down.sql": file("DROP TABLE"),
+			},
+			expectedError: "has no up file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded, err := loadMigrations(tt.source)
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual := versions(loaded); actual != tt.expectedVersions {
+				t.Errorf("expected versions %s, got %s", tt.expectedVersions, actual)
+			}
+		})
+	}
+}
+
+func TestLoadMigrations_embedded(t *testing.T) {
+	loaded, err := loadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(loaded) == 0 {
+		t.Fatal("expected embedded migrations")
+	}
+	for _, m := range loaded {
+		if !m.hasDown {
+			t.Errorf("migration %d_%s has no down file", m.version, m.name)
+		}
+	}
+}
+
+func TestMigrator_plan(t *testing.T) {
+	migrator := &Migrator{migrations: []migration{{version: 1}, {version: 2}, {version: 5}}}
+
+	tests := []struct {
+		name                   string
+		current                int
+		steps                  int
+		expectedPending        string
+		expectedRollbacks      string
+		expectedTargetVersions string
+	}{
+		{
+			name:                   "fresh database",
+			current:                NoVersion,
+			steps:                  1,
+			expectedPending:        "1,2,5",
+			expectedRollbacks:      "",
+			expectedTargetVersions: "",
+		},
+		{
+			name:                   "part way",
+			current:                2,
+			steps:                  1,
+			expectedPending:        "5",
+			expectedRollbacks:      "2",
+			expectedTargetVersions: "1",
+		},
+		{
+			name:                   "rolls back past the first migration",
+			current:                5,
+			steps:                  10,
+			expectedPending:        "",
+			expectedRollbacks:      "5,2,1",
+			expectedTargetVersions: "2,1,-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := versions(migrator.pending(tt.current)); actual != tt.expectedPending {
+				t.Errorf("expected pending %s, got %s", tt.expectedPending, actual)
+			}
+
+			rollbacks, targets := migrator.rollbacks(tt.current, tt.steps)
+			if actual := versions(rollbacks); actual != tt.expectedRollbacks {
+				t.Errorf("expected rollbacks %s, got %s", tt.expectedRollbacks, actual)
+			}
+			if actual := joinVersions(targets); actual != tt.expectedTargetVersions {
+				t.Errorf("expected target versions %s, got %s", tt.expectedTargetVersions, actual)
+			}
+		})
+	}
+}
+
+func versions(migrations []migration) string {
+	versions := make([]int, len(migrations))
+	for i, m := range migrations {
+		versions[i] = m.version
+	}
+	return joinVersions(versions)
+}
+
+func joinVersions(versions []int) string {
+	parts := make([]string, len(versions))
+	for i, version := range versions {
+		parts[i] = strconv.Itoa(version)
+	}
+	return strings.Join(parts, ",")
+}
@@ -1,37 +1,316 @@
 package postgres
 
 import (
-	"database/sql"
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"time"
 
-	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/cgund98/go-postgres-api-template/internal/config"
+	"github.com/cgund98/go-postgres-api-template/internal/observability"
+)
+
+const (
+	defaultMaxConns        = 10
+	defaultMinIdleConns    = 0
+	defaultConnMaxLifetime = 30 * time.Minute
+	defaultConnMaxIdleTime = 5 * time.Minute
+	defaultConnectTimeout  = 5 * time.Second
+	defaultPoolName        = "primary"
 )
 
+// PoolOptions configures the connection pool. Nil fields fall back to the defaults.
+type PoolOptions struct {
+	// MaxConns caps the connections held by this process. Size it so that the sum across
+	// every API and worker replica stays below the server's max_connections.
+	MaxConns *int
+
+	// MinIdleConns is how many idle connections are kept open ahead of bursts. Capped at MaxConns.
+	MinIdleConns *int
+
+	// ConnMaxLifetime recycles connections so they are rebalanced after failovers and proxy restarts
+	ConnMaxLifetime *time.Duration
+
+	// ConnMaxIdleTime closes connections that have been idle this long
+	ConnMaxIdleTime *time.Duration
+
+	// ConnectTimeout bounds establishing a connection, including the startup ping
+	ConnectTimeout *time.Duration
+
+	// ApplicationName is reported to Postgres, so connections can be told apart in pg_stat_activity.
+	// It does not override an application_name already set in the connection string.
+	ApplicationName string
+
+	// Name labels the pool's metrics, to tell the primary and replica pools apart. Defaults to primary.
+	Name string
+
+	// SlowQueryThreshold is how long a statement runs before it is logged as slow. Zero disables the log.
+	SlowQueryThreshold *time.Duration
+
+	// ExplainSlowQueries logs the EXPLAIN (ANALYZE, BUFFERS) plan of slow reads.
+	// This runs every slow read a second time, so it is meant for development only.
+	ExplainSlowQueries bool
+
+	// ExplainSlowWrites also logs the plans of slow INSERT, UPDATE and DELETE statements. Replaying a write
+	// consumes sequence values, waits on row locks and can hit unique violations, so never enable it in production.
+	ExplainSlowWrites bool
+}
+
+// PoolOptionsFromConfig returns the pool options in the database config.
+// Zero values fall back to the pool defaults, and defaultApplicationName is used when none is configured.
+func PoolOptionsFromConfig(cfg config.DatabaseConfig, defaultApplicationName string) PoolOptions {
+	options := PoolOptions{
+		ApplicationName: cfg.ApplicationName,
+		MaxConns:        positiveOrNil(cfg.MaxOpenConns),
+		MinIdleConns:    positiveOrNil(cfg.MinIdleConns),
+		ConnMaxLifetime: secondsOrNil(cfg.ConnMaxLifetimeSeconds),
+		ConnMaxIdleTime: secondsOrNil(cfg.ConnMaxIdleTimeSeconds),
+		ConnectTimeout:  secondsOrNil(cfg.ConnectTimeoutSeconds),
+
+		SlowQueryThreshold: millisecondsOrNil(cfg.SlowQueryMilliseconds),
+		ExplainSlowQueries: cfg.ExplainSlowQueries,
+		ExplainSlowWrites:  cfg.ExplainSlowWrites,
+	}
+	if options.ApplicationName == "" {
+		options.ApplicationName = defaultApplicationName
+	}
+	return options
+}
+
+// positiveOrNil returns a pointer to the value, or nil so the pool default is used when it is not set
+func positiveOrNil(value int) *int {
+	if value <= 0 {
+		return nil
+	}
+	return &value
+}
+
+// secondsOrNil converts seconds to a duration, or returns nil so the pool default is used when it is not set
+func secondsOrNil(seconds int) *time.Duration {
+	if seconds <= 0 {
+		return nil
+	}
+	duration := time.Duration(seconds) * time.Second
+	return &duration
+}
+
+// millisecondsOrNil converts milliseconds to a duration, or returns nil so the default is used when it is not set
+func millisecondsOrNil(milliseconds int) *time.Duration {
+	if milliseconds <= 0 {
+		return nil
+	}
+	duration := time.Duration(milliseconds) * time.Millisecond
+	return &duration
+}
+
 // Pool manages PostgreSQL database connections
 type Pool struct {
-	db *sql.DB
+	pool      *pgxpool.Pool
+	settings  PoolSettings
+	collector prometheus.Collector
+}
+
+// PoolSettings are the limits a pool was opened with
+type PoolSettings struct {
+	MaxConns        int    `json:"max_conns"`
+	MinIdleConns    int    `json:"min_idle_conns"`
+	ConnMaxLifetime string `json:"conn_max_lifetime"`
+	ConnMaxIdleTime string `json:"conn_max_idle_time"`
+	ConnectTimeout  string `json:"connect_timeout"`
+	ApplicationName string `json:"application_name,omitempty"`
 }
 
-// NewPool creates a new PostgreSQL connection pool
-func NewPool(connectionString string) (*Pool, error) {
-	db, err := sql.Open("postgres", connectionString)
+// NewPool creates a new PostgreSQL connection pool, checks the database can be reached,
+// and registers the pool's statistics as metrics
+func NewPool(connectionString string, options PoolOptions) (*Pool, error) {
+	pool, err := openPool(connectionString, options)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, err
 	}
 
-	if err := db.Ping(); err != nil {
+	ctx := context.Background()
+	if timeout := pool.pool.Config().ConnConfig.ConnectTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if err := pool.pool.Ping(ctx); err != nil {
+		pool.pool.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &Pool{db: db}, nil
+	return pool, nil
 }
 
-// DB returns the underlying sql.DB instance
-func (p *Pool) DB() *sql.DB {
-	return p.db
+// openPool creates a connection pool without connecting, so a database that is down can be retried later
+func openPool(connectionString string, options PoolOptions) (*Pool, error) {
+	poolConfig, err := newPoolConfig(connectionString, options)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	collector := newPoolCollector(pool, poolName(options))
+	if err := register(queryDuration); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to register database metrics: %w", err)
+	}
+	if err := register(collector); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to register metrics of pool %s: %w", poolName(options), err)
+	}
+
+	// Plans are captured on the pool whose statements are slow, once it exists
+	if tracer, ok := poolConfig.ConnConfig.Tracer.(*queryTracer); ok && options.ExplainSlowQueries {
+		tracer.explain.Store(pool)
+	}
+
+	return &Pool{
+		pool:      pool,
+		collector: collector,
+		settings: PoolSettings{
+			MaxConns:        int(poolConfig.MaxConns),
+			MinIdleConns:    int(poolConfig.MinIdleConns),
+			ConnMaxLifetime: poolConfig.MaxConnLifetime.String(),
+			ConnMaxIdleTime: poolConfig.MaxConnIdleTime.String(),
+			ConnectTimeout:  poolConfig.ConnConfig.ConnectTimeout.String(),
+			ApplicationName: poolConfig.ConnConfig.RuntimeParams["application_name"],
+		},
+	}, nil
+}
+
+// newPoolConfig parses a URL or key/value connection string and applies the pool options.
+// Settings already in the connection string, such as application_name, are kept.
+func newPoolConfig(connectionString string, options PoolOptions) (*pgxpool.Config, error) {
+	var maxConns = defaultMaxConns
+	var minIdleConns = defaultMinIdleConns
+	var connMaxLifetime = defaultConnMaxLifetime
+	var connMaxIdleTime = defaultConnMaxIdleTime
+	var connectTimeout = defaultConnectTimeout
+	var slowQueryThreshold = defaultSlowQueryThreshold
+
+	if options.MaxConns != nil {
+		maxConns = *options.MaxConns
+	}
+
+	if options.MinIdleConns != nil {
+		minIdleConns = *options.MinIdleConns
+	}
+
+	if options.ConnMaxLifetime != nil {
+		connMaxLifetime = *options.ConnMaxLifetime
+	}
+
+	if options.ConnMaxIdleTime != nil {
+		connMaxIdleTime = *options.ConnMaxIdleTime
+	}
+
+	if options.ConnectTimeout != nil {
+		connectTimeout = *options.ConnectTimeout
+	}
+
+	if options.SlowQueryThreshold != nil {
+		slowQueryThreshold = *options.SlowQueryThreshold
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(connectionString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database url: %w", err)
+	}
+
+	poolConfig.MaxConns = int32(max(maxConns, 1))
+	poolConfig.MinIdleConns = int32(min(minIdleConns, maxConns))
+	poolConfig.MaxConnLifetime = connMaxLifetime
+	poolConfig.MaxConnIdleTime = connMaxIdleTime
+	poolConfig.ConnConfig.ConnectTimeout = connectTimeout
+
+	if options.ApplicationName != "" && poolConfig.ConnConfig.RuntimeParams["application_name"] == "" {
+		poolConfig.ConnConfig.RuntimeParams["application_name"] = options.ApplicationName
+	}
+
+	poolConfig.ConnConfig.Tracer = newQueryTracer(poolName(options), slowQueryThreshold, options.ExplainSlowWrites)
+
+	return poolConfig, nil
+}
+
+// poolName returns the name labelling the pool's metrics
+func poolName(options PoolOptions) string {
+	if options.Name == "" {
+		return defaultPoolName
+	}
+	return options.Name
+}
+
+// Pool returns the underlying pgxpool.Pool instance
+func (p *Pool) Pool() *pgxpool.Pool {
+	return p.pool
 }
 
 // Close closes the database connection pool
 func (p *Pool) Close() error {
-	return p.db.Close()
+	p.pool.Close()
+	// Free the pool's name, so a pool opened later can use it
+	observability.Registry.Unregister(p.collector)
+	return nil
+}
+
+/** -------------------------------- Statistics -------------------------------- */
+
+// PoolStats is a point-in-time snapshot of the pool, for sizing it across replicas
+type PoolStats struct {
+	Settings PoolSettings `json:"settings"`
+
+	TotalConns        int `json:"total_conns"`
+	AcquiredConns     int `json:"acquired_conns"`
+	IdleConns         int `json:"idle_conns"`
+	ConstructingConns int `json:"constructing_conns"`
+
+	// EmptyAcquireCount and EmptyAcquireWaitTime grow when requests queue for a connection,
+	// meaning the pool is too small
+	AcquireCount         int64  `json:"acquire_count"`
+	EmptyAcquireCount    int64  `json:"empty_acquire_count"`
+	EmptyAcquireWaitTime string `json:"empty_acquire_wait_time"`
+	CanceledAcquireCount int64  `json:"canceled_acquire_count"`
+
+	// NewConnsCount growing quickly alongside MaxIdleDestroyCount means MinIdleConns is too low for the traffic
+	NewConnsCount           int64 `json:"new_conns_count"`
+	MaxIdleDestroyCount     int64 `json:"max_idle_destroy_count"`
+	MaxLifetimeDestroyCount int64 `json:"max_lifetime_destroy_count"`
+}
+
+// Stats returns the pool's current statistics
+func (p *Pool) Stats() PoolStats {
+	stat := p.pool.Stat()
+	return PoolStats{
+		Settings:                p.settings,
+		TotalConns:              int(stat.TotalConns()),
+		AcquiredConns:           int(stat.AcquiredConns()),
+		IdleConns:               int(stat.IdleConns()),
+		ConstructingConns:       int(stat.ConstructingConns()),
+		AcquireCount:            stat.AcquireCount(),
+		EmptyAcquireCount:       stat.EmptyAcquireCount(),
+		EmptyAcquireWaitTime:    stat.EmptyAcquireWaitTime().String(),
+		CanceledAcquireCount:    stat.CanceledAcquireCount(),
+		NewConnsCount:           stat.NewConnsCount(),
+		MaxIdleDestroyCount:     stat.MaxIdleDestroyCount(),
+		MaxLifetimeDestroyCount: stat.MaxLifetimeDestroyCount(),
+	}
+}
+
+// StatsHandler serves the pool's statistics as JSON
+func (p *Pool) StatsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(p.Stats()); err != nil {
+			observability.Logger.Error("failed to write database stats", "error", err)
+		}
+	}
 }
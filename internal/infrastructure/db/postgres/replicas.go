@@ -0,0 +1,203 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultHealthCheckInterval = 5 * time.Second
+	defaultHealthCheckTimeout  = 2 * time.Second
+)
+
+// ReplicaSetOptions configures health checking of the replicas. Nil fields fall back to the defaults.
+type ReplicaSetOptions struct {
+	// HealthCheckInterval is how often every replica is pinged
+	HealthCheckInterval *time.Duration
+
+	// HealthCheckTimeout is how long a ping may take before the replica is considered down
+	HealthCheckTimeout *time.Duration
+}
+
+// ReplicaSet holds the read replicas that read-only transactions are routed to.
+// Replicas are pinged in the background, and a replica that fails a ping or a BEGIN is skipped
+// until it passes a ping again. When no replica is healthy, reads fall back to the primary.
+type ReplicaSet struct {
+	replicas            []*replica
+	healthCheckInterval time.Duration
+	healthCheckTimeout  time.Duration
+
+	// next is the round robin counter used to spread reads across the healthy replicas
+	next atomic.Uint64
+}
+
+// replica is one read replica and its latest health
+type replica struct {
+	name    string
+	pool    *Pool
+	healthy atomic.Bool
+}
+
+// NewReplicaSet creates a pool per replica and checks their health once, so routing is right from the start.
+// A replica that is down does not fail startup; it is used once it passes a health check.
+func NewReplicaSet(connectionStrings []string, poolOptions PoolOptions, options ReplicaSetOptions) (*ReplicaSet, error) {
+	var healthCheckInterval = defaultHealthCheckInterval
+	var healthCheckTimeout = defaultHealthCheckTimeout
+
+	if options.HealthCheckInterval != nil {
+		healthCheckInterval = *options.HealthCheckInterval
+	}
+
+	if options.HealthCheckTimeout != nil {
+		healthCheckTimeout = *options.HealthCheckTimeout
+	}
+
+	if err := register(replicaHealthy); err != nil {
+		return nil, fmt.Errorf("failed to register replica metrics: %w", err)
+	}
+
+	set := &ReplicaSet{
+		healthCheckInterval: healthCheckInterval,
+		healthCheckTimeout:  healthCheckTimeout,
+	}
+	for i, connectionString := range connectionStrings {
+		replicaOptions := replicaPoolOptions(poolOptions, i)
+		pool, err := openPool(connectionString, replicaOptions)
+		if err != nil {
+			set.Close()
+			return nil, fmt.Errorf("failed to open %s: %w", replicaOptions.Name, err)
+		}
+		set.replicas = append(set.replicas, &replica{name: replicaOptions.Name, pool: pool})
+	}
+
+	set.checkHealth(context.Background())
+	return set, nil
+}
+
+// replicaPoolOptions names the pool of the replica at the position, since connection strings hold credentials.
+// The name labels the pool's metrics and is appended to the application name, so the primary and
+// every replica can be told apart in metrics and in pg_stat_activity.
+func replicaPoolOptions(poolOptions PoolOptions, position int) PoolOptions {
+	options := poolOptions
+	options.Name = fmt.Sprintf("replica-%d", position)
+	if options.ApplicationName != "" {
+		options.ApplicationName = options.ApplicationName + "-" + options.Name
+	}
+	return options
+}
+
+// Start starts health checking the replicas. This will begin in a new goroutine and return immediately.
+func (r *ReplicaSet) Start(ctx context.Context) {
+	go func() {
+		logger.Info("starting replica health checks", "replicas", len(r.replicas), "interval", r.healthCheckInterval)
+		ticker := time.NewTicker(r.healthCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.checkHealth(ctx)
+			}
+		}
+	}()
+}
+
+// checkHealth pings every replica and records whether it is healthy
+func (r *ReplicaSet) checkHealth(ctx context.Context) {
+	for _, replica := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, r.healthCheckTimeout)
+		err := replica.pool.pool.Ping(pingCtx)
+		cancel()
+
+		if err != nil {
+			r.markUnhealthy(replica, err)
+			continue
+		}
+		if !replica.healthy.Swap(true) {
+			logger.Info("replica is healthy", "replica", replica.name)
+		}
+		replicaHealthy.WithLabelValues(replica.name).Set(1)
+	}
+}
+
+// markUnhealthy stops routing reads to the replica until it passes a health check
+func (r *ReplicaSet) markUnhealthy(replica *replica, err error) {
+	if replica.healthy.Swap(false) {
+		logger.Warn("replica is unhealthy, reading from the other replicas or the primary", "replica", replica.name, "error", err)
+	}
+	replicaHealthy.WithLabelValues(replica.name).Set(0)
+}
+
+// pick returns the next healthy replica by round robin, or nil if none is healthy
+func (r *ReplicaSet) pick() *replica {
+	n := uint64(len(r.replicas))
+	start := r.next.Add(1)
+	for i := range n {
+		if replica := r.replicas[(start+i)%n]; replica.healthy.Load() {
+			return replica
+		}
+	}
+	return nil
+}
+
+// Close closes the pool of every replica
+func (r *ReplicaSet) Close() {
+	for _, replica := range r.replicas {
+		_ = replica.pool.Close()
+	}
+}
+
+/** -------------------------------- Read your writes -------------------------------- */
+
+// writeTracker remembers when a read-write transaction of a client last committed
+type writeTracker struct {
+	window time.Duration
+
+	// lastWrite is the commit time of the latest read-write transaction, in Unix nanoseconds
+	lastWrite atomic.Int64
+}
+
+// WithReadYourWrites returns a context in which read-only transactions go to the primary for window after
+// a read-write transaction commits, so a client reads its own writes even when the replicas are lagging.
+// lastWrite is the client's latest write in an earlier request, or zero if it is not known.
+func WithReadYourWrites(ctx context.Context, window time.Duration, lastWrite time.Time) context.Context {
+	tracker := &writeTracker{window: window}
+	if !lastWrite.IsZero() {
+		tracker.lastWrite.Store(lastWrite.UnixNano())
+	}
+	return context.WithValue(ctx, writeTrackerKey, tracker)
+}
+
+// LastWrite returns when a read-write transaction last committed in the context, including a write
+// passed to WithReadYourWrites, or zero if there was none or read-your-writes is not enabled
+func LastWrite(ctx context.Context) time.Time {
+	tracker, ok := ctx.Value(writeTrackerKey).(*writeTracker)
+	if !ok {
+		return time.Time{}
+	}
+	if lastWrite := tracker.lastWrite.Load(); lastWrite != 0 {
+		return time.Unix(0, lastWrite)
+	}
+	return time.Time{}
+}
+
+// recordWrite notes that a read-write transaction committed, if read-your-writes is enabled in the context
+func recordWrite(ctx context.Context, now time.Time) {
+	if tracker, ok := ctx.Value(writeTrackerKey).(*writeTracker); ok {
+		tracker.lastWrite.Store(now.UnixNano())
+	}
+}
+
+// wroteRecently reports whether a read-write transaction committed within the read-your-writes window
+func wroteRecently(ctx context.Context, now time.Time) bool {
+	tracker, ok := ctx.Value(writeTrackerKey).(*writeTracker)
+	if !ok {
+		return false
+	}
+	lastWrite := tracker.lastWrite.Load()
+	return lastWrite != 0 && now.Sub(time.Unix(0, lastWrite)) < tracker.window
+}
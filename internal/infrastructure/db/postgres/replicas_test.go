@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplicaSet_pick(t *testing.T) {
+	tests := []struct {
+		name          string
+		healthy       []bool
+		picks         int
+		expectedOrder string
+	}{
+		{
+			name:          "spreads reads across healthy replicas",
+			healthy:       []bool{true, true, true},
+			picks:         4,
+			expectedOrder: "replica-1,replica-2,replica-0,replica-1",
+		},
+		{
+			name:          "skips unhealthy replicas",
+			healthy:       []bool{true, false, true},
+			picks:         4,
+			expectedOrder: "replica-2,replica-2,replica-0,replica-2",
+		},
+		{
+			name:          "returns nothing when every replica is unhealthy",
+			healthy:       []bool{false, false},
+			picks:         2,
+			expectedOrder: ",",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := &ReplicaSet{}
+			for i, healthy := range tt.healthy {
+				r := &replica{name: fmt.Sprintf("replica-%d", i)}
+				r.healthy.Store(healthy)
+				set.replicas = append(set.replicas, r)
+			}
+
+			order := make([]string, tt.picks)
+			for i := range order {
+				if r := set.pick(); r != nil {
+					order[i] = r.name
+				}
+			}
+			if actual := strings.Join(order, ","); actual != tt.expectedOrder {
+				t.Errorf("expected order %s, got %s", tt.expectedOrder, actual)
+			}
+		})
+	}
+}
+
+func TestReplicaPoolOptions(t *testing.T) {
+	tests := []struct {
+		name                    string
+		options                 PoolOptions
+		position                int
+		expectedName            string
+		expectedApplicationName string
+	}{
+		{
+			name:                    "names each replica after its position",
+			options:                 PoolOptions{ApplicationName: "api"},
+			position:                1,
+			expectedName:            "replica-1",
+			expectedApplicationName: "api-replica-1",
+		},
+		{
+			name:         "leaves the application name to the connection string when none is set",
+			position:     0,
+			expectedName: "replica-0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := replicaPoolOptions(tt.options, tt.position)
+			if actual.Name != tt.expectedName {
+				t.Errorf("expected name %q, got %q", tt.expectedName, actual.Name)
+			}
+			if actual.ApplicationName != tt.expectedApplicationName {
+				t.Errorf("expected application name %q, got %q", tt.expectedApplicationName, actual.ApplicationName)
+			}
+		})
+	}
+}
+
+func TestWroteRecently(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		wroteAt   time.Time
+		checkedAt time.Time
+		expected  bool
+	}{
+		{
+			name:      "disabled without read-your-writes",
+			ctx:       context.Background(),
+			wroteAt:   now,
+			checkedAt: now,
+			expected:  false,
+		},
+		{
+			name:      "no write yet",
+			ctx:       WithReadYourWrites(context.Background(), time.Second, time.Time{}),
+			checkedAt: now,
+			expected:  false,
+		},
+		{
+			name:      "within the window",
+			ctx:       WithReadYourWrites(context.Background(), time.Second, time.Time{}),
+			wroteAt:   now,
+			checkedAt: now.Add(500 * time.Millisecond),
+			expected:  true,
+		},
+		{
+			name:      "within the window of a write in an earlier request",
+			ctx:       WithReadYourWrites(context.Background(), time.Second, now),
+			checkedAt: now.Add(500 * time.Millisecond),
+			expected:  true,
+		},
+		{
+			name:      "after the window",
+			ctx:       WithReadYourWrites(context.Background(), time.Second, time.Time{}),
+			wroteAt:   now,
+			checkedAt: now.Add(2 * time.Second),
+			expected:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !tt.wroteAt.IsZero() {
+				recordWrite(tt.ctx, tt.wroteAt)
+			}
+			if actual := wroteRecently(tt.ctx, tt.checkedAt); actual != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, actual)
+			}
+		})
+	}
+}
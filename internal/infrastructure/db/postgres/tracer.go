@@ -0,0 +1,231 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	defaultSlowQueryThreshold = 200 * time.Millisecond
+
+	// explainTimeout bounds capturing the plan of a slow statement, which runs the statement again
+	explainTimeout = 10 * time.Second
+
+	// maxCallerDepth is how far up the stack the caller of a statement is looked for
+	maxCallerDepth = 32
+)
+
+// queryTraceKey stores the queryTrace of a running statement
+var queryTraceKey = &struct{ name string }{"postgres_query_trace"}
+
+// explainKey marks the context of an EXPLAIN, so capturing a plan doesn't trigger another capture
+var explainKey = &struct{ name string }{"postgres_explain"}
+
+// queryTracer times every statement run through a pool. It records the latency per query, named after
+// the function that ran it, and logs statements slower than the threshold with their normalized SQL and caller.
+// With explain set, it also logs the EXPLAIN (ANALYZE, BUFFERS) plan of slow statements that are safe to replay.
+type queryTracer struct {
+	poolName  string
+	threshold time.Duration
+
+	// explainWrites also captures the plans of slow writes, which replaying consumes sequence values,
+	// takes row locks again and can hit unique violations. For development only.
+	explainWrites bool
+
+	// explain is the pool plans are captured on, or nil when capturing plans is disabled
+	explain atomic.Pointer[pgxpool.Pool]
+
+	// explaining is set while a plan is captured, so a burst of slow statements captures one plan at a time
+	explaining atomic.Bool
+}
+
+// queryTrace is what is known about a statement when it starts
+type queryTrace struct {
+	start  time.Time
+	sql    string
+	args   []any
+	name   string
+	caller string
+}
+
+func newQueryTracer(poolName string, threshold time.Duration, explainWrites bool) *queryTracer {
+	return &queryTracer{poolName: poolName, threshold: threshold, explainWrites: explainWrites}
+}
+
+// TraceQueryStart implements pgx.QueryTracer
+func (t *queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	name, caller := queryCaller()
+	return context.WithValue(ctx, queryTraceKey, &queryTrace{
+		start:  time.Now(),
+		sql:    data.SQL,
+		args:   data.Args,
+		name:   name,
+		caller: caller,
+	})
+}
+
+// TraceQueryEnd implements pgx.QueryTracer
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	trace, ok := ctx.Value(queryTraceKey).(*queryTrace)
+	if !ok || ctx.Value(explainKey) != nil {
+		return
+	}
+	elapsed := time.Since(trace.start)
+
+	outcome := "ok"
+	if data.Err != nil {
+		outcome = "error"
+	}
+	queryDuration.WithLabelValues(t.poolName, trace.name, outcome).Observe(elapsed.Seconds())
+
+	if t.threshold <= 0 || elapsed < t.threshold {
+		return
+	}
+	logger.Warn("slow query",
+		"pool", t.poolName,
+		"query", trace.name,
+		"caller", trace.caller,
+		"duration_ms", elapsed.Milliseconds(),
+		"sql", normalizeSQL(trace.sql),
+		"error", data.Err,
+	)
+
+	if pool := t.explain.Load(); pool != nil && explainable(trace.sql, t.explainWrites) && t.explaining.CompareAndSwap(false, true) {
+		go func() {
+			defer t.explaining.Store(false)
+			t.logPlan(context.WithoutCancel(ctx), pool, trace)
+		}()
+	}
+}
+
+// logPlan runs the statement again under EXPLAIN (ANALYZE, BUFFERS) and logs the plan.
+// ANALYZE executes the statement, so it runs in a transaction that is always rolled back.
+// Meant for development: the statement's load is doubled, and writes, when opted in, take their locks again.
+func (t *queryTracer) logPlan(ctx context.Context, pool *pgxpool.Pool, trace *queryTrace) {
+	ctx, cancel := context.WithTimeout(context.WithValue(ctx, explainKey, true), explainTimeout)
+	defer cancel()
+
+	var plan []string
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, "EXPLAIN (ANALYZE, BUFFERS) "+trace.sql, trace.args...)
+		if err != nil {
+			return err
+		}
+		plan, err = pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return err
+		}
+		// Roll back whatever the statement changed
+		return errExplainRollback
+	})
+	if err != nil && !errors.Is(err, errExplainRollback) {
+		logger.Error("failed to capture query plan", "query", trace.name, "error", err)
+		return
+	}
+
+	logger.Info("slow query plan", "pool", t.poolName, "query", trace.name, "plan", strings.Join(plan, "\n"))
+}
+
+// explainableReads are the statements whose plan is captured. Everything else, such as transaction
+// control, DDL and multi-statement migrations, either has no plan or must not run twice.
+var explainableReads = []string{"SELECT", "WITH"}
+
+// explainableWrites are the writes whose plan is only captured when writes are opted in
+var explainableWrites = []string{"INSERT", "UPDATE", "DELETE"}
+
+// writeKeywords mark a read that writes or locks rows after all, such as a WITH holding a data-modifying
+// common table expression or a SELECT ... FOR UPDATE. Matching words rather than parsing skips a few
+// harmless statements, such as one reading a column named share, which only costs their plan.
+var writeKeywords = []string{"insert", "update", "delete", "merge", "share"}
+
+// sessionSideEffects are functions whose effects outlive the rolled back transaction, or lock the session:
+// replaying pg_advisory_lock would leave the lock held by an idle pooled connection
+var sessionSideEffects = []string{
+	"pg_advisory_lock", "pg_advisory_lock_shared", "pg_try_advisory_lock", "pg_try_advisory_lock_shared",
+	"pg_advisory_unlock", "pg_advisory_unlock_shared", "pg_advisory_unlock_all",
+	"set_config", "nextval", "setval", "pg_notify", "dblink",
+}
+
+// explainable reports whether a slow statement can be replayed under EXPLAIN ANALYZE: a single
+// SELECT or WITH statement that neither writes nor locks rows, or with writes set, a single INSERT,
+// UPDATE, DELETE or WITH statement. Either way, it must not call a function with session side effects.
+func explainable(sql string, writes bool) bool {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return false
+	}
+	command := strings.ToUpper(fields[0])
+	switch {
+	case slices.Contains(explainableWrites, command):
+		if !writes {
+			return false
+		}
+	case slices.Contains(explainableReads, command):
+		if !writes && writesRows(sql) {
+			return false
+		}
+	default:
+		return false
+	}
+	if strings.Contains(strings.TrimRight(sql, "; \t\n"), ";") {
+		return false
+	}
+	lower := strings.ToLower(sql)
+	return !slices.ContainsFunc(sessionSideEffects, func(function string) bool {
+		return strings.Contains(lower, function)
+	})
+}
+
+// writesRows reports whether a read holds a keyword of a write or a row lock
+func writesRows(sql string) bool {
+	words := strings.FieldsFunc(strings.ToLower(sql), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '_'
+	})
+	return slices.ContainsFunc(words, func(word string) bool {
+		return slices.Contains(writeKeywords, word)
+	})
+}
+
+// errExplainRollback rolls back the transaction a plan was captured in
+var errExplainRollback = errors.New("rollback after explain")
+
+// normalizeSQL collapses the whitespace of a statement onto one line for logging
+func normalizeSQL(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
+
+// queryCaller returns the name of the function that ran the statement, such as
+// repo.PostgresRepository.GetByID, and its file and line. Frames inside pgx are skipped.
+func queryCaller() (name, caller string) {
+	pcs := make([]uintptr, maxCallerDepth)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "github.com/jackc/") {
+			return queryName(frame.Function), fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return "unknown", "unknown"
+		}
+	}
+}
+
+// queryName shortens a function name to its package and method, dropping pointer receiver markers
+func queryName(function string) string {
+	if i := strings.LastIndex(function, "/"); i >= 0 {
+		function = function[i+1:]
+	}
+	return strings.NewReplacer("(*", "", ")", "").Replace(function)
+}
+
+var _ pgx.QueryTracer = &queryTracer{}
@@ -0,0 +1,201 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNormalizeSQL(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected string
+	}{
+		{
+			name:     "collapses a multi-line statement onto one line",
+			sql:      "\n\t\tSELECT id, email\n\t\tFROM users\n\t\tWHERE id = $1\n\t",
+			expected: "SELECT id, email FROM users WHERE id = $1",
+		},
+		{
+			name:     "leaves a one-line statement as it is",
+			sql:      "SELECT 1",
+			expected: "SELECT 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := normalizeSQL(tt.sql); actual != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestQueryName(t *testing.T) {
+	tests := []struct {
+		name     string
+		function string
+		expected string
+	}{
+		{
+			name:     "drops the package path and pointer receiver",
+			function: "github.com/cgund98/go-postgres-api-template/internal/infrastructure/db/postgres/repo.(*PostgresRepository).GetByID",
+			expected: "repo.PostgresRepository.GetByID",
+		},
+		{
+			name:     "keeps a plain function",
+			function: "main.main",
+			expected: "main.main",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := queryName(tt.function); actual != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestQueryTracer(t *testing.T) {
+	tests := []struct {
+		name           string
+		poolName       string
+		explaining     bool
+		err            error
+		expectedSeries int
+	}{
+		{
+			name:           "records the latency of a statement",
+			poolName:       "tracer-test-ok",
+			expectedSeries: 1,
+		},
+		{
+			name:           "records the latency of a failed statement",
+			poolName:       "tracer-test-error",
+			err:            errors.New("connection reset"),
+			expectedSeries: 1,
+		},
+		{
+			name:       "ignores the statements run to capture a plan",
+			poolName:   "tracer-test-explain",
+			explaining: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := newQueryTracer(tt.poolName, 0, false)
+
+			ctx := context.Background()
+			if tt.explaining {
+				ctx = context.WithValue(ctx, explainKey, true)
+			}
+			ctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+
+			trace := ctx.Value(queryTraceKey).(*queryTrace)
+			if expected := "postgres.TestQueryTracer.func1"; trace.name != expected {
+				t.Errorf("expected query name %q, got %q", expected, trace.name)
+			}
+
+			before := testutil.CollectAndCount(queryDuration)
+			tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: tt.err})
+			if actual := testutil.CollectAndCount(queryDuration) - before; actual != tt.expectedSeries {
+				t.Errorf("expected %d new series, got %d", tt.expectedSeries, actual)
+			}
+		})
+	}
+}
+
+func TestExplainable(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		writes   bool
+		expected bool
+	}{
+		{name: "select", sql: "\n\t\tSELECT id FROM users WHERE id = $1\n\t", expected: true},
+		{name: "common table expression", sql: "WITH due AS (SELECT 1) SELECT * FROM due", expected: true},
+		{name: "trailing semicolon", sql: "SELECT 1;\n", expected: true},
+		{name: "insert", sql: "INSERT INTO users (id, email) VALUES ($1, $2)", expected: false},
+		{name: "update", sql: "update users set email = $2 where id = $1", expected: false},
+		{name: "delete", sql: "DELETE FROM scheduled_events WHERE event_id = ANY($1)", expected: false},
+		{name: "data-modifying common table expression", sql: "WITH due AS (DELETE FROM scheduled_events RETURNING *) SELECT * FROM due", expected: false},
+		{name: "select for update", sql: "SELECT sequence FROM processed_event_sequences WHERE aggregate_id = $1 FOR UPDATE", expected: false},
+		{name: "select for share", sql: "SELECT id FROM users FOR KEY SHARE", expected: false},
+		{name: "insert with writes", sql: "INSERT INTO users (id, email) VALUES ($1, $2)", writes: true, expected: true},
+		{name: "update with writes", sql: "update users set email = $2 where id = $1", writes: true, expected: true},
+		{name: "delete with writes", sql: "DELETE FROM scheduled_events WHERE event_id = ANY($1)", writes: true, expected: true},
+		{name: "data-modifying common table expression with writes", sql: "WITH due AS (DELETE FROM scheduled_events RETURNING *) SELECT * FROM due", writes: true, expected: true},
+		{name: "advisory lock", sql: "SELECT pg_advisory_lock($1)", expected: false},
+		{name: "advisory unlock", sql: "SELECT pg_advisory_unlock($1)", expected: false},
+		{name: "sequence", sql: "SELECT nextval('users_id_seq')", writes: true, expected: false},
+		{name: "session setting", sql: "SELECT set_config('search_path', $1, false)", expected: false},
+		{name: "transaction control", sql: "BEGIN ISOLATION LEVEL SERIALIZABLE", writes: true, expected: false},
+		{name: "savepoint", sql: "savepoint sp_1", writes: true, expected: false},
+		{name: "ddl", sql: "CREATE INDEX CONCURRENTLY idx_users_email ON users(email)", writes: true, expected: false},
+		{name: "multiple statements", sql: "ALTER TABLE users ADD COLUMN name TEXT; UPDATE users SET name = email", writes: true, expected: false},
+		{name: "multiple selects", sql: "SELECT 1; SELECT 2", expected: false},
+		{name: "multiple writes", sql: "UPDATE users SET name = email; DELETE FROM users", writes: true, expected: false},
+		{name: "empty", sql: "  ", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := explainable(tt.sql, tt.writes); actual != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestQueryTracer_capturesPlansOfExplainableStatements(t *testing.T) {
+	// The pool connects lazily, so capturing a plan fails quickly without a database
+	pool, err := pgxpool.New(context.Background(), "postgres://localhost:1/app?connect_timeout=1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer pool.Close()
+
+	tests := []struct {
+		name     string
+		sql      string
+		expected bool
+	}{
+		{name: "captures a slow select", sql: "SELECT * FROM users", expected: true},
+		{name: "skips a slow update", sql: "UPDATE users SET email = $2 WHERE id = $1", expected: false},
+		{name: "skips a slow advisory lock", sql: "SELECT pg_advisory_lock($1)", expected: false},
+		{name: "skips a slow migration", sql: "CREATE TABLE users (id TEXT); CREATE INDEX ON users(id)", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := newQueryTracer("tracer-test-explain", time.Nanosecond, false)
+			tracer.explain.Store(pool)
+
+			ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: tt.sql})
+			tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+
+			// The capture is claimed before its goroutine starts
+			if actual := tracer.explaining.Load(); actual != tt.expected {
+				t.Errorf("expected capturing a plan to be %v, got %v", tt.expected, actual)
+			}
+
+			deadline := time.Now().Add(5 * time.Second)
+			for tracer.explaining.Load() {
+				if time.Now().After(deadline) {
+					t.Fatal("plan capture did not finish")
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		})
+	}
+}
@@ -2,29 +2,125 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
+	"errors"
+	"math/rand/v2"
+	"strconv"
+	"sync/atomic"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cgund98/go-postgres-api-template/internal/config"
 	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/db"
 	"github.com/cgund98/go-postgres-api-template/internal/observability"
 )
 
 var logger = observability.Logger
 
+const (
+	defaultMaxAttempts    = 3
+	defaultRetryBaseDelay = 10 * time.Millisecond
+	defaultRetryMaxDelay  = 500 * time.Millisecond
+
+	// SQLSTATE codes after which the whole transaction can be run again
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// TransactionManagerOptions configures retries of transactions. Nil fields fall back to the defaults.
+type TransactionManagerOptions struct {
+	// MaxAttempts is how many times a transaction is run before a serialization failure
+	// or deadlock is returned to the caller. 1 disables retries.
+	MaxAttempts *int
+
+	// RetryBaseDelay is the backoff before the first retry. It doubles on every retry, up to RetryMaxDelay,
+	// and each delay is jittered so transactions that conflicted don't collide again.
+	RetryBaseDelay *time.Duration
+	RetryMaxDelay  *time.Duration
+
+	// Replicas receive read-only transactions, unless the context wrote recently; see WithReadYourWrites.
+	// Without replicas, every transaction goes to the primary.
+	Replicas *ReplicaSet
+}
+
+// TransactionManagerOptionsFromConfig returns the transaction options in the database config.
+// Zero values fall back to the defaults.
+func TransactionManagerOptionsFromConfig(cfg config.DatabaseConfig) TransactionManagerOptions {
+	return TransactionManagerOptions{
+		MaxAttempts: positiveOrNil(cfg.TxMaxAttempts),
+	}
+}
+
 // TransactionManager implements db.TransactionManager[*Context] for PostgreSQL.
 // It stores transactions in context using the txKey defined in context.go.
 // Repositories must use GetDBFromContext() from the same package to retrieve transactions.
 type TransactionManager struct {
-	db *sql.DB
+	pool           *pgxpool.Pool
+	replicas       *ReplicaSet
+	maxAttempts    int
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
 }
 
 // NewTransactionManager creates a new PostgreSQL transaction manager
-func NewTransactionManager(db *sql.DB) *TransactionManager {
-	return &TransactionManager{db: db}
+func NewTransactionManager(pool *pgxpool.Pool, options TransactionManagerOptions) *TransactionManager {
+	var maxAttempts = defaultMaxAttempts
+	var retryBaseDelay = defaultRetryBaseDelay
+	var retryMaxDelay = defaultRetryMaxDelay
+
+	if options.MaxAttempts != nil {
+		maxAttempts = max(*options.MaxAttempts, 1)
+	}
+
+	if options.RetryBaseDelay != nil {
+		retryBaseDelay = *options.RetryBaseDelay
+	}
+
+	if options.RetryMaxDelay != nil {
+		retryMaxDelay = *options.RetryMaxDelay
+	}
+
+	return &TransactionManager{
+		pool:           pool,
+		replicas:       options.Replicas,
+		maxAttempts:    maxAttempts,
+		retryBaseDelay: retryBaseDelay,
+		retryMaxDelay:  max(retryMaxDelay, retryBaseDelay),
+	}
 }
 
-// WithTransaction executes a function within a transaction
-func (m *TransactionManager) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
-	tx, err := m.db.BeginTx(ctx, nil)
+// WithTransaction executes a function within a transaction.
+// If the transaction hits a serialization failure or deadlock, the function is run again in a new
+// transaction, unless it was marked db.NonRetryable because it has side effects outside the database.
+//
+// Called while a transaction is already in the context, for example when one service calls another,
+// the function joins that transaction through a savepoint instead: if it fails, only its own changes are
+// rolled back, and nothing is committed until the outermost function returns. Isolation, read-only and
+// deferrable options of a nested call have no effect, since the outer transaction has already started.
+func (m *TransactionManager) WithTransaction(ctx context.Context, fn func(ctx context.Context) error, opts ...db.TxOption) error {
+	options := db.NewTxOptions(opts...)
+
+	if outer := GetTXFromContext(ctx); outer != nil {
+		if state, ok := ctx.Value(txStateKey).(*transactionState); ok && options.NonRetryable {
+			state.markNonRetryable()
+		}
+		return m.withSavepoint(ctx, outer, fn)
+	}
+
+	state := &transactionState{}
+	if options.NonRetryable {
+		state.markNonRetryable()
+	}
+	return m.retry(ctx, state, func() error {
+		return m.withTransaction(context.WithValue(ctx, txStateKey, state), fn, options)
+	})
+}
+
+// withTransaction runs fn once, committing if it succeeds and rolling back if it fails
+func (m *TransactionManager) withTransaction(ctx context.Context, fn func(ctx context.Context) error, options db.TxOptions) error {
+	tx, err := m.begin(ctx, options)
 	if err != nil {
 		return err
 	}
@@ -35,7 +131,7 @@ func (m *TransactionManager) WithTransaction(ctx context.Context, fn func(ctx co
 
 	// Execute the function
 	if err := fn(txCtx); err != nil {
-		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
 			logger.Error("failed to rollback transaction", "error", rollbackErr)
 			return rollbackErr
 		}
@@ -44,7 +140,166 @@ func (m *TransactionManager) WithTransaction(ctx context.Context, fn func(ctx co
 	}
 
 	// Commit the transaction
-	return tx.Commit()
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	if !options.ReadOnly {
+		recordWrite(ctx, time.Now())
+	}
+	return nil
+}
+
+// begin starts a transaction on the primary, or on a healthy replica for read-only transactions.
+// A replica that fails to begin is marked unhealthy and the transaction falls back to the primary.
+func (m *TransactionManager) begin(ctx context.Context, options db.TxOptions) (pgx.Tx, error) {
+	if options.ReadOnly && m.replicas != nil && !wroteRecently(ctx, time.Now()) {
+		if replica := m.replicas.pick(); replica != nil {
+			tx, err := replica.pool.pool.BeginTx(ctx, pgxTxOptions(options))
+			if err == nil || ctx.Err() != nil {
+				return tx, err
+			}
+			m.replicas.markUnhealthy(replica, err)
+		}
+	}
+	return m.pool.BeginTx(ctx, pgxTxOptions(options))
+}
+
+// withSavepoint runs fn in a savepoint of the outer transaction, rolling back to it if fn fails.
+// Releasing the savepoint keeps the changes in the outer transaction, which commits them.
+func (m *TransactionManager) withSavepoint(ctx context.Context, outer pgx.Tx, fn func(ctx context.Context) error) error {
+	sp, err := beginSavepoint(ctx, outer)
+	if err != nil {
+		return err
+	}
+
+	txCtx := context.WithValue(ctx, txKey, sp)
+
+	if err := fn(txCtx); err != nil {
+		if rollbackErr := sp.Rollback(ctx); rollbackErr != nil {
+			logger.Error("failed to rollback to savepoint", "savepoint", sp.name, "error", rollbackErr)
+			return rollbackErr
+		}
+		// The error is returned to the caller, which decides whether it is worth logging
+		logger.Debug("rolled back to savepoint", "savepoint", sp.name, "cause", err)
+		return err
+	}
+
+	return sp.Commit(ctx)
+}
+
+// savepoint is the transaction of a function nested in another transaction.
+// Statements run in the outer transaction, and Commit and Rollback release or roll back to the savepoint.
+type savepoint struct {
+	pgx.Tx
+	name  string
+	depth int
+}
+
+// beginSavepoint creates a savepoint in the outer transaction, named after its nesting depth.
+// Sibling savepoints reuse a name, which is safe since each is released or rolled back before the next.
+func beginSavepoint(ctx context.Context, outer pgx.Tx) (*savepoint, error) {
+	depth := 1
+	if parent, ok := outer.(*savepoint); ok {
+		outer = parent.Tx
+		depth = parent.depth + 1
+	}
+
+	sp := &savepoint{Tx: outer, name: "sp_" + strconv.Itoa(depth), depth: depth}
+	if _, err := outer.Exec(ctx, "SAVEPOINT "+sp.name); err != nil {
+		return nil, err
+	}
+	return sp, nil
+}
+
+// Commit releases the savepoint, keeping its changes in the outer transaction
+func (s *savepoint) Commit(ctx context.Context) error {
+	_, err := s.Tx.Exec(ctx, "RELEASE SAVEPOINT "+s.name)
+	return err
+}
+
+// Rollback undoes the changes made since the savepoint
+func (s *savepoint) Rollback(ctx context.Context) error {
+	_, err := s.Tx.Exec(ctx, "ROLLBACK TO SAVEPOINT "+s.name)
+	return err
+}
+
+// transactionState is shared by a transaction and the savepoints nested in it
+type transactionState struct {
+	// nonRetryable is set when any function in the transaction has side effects outside the database,
+	// since retrying the outermost function runs the nested ones again too
+	nonRetryable atomic.Bool
+}
+
+func (s *transactionState) markNonRetryable() {
+	s.nonRetryable.Store(true)
+}
+
+/** -------------------------------- Retries -------------------------------- */
+
+// retry runs attempt until it succeeds, fails with an error that can't be retried, or runs out of attempts
+func (m *TransactionManager) retry(ctx context.Context, state *transactionState, attempt func() error) error {
+	for n := 1; ; n++ {
+		err := attempt()
+		if err == nil || state.nonRetryable.Load() || n >= m.maxAttempts || !IsRetryable(err) {
+			return err
+		}
+
+		delay := m.backoff(n)
+		logger.Warn("retrying transaction", "attempt", n, "delay", delay, "error", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// backoff returns the jittered delay before the given retry, between half and all of the exponential delay
+func (m *TransactionManager) backoff(attempt int) time.Duration {
+	delay := m.retryBaseDelay << min(attempt-1, 30)
+	if delay <= 0 || delay > m.retryMaxDelay {
+		delay = m.retryMaxDelay
+	}
+	if delay <= 1 {
+		return delay
+	}
+	return delay/2 + rand.N(delay/2)
+}
+
+// IsRetryable reports whether the error is a serialization failure or deadlock,
+// after which running the whole transaction again can succeed
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == serializationFailure || pgErr.Code == deadlockDetected
+}
+
+/** -------------------------------- Options -------------------------------- */
+
+// pgxTxOptions converts transaction options to pgx's
+func pgxTxOptions(options db.TxOptions) pgx.TxOptions {
+	txOptions := pgx.TxOptions{}
+
+	switch options.Isolation {
+	case db.ReadCommitted:
+		txOptions.IsoLevel = pgx.ReadCommitted
+	case db.RepeatableRead:
+		txOptions.IsoLevel = pgx.RepeatableRead
+	case db.Serializable:
+		txOptions.IsoLevel = pgx.Serializable
+	}
+
+	if options.ReadOnly {
+		txOptions.AccessMode = pgx.ReadOnly
+	}
+
+	if options.Deferrable {
+		txOptions.DeferrableMode = pgx.Deferrable
+	}
+
+	return txOptions
 }
 
 // Ensure TransactionManager implements db.TransactionManager
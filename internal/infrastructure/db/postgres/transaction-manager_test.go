@@ -0,0 +1,227 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/db"
+)
+
+func TestPgxTxOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []db.TxOption
+		expected pgx.TxOptions
+	}{
+		{
+			name:     "defaults to a read-write transaction at the database's isolation level",
+			expected: pgx.TxOptions{},
+		},
+		{
+			name:     "read only",
+			opts:     []db.TxOption{db.ReadOnly()},
+			expected: pgx.TxOptions{AccessMode: pgx.ReadOnly},
+		},
+		{
+			name:     "serializable read only deferrable",
+			opts:     []db.TxOption{db.WithIsolation(db.Serializable), db.ReadOnly(), db.Deferrable()},
+			expected: pgx.TxOptions{IsoLevel: pgx.Serializable, AccessMode: pgx.ReadOnly, DeferrableMode: pgx.Deferrable},
+		},
+		{
+			name:     "repeatable read",
+			opts:     []db.TxOption{db.WithIsolation(db.RepeatableRead)},
+			expected: pgx.TxOptions{IsoLevel: pgx.RepeatableRead},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := pgxTxOptions(db.NewTxOptions(tt.opts...))
+			if actual != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "serialization failure", err: &pgconn.PgError{Code: "40001"}, expected: true},
+		{name: "deadlock", err: &pgconn.PgError{Code: "40P01"}, expected: true},
+		{name: "wrapped serialization failure", err: fmt.Errorf("failed to update user: %w", &pgconn.PgError{Code: "40001"}), expected: true},
+		{name: "unique violation", err: &pgconn.PgError{Code: "23505"}, expected: false},
+		{name: "other error", err: errors.New("connection reset"), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := IsRetryable(tt.err); actual != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestTransactionManager_retry(t *testing.T) {
+	serializationFailure := &pgconn.PgError{Code: "40001"}
+	notFound := errors.New("user not found")
+	delay := time.Millisecond
+
+	tests := []struct {
+		name             string
+		opts             []db.TxOption
+		errs             []error
+		expectedAttempts int
+		expectedError    error
+	}{
+		{
+			name:             "retries until the transaction succeeds",
+			errs:             []error{serializationFailure, serializationFailure, nil},
+			expectedAttempts: 3,
+		},
+		{
+			name:             "gives up after max attempts",
+			errs:             []error{serializationFailure, serializationFailure, serializationFailure, nil},
+			expectedAttempts: 3,
+			expectedError:    serializationFailure,
+		},
+		{
+			name:             "does not retry other errors",
+			errs:             []error{notFound, nil},
+			expectedAttempts: 1,
+			expectedError:    notFound,
+		},
+		{
+			name:             "does not retry non-retryable transactions",
+			opts:             []db.TxOption{db.NonRetryable()},
+			errs:             []error{serializationFailure, nil},
+			expectedAttempts: 1,
+			expectedError:    serializationFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewTransactionManager(nil, TransactionManagerOptions{RetryBaseDelay: &delay, RetryMaxDelay: &delay})
+
+			state := &transactionState{}
+			if db.NewTxOptions(tt.opts...).NonRetryable {
+				state.markNonRetryable()
+			}
+
+			attempts := 0
+			err := manager.retry(context.Background(), state, func() error {
+				err := tt.errs[attempts]
+				attempts++
+				return err
+			})
+
+			if !errors.Is(err, tt.expectedError) {
+				t.Errorf("expected error %v, got %v", tt.expectedError, err)
+			}
+			if attempts != tt.expectedAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.expectedAttempts, attempts)
+			}
+		})
+	}
+}
+
+func TestTransactionManager_backoff(t *testing.T) {
+	baseDelay := 10 * time.Millisecond
+	maxDelay := 50 * time.Millisecond
+	manager := NewTransactionManager(nil, TransactionManagerOptions{RetryBaseDelay: &baseDelay, RetryMaxDelay: &maxDelay})
+
+	for attempt, expected := range map[int]time.Duration{1: 10 * time.Millisecond, 2: 20 * time.Millisecond, 3: 40 * time.Millisecond, 10: maxDelay} {
+		for range 100 {
+			if delay := manager.backoff(attempt); delay < expected/2 || delay > expected {
+				t.Fatalf("expected the delay of attempt %d between %v and %v, got %v", attempt, expected/2, expected, delay)
+			}
+		}
+	}
+}
+
+// recordingTx is a pgx.Tx recording the statements a nested transaction issues
+type recordingTx struct {
+	pgx.Tx
+	statements *[]string
+}
+
+func (tx *recordingTx) Exec(_ context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	*tx.statements = append(*tx.statements, sql)
+	return pgconn.CommandTag{}, nil
+}
+
+func TestTransactionManager_WithTransaction_nested(t *testing.T) {
+	failure := errors.New("failed to delete invoices")
+
+	tests := []struct {
+		name                 string
+		opts                 []db.TxOption
+		fnErr                error
+		nested               bool
+		expectedStatements   string
+		expectedNonRetryable bool
+	}{
+		{
+			name:               "releases the savepoint and leaves the commit to the outer transaction",
+			expectedStatements: "SAVEPOINT sp_1,RELEASE SAVEPOINT sp_1",
+		},
+		{
+			name:               "rolls back only the savepoint",
+			fnErr:              failure,
+			expectedStatements: "SAVEPOINT sp_1,ROLLBACK TO SAVEPOINT sp_1",
+		},
+		{
+			name:               "names savepoints after their depth",
+			nested:             true,
+			expectedStatements: "SAVEPOINT sp_1,SAVEPOINT sp_2,RELEASE SAVEPOINT sp_2,RELEASE SAVEPOINT sp_1",
+		},
+		{
+			name:                 "makes the outer transaction non-retryable",
+			opts:                 []db.TxOption{db.NonRetryable()},
+			expectedStatements:   "SAVEPOINT sp_1,RELEASE SAVEPOINT sp_1",
+			expectedNonRetryable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var statements []string
+			outer := &recordingTx{statements: &statements}
+			state := &transactionState{}
+			ctx := context.WithValue(context.WithValue(context.Background(), txStateKey, state), txKey, outer)
+
+			manager := NewTransactionManager(nil, TransactionManagerOptions{})
+			err := manager.WithTransaction(ctx, func(txCtx context.Context) error {
+				if tx := GetTXFromContext(txCtx); tx == outer || tx == nil {
+					t.Error("expected the nested function to run in the savepoint")
+				}
+				if tt.nested {
+					return manager.WithTransaction(txCtx, func(context.Context) error { return nil })
+				}
+				return tt.fnErr
+			}, tt.opts...)
+
+			if !errors.Is(err, tt.fnErr) {
+				t.Errorf("expected error %v, got %v", tt.fnErr, err)
+			}
+			if actual := strings.Join(statements, ","); actual != tt.expectedStatements {
+				t.Errorf("expected statements %s, got %s", tt.expectedStatements, actual)
+			}
+			if actual := state.nonRetryable.Load(); actual != tt.expectedNonRetryable {
+				t.Errorf("expected non-retryable %v, got %v", tt.expectedNonRetryable, actual)
+			}
+		})
+	}
+}
@@ -6,5 +6,74 @@ import "context"
 // It's generic over the database context type
 type TransactionManager interface {
 	// WithTransaction executes a function within a transaction
-	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error, opts ...TxOption) error
+}
+
+/** -------------------------------- Options -------------------------------- */
+
+// IsolationLevel is the SQL isolation level of a transaction
+type IsolationLevel string
+
+const (
+	// DefaultIsolation uses the database's default, READ COMMITTED for Postgres
+	DefaultIsolation IsolationLevel = ""
+	ReadCommitted    IsolationLevel = "read committed"
+	RepeatableRead   IsolationLevel = "repeatable read"
+	Serializable     IsolationLevel = "serializable"
+)
+
+// TxOptions describes the consistency a transaction needs
+type TxOptions struct {
+	Isolation IsolationLevel
+
+	// ReadOnly rejects writes, and lets the database skip work it does for read-write transactions
+	ReadOnly bool
+
+	// Deferrable lets a SERIALIZABLE READ ONLY transaction wait for a snapshot that can't
+	// be involved in a serialization failure, so long reports never have to be retried
+	Deferrable bool
+
+	// NonRetryable stops the transaction from being run again after a serialization failure or deadlock.
+	// Set it when the function has side effects outside the database, such as publishing events.
+	NonRetryable bool
+}
+
+// TxOption configures a transaction started by WithTransaction
+type TxOption func(*TxOptions)
+
+// WithIsolation sets the isolation level of the transaction
+func WithIsolation(level IsolationLevel) TxOption {
+	return func(o *TxOptions) {
+		o.Isolation = level
+	}
+}
+
+// ReadOnly starts a read-only transaction
+func ReadOnly() TxOption {
+	return func(o *TxOptions) {
+		o.ReadOnly = true
+	}
+}
+
+// Deferrable starts a deferrable transaction. Only has an effect on SERIALIZABLE READ ONLY transactions.
+func Deferrable() TxOption {
+	return func(o *TxOptions) {
+		o.Deferrable = true
+	}
+}
+
+// NonRetryable runs the function at most once, for functions with side effects outside the database
+func NonRetryable() TxOption {
+	return func(o *TxOptions) {
+		o.NonRetryable = true
+	}
+}
+
+// NewTxOptions applies the options to a read-write transaction at the default isolation level
+func NewTxOptions(opts ...TxOption) TxOptions {
+	var options TxOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
 }
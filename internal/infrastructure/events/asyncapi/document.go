@@ -0,0 +1,180 @@
+package asyncapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/publisher"
+	"github.com/cgund98/go-postgres-api-template/internal/observability"
+)
+
+var logger = observability.Logger
+
+const (
+	// Version is the AsyncAPI specification version of the generated document
+	Version = "3.0.0"
+
+	contentType   = "application/json"
+	schemasPrefix = "#/components/schemas/"
+
+	// eventTypeAttribute is the SNS message attribute set by the publisher with the event type
+	eventTypeAttribute = "event_type"
+)
+
+// Document is an AsyncAPI 3 document describing the events the application publishes
+type Document struct {
+	AsyncAPI           string               `json:"asyncapi"`
+	Info               Info                 `json:"info"`
+	DefaultContentType string               `json:"defaultContentType"`
+	Channels           map[string]Channel   `json:"channels"`
+	Operations         map[string]Operation `json:"operations"`
+	Components         Components           `json:"components"`
+}
+
+// Info describes the application publishing the events
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Channel is an SNS topic events are published to
+type Channel struct {
+	Address     string               `json:"address"`
+	Description string               `json:"description,omitempty"`
+	Messages    map[string]Reference `json:"messages"`
+	Bindings    map[string]any       `json:"bindings,omitempty"`
+}
+
+// Operation describes the application sending messages to a channel
+type Operation struct {
+	Action   string      `json:"action"`
+	Channel  Reference   `json:"channel"`
+	Summary  string      `json:"summary,omitempty"`
+	Messages []Reference `json:"messages"`
+}
+
+// Message describes an event type
+type Message struct {
+	Name        string       `json:"name"`
+	Title       string       `json:"title,omitempty"`
+	Summary     string       `json:"summary,omitempty"`
+	ContentType string       `json:"contentType"`
+	Headers     *huma.Schema `json:"headers,omitempty"`
+	Payload     *huma.Schema `json:"payload"`
+
+	// Metadata holds the registration metadata, such as the owning domain
+	Metadata map[string]string `json:"x-metadata,omitempty"`
+
+	// SchemaVersion is the version of the payload schema from the event registry
+	SchemaVersion int `json:"x-schema-version,omitempty"`
+}
+
+// Components holds the messages and the JSON Schemas of their payloads
+type Components struct {
+	Messages map[string]Message      `json:"messages"`
+	Schemas  map[string]*huma.Schema `json:"schemas"`
+}
+
+// Reference is a JSON reference to another part of the document
+type Reference struct {
+	Ref string `json:"$ref"`
+}
+
+// Generate builds an AsyncAPI document from the registered event types.
+// Each topic in the router is a channel carrying the event types routed to it, and each event type
+// is a message whose payload schema is derived from its Go struct.
+// Event types that don't match any route are documented as messages without a channel.
+func Generate(registry *events.Registry, router *publisher.Router, info Info) *Document {
+	schemas := huma.NewMapRegistry(schemasPrefix, huma.DefaultSchemaNamer)
+
+	document := &Document{
+		AsyncAPI:           Version,
+		Info:               info,
+		DefaultContentType: contentType,
+		Channels:           map[string]Channel{},
+		Operations:         map[string]Operation{},
+		Components: Components{
+			Messages: map[string]Message{},
+		},
+	}
+
+	for _, registration := range registry.Registrations() {
+		document.Components.Messages[registration.Type] = newMessage(schemas, registration)
+
+		topicARN, err := router.TopicARN(registration.Type)
+		if err != nil {
+			logger.Warn("event type is not routed to a topic, so it has no channel", "event_type", registration.Type)
+			continue
+		}
+
+		channelID := topicName(topicARN)
+		channel, ok := document.Channels[channelID]
+		if !ok {
+			channel = Channel{
+				Address:     channelID,
+				Description: "SNS topic " + topicARN,
+				Messages:    map[string]Reference{},
+				Bindings:    map[string]any{"sns": map[string]any{"name": channelID}},
+			}
+		}
+		channel.Messages[registration.Type] = Reference{Ref: "#/components/messages/" + registration.Type}
+		document.Channels[channelID] = channel
+
+		operationID := "publish." + registration.Type
+		document.Operations[operationID] = Operation{
+			Action:   "send",
+			Channel:  Reference{Ref: "#/channels/" + channelID},
+			Summary:  registration.Description,
+			Messages: []Reference{{Ref: "#/channels/" + channelID + "/messages/" + registration.Type}},
+		}
+	}
+
+	document.Components.Schemas = schemas.Map()
+	return document
+}
+
+// newMessage describes a registered event type, adding the schema of its payload to the registry
+func newMessage(schemas huma.Registry, registration events.Registration) Message {
+	return Message{
+		Name:        registration.Type,
+		Title:       registration.GoType().Name(),
+		Summary:     registration.Description,
+		ContentType: contentType,
+		Headers: &huma.Schema{
+			Type:        huma.TypeObject,
+			Description: "SNS message attributes, used by subscription filter policies",
+			Properties: map[string]*huma.Schema{
+				eventTypeAttribute: {Type: huma.TypeString, Enum: []any{registration.Type}},
+			},
+			Required: []string{eventTypeAttribute},
+		},
+		Payload:       schemas.Schema(registration.GoType(), true, registration.GoType().Name()),
+		Metadata:      registration.Metadata,
+		SchemaVersion: registration.Version,
+	}
+}
+
+// topicName returns the name of a topic from its ARN
+func topicName(topicARN string) string {
+	return topicARN[strings.LastIndex(topicARN, ":")+1:]
+}
+
+// Handler serves the document as JSON. The document is encoded once, since the registry doesn't change at runtime.
+func Handler(document *Document) (http.HandlerFunc, error) {
+	data, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		if _, err := w.Write(data); err != nil {
+			logger.Error("Failed to write AsyncAPI document", "error", err)
+		}
+	}, nil
+}
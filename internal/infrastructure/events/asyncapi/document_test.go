@@ -0,0 +1,112 @@
+package asyncapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	userEvents "github.com/cgund98/go-postgres-api-template/internal/domain/user/events"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/publisher"
+)
+
+func TestGenerate(t *testing.T) {
+	registry := events.NewRegistry()
+	if err := userEvents.RegisterEvents(registry); err != nil {
+		t.Fatalf("failed to register events: %v", err)
+	}
+
+	tests := []struct {
+		name               string
+		routes             []publisher.Route
+		expectedChannels   map[string]int
+		expectedOperations int
+	}{
+		{
+			name:               "puts every event on the default topic",
+			routes:             []publisher.Route{{Pattern: "*", TopicARN: "arn:aws:sns:us-east-1:000000000000:events-topic"}},
+			expectedChannels:   map[string]int{"events-topic": 3},
+			expectedOperations: 3,
+		},
+		{
+			name: "creates a channel per routed topic",
+			routes: []publisher.Route{
+				{Pattern: "user.deleted", TopicARN: "arn:aws:sns:us-east-1:000000000000:audit-topic"},
+				{Pattern: "user.*", TopicARN: "arn:aws:sns:us-east-1:000000000000:user-topic"},
+			},
+			expectedChannels:   map[string]int{"audit-topic": 1, "user-topic": 2},
+			expectedOperations: 3,
+		},
+		{
+			name:               "documents unrouted events without a channel",
+			routes:             []publisher.Route{{Pattern: "user.created", TopicARN: "arn:aws:sns:us-east-1:000000000000:user-topic"}},
+			expectedChannels:   map[string]int{"user-topic": 1},
+			expectedOperations: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, err := publisher.NewRouter(publisher.RouterOptions{Routes: tt.routes})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			document := Generate(registry, router, Info{Title: "Test", Version: "1.0.0"})
+
+			if document.AsyncAPI != Version {
+				t.Errorf("expected version %s, got %s", Version, document.AsyncAPI)
+			}
+			if len(document.Channels) != len(tt.expectedChannels) {
+				t.Errorf("expected %d channels, got %d", len(tt.expectedChannels), len(document.Channels))
+			}
+			for channelID, messages := range tt.expectedChannels {
+				if len(document.Channels[channelID].Messages) != messages {
+					t.Errorf("expected %d messages on channel %s, got %d", messages, channelID, len(document.Channels[channelID].Messages))
+				}
+			}
+			if len(document.Operations) != tt.expectedOperations {
+				t.Errorf("expected %d operations, got %d", tt.expectedOperations, len(document.Operations))
+			}
+
+			// Every registered event is documented with a payload schema, whether or not it is routed
+			if len(document.Components.Messages) != 3 {
+				t.Errorf("expected 3 messages, got %d", len(document.Components.Messages))
+			}
+			message := document.Components.Messages[userEvents.EventTypeUserCreated]
+			if message.Payload.Ref != "#/components/schemas/UserCreatedEvent" || message.SchemaVersion != 1 {
+				t.Errorf("unexpected message: %+v", message)
+			}
+			schema := document.Components.Schemas["UserCreatedEvent"]
+			if schema == nil || schema.Properties["event_id"] == nil || schema.Properties["after"] == nil {
+				t.Errorf("expected schema with event metadata and snapshot fields, got %+v", schema)
+			}
+			if document.Components.Schemas["UserSnapshot"] == nil {
+				t.Error("expected nested snapshot schema")
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	document := Generate(events.NewRegistry(), &publisher.Router{}, Info{Title: "Test", Version: "1.0.0"})
+	handler, err := Handler(document)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/asyncapi.json", nil))
+
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected response: %d %s", recorder.Code, recorder.Header().Get("Content-Type"))
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(recorder.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("expected JSON body: %v", err)
+	}
+	if decoded["asyncapi"] != Version {
+		t.Errorf("expected asyncapi %s, got %v", Version, decoded["asyncapi"])
+	}
+}
@@ -25,6 +25,27 @@ type EventMetadata struct {
 	EventID   string    `json:"event_id"`
 	EventType string    `json:"event_type"`
 	Timestamp time.Time `json:"timestamp"`
+
+	// Sequence is the position of the event in the history of its aggregate, starting at 1.
+	// Zero means the event was produced without a sequence.
+	Sequence int64 `json:"sequence,omitempty"`
+}
+
+// Sequenced is implemented by events that carry a per-aggregate sequence number.
+// Every event embedding EventMetadata implements it.
+type Sequenced interface {
+	SequenceNumber() int64
+	SetSequence(sequence int64)
+}
+
+// SequenceNumber implements the Sequenced interface
+func (m *EventMetadata) SequenceNumber() int64 {
+	return m.Sequence
+}
+
+// SetSequence implements the Sequenced interface
+func (m *EventMetadata) SetSequence(sequence int64) {
+	m.Sequence = sequence
 }
 
 // NewBaseEvent creates a new BaseEvent with a generated EventID and current timestamp.
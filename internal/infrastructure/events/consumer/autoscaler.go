@@ -0,0 +1,130 @@
+package consumer
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTargetDrainTime = 30 * time.Second
+
+	// latencySmoothing is the weight of the latest interval in the handler latency moving average
+	latencySmoothing = 0.5
+)
+
+// AutoscalingOptions lets a consumer scale its number of pollers with the queue backlog
+type AutoscalingOptions struct {
+	// MinConcurrency is the number of pollers kept running when the queue is empty. Defaults to 1.
+	MinConcurrency int
+
+	// MaxConcurrency caps the number of pollers. Raised to MinConcurrency if lower.
+	MaxConcurrency int
+
+	// TargetDrainTime is how quickly the pollers should be able to work through the backlog,
+	// given the observed handler latency. Lower values scale up harder on bursts.
+	TargetDrainTime *time.Duration
+}
+
+// autoscaler sizes a consumer's poller pool from the sampled backlog and handler latency.
+// It scales up straight to the size needed, but only scales down by one poller per decision,
+// so a momentary dip in the backlog during a burst doesn't shed pollers that are about to be needed again.
+type autoscaler struct {
+	minConcurrency  int
+	maxConcurrency  int
+	targetDrainTime time.Duration
+
+	mu sync.Mutex
+
+	// backlog is the latest ApproximateNumberOfMessages sample, or -1 before the first sample
+	backlog float64
+
+	// handled and handlerSeconds accumulate handler work since the last decision
+	handled        int
+	handlerSeconds float64
+
+	// latency is the moving average handler latency per message, in seconds
+	latency float64
+}
+
+func newAutoscaler(options AutoscalingOptions) *autoscaler {
+	var minConcurrency = 1
+	var targetDrainTime = defaultTargetDrainTime
+
+	if options.MinConcurrency > 0 {
+		minConcurrency = options.MinConcurrency
+	}
+
+	if options.TargetDrainTime != nil && *options.TargetDrainTime > 0 {
+		targetDrainTime = *options.TargetDrainTime
+	}
+
+	return &autoscaler{
+		minConcurrency:  minConcurrency,
+		maxConcurrency:  max(options.MaxConcurrency, minConcurrency),
+		targetDrainTime: targetDrainTime,
+		backlog:         -1,
+	}
+}
+
+// recordBacklog stores the latest number of visible messages in the queue
+func (a *autoscaler) recordBacklog(visible float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.backlog = visible
+}
+
+// recordHandled adds the time spent handling a number of messages
+func (a *autoscaler) recordHandled(messages int, elapsed time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.handled += messages
+	a.handlerSeconds += elapsed.Seconds()
+}
+
+// decide returns the number of pollers the consumer should run, along with the backlog and latency used
+func (a *autoscaler) decide(current int) (desired int, backlog float64, latency time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.handled > 0 {
+		observed := a.handlerSeconds / float64(a.handled)
+		if a.latency == 0 {
+			a.latency = observed
+		} else {
+			a.latency = latencySmoothing*observed + (1-latencySmoothing)*a.latency
+		}
+		a.handled = 0
+		a.handlerSeconds = 0
+	}
+
+	latency = time.Duration(a.latency * float64(time.Second))
+	return a.desiredConcurrency(current, a.backlog, a.latency), a.backlog, latency
+}
+
+// desiredConcurrency computes the pool size that drains the backlog within the target drain time.
+// Each poller handles roughly one message per latency seconds. Until a latency has been observed,
+// a backlog doubles the pool instead. Without a backlog sample, the pool is left as it is.
+func (a *autoscaler) desiredConcurrency(current int, backlog, latency float64) int {
+	if backlog < 0 {
+		return min(max(current, a.minConcurrency), a.maxConcurrency)
+	}
+
+	var needed int
+	switch {
+	case backlog == 0:
+		needed = a.minConcurrency
+	case latency == 0:
+		needed = current * 2
+	default:
+		needed = int(math.Ceil(backlog * latency / a.targetDrainTime.Seconds()))
+	}
+
+	needed = min(max(needed, a.minConcurrency), a.maxConcurrency)
+	if needed < current {
+		needed = max(current-1, a.minConcurrency)
+	}
+	return needed
+}
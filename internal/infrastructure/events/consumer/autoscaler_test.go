@@ -0,0 +1,141 @@
+package consumer
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/cgund98/go-postgres-api-template/internal/domain/user/events"
+)
+
+func TestAutoscaler_decide(t *testing.T) {
+	drainTime := 10 * time.Second
+
+	tests := []struct {
+		name            string
+		current         int
+		backlog         float64
+		handled         int
+		handlerTime     time.Duration
+		expectedDesired int
+	}{
+		{
+			name:            "keeps the pool until the backlog is sampled",
+			current:         3,
+			backlog:         -1,
+			expectedDesired: 3,
+		},
+		{
+			name:            "sizes the pool to drain the backlog in time",
+			current:         2,
+			backlog:         100,
+			handled:         10,
+			handlerTime:     5 * time.Second,
+			expectedDesired: 5,
+		},
+		{
+			name:            "caps the pool at max concurrency",
+			current:         2,
+			backlog:         10000,
+			handled:         10,
+			handlerTime:     5 * time.Second,
+			expectedDesired: 8,
+		},
+		{
+			name:            "doubles the pool before any latency is observed",
+			current:         3,
+			backlog:         100,
+			expectedDesired: 6,
+		},
+		{
+			name:            "scales down one poller at a time",
+			current:         6,
+			backlog:         0,
+			expectedDesired: 5,
+		},
+		{
+			name:            "does not scale below min concurrency",
+			current:         2,
+			backlog:         0,
+			expectedDesired: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scaler := newAutoscaler(AutoscalingOptions{MinConcurrency: 2, MaxConcurrency: 8, TargetDrainTime: &drainTime})
+			if tt.backlog >= 0 {
+				scaler.recordBacklog(tt.backlog)
+			}
+			if tt.handled > 0 {
+				scaler.recordHandled(tt.handled, tt.handlerTime)
+			}
+
+			if desired, _, _ := scaler.decide(tt.current); desired != tt.expectedDesired {
+				t.Errorf("expected %d pollers, got %d", tt.expectedDesired, desired)
+			}
+		})
+	}
+}
+
+// autoscalingSQSClient serves receives and queue attributes to concurrent pollers
+// without touching the mock's call counters
+type autoscalingSQSClient struct {
+	*mockSQSClient
+	receive    func(*sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error)
+	attributes func(*sqs.GetQueueAttributesInput) (*sqs.GetQueueAttributesOutput, error)
+}
+
+func (m autoscalingSQSClient) ReceiveMessage(input *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
+	return m.receive(input)
+}
+
+func (m autoscalingSQSClient) GetQueueAttributes(input *sqs.GetQueueAttributesInput) (*sqs.GetQueueAttributesOutput, error) {
+	return m.attributes(input)
+}
+
+func TestSQSConsumer_autoscaling(t *testing.T) {
+	var visible atomic.Int64
+	visible.Store(100)
+
+	receive := func(_ *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
+		time.Sleep(time.Millisecond)
+		return &sqs.ReceiveMessageOutput{}, nil
+	}
+	attributes := func(_ *sqs.GetQueueAttributesInput) (*sqs.GetQueueAttributesOutput, error) {
+		return &sqs.GetQueueAttributesOutput{Attributes: map[string]*string{
+			sqs.QueueAttributeNameApproximateNumberOfMessages: aws.String(strconv.FormatInt(visible.Load(), 10)),
+		}}, nil
+	}
+
+	interval := 10 * time.Millisecond
+	consumer := NewSQSConsumer[*events.UserCreatedEvent](autoscalingSQSClient{&mockSQSClient{}, receive, attributes}, SQSConsumerOptions{
+		QueueURL:                "https://sqs.us-east-1.amazonaws.com/123456789/autoscaling-test-queue",
+		QueueAttributesInterval: &interval,
+		Autoscaling:             &AutoscalingOptions{MinConcurrency: 1, MaxConcurrency: 4},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	consumer.Start(ctx, &mockDeserializer{}, &mockHandler{})
+
+	waitForConcurrency := func(expected int) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for consumer.Status().Concurrency != expected {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected concurrency %d, got %d", expected, consumer.Status().Concurrency)
+			}
+			time.Sleep(interval)
+		}
+	}
+
+	waitForConcurrency(4)
+	visible.Store(0)
+	waitForConcurrency(1)
+}
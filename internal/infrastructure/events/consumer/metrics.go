@@ -0,0 +1,107 @@
+package consumer
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/cgund98/go-postgres-api-template/internal/observability"
+)
+
+const (
+	metricsSubsystem = "sqs_consumer"
+
+	// unknownEventType is used as the event_type label when a message carries no type information
+	unknownEventType = "unknown"
+)
+
+// consumerMetrics holds the Prometheus collectors shared by all SQS consumers.
+// Every series is labelled by queue name and event type so lag can be tracked per event type.
+type consumerMetrics struct {
+	received        *prometheus.CounterVec
+	handled         *prometheus.CounterVec
+	failed          *prometheus.CounterVec
+	filtered        *prometheus.CounterVec
+	deadLettered    *prometheus.CounterVec
+	handlerDuration *prometheus.HistogramVec
+	queueMessages   *prometheus.GaugeVec
+	oldestMessage   *prometheus.GaugeVec
+	pollers         *prometheus.GaugeVec
+}
+
+func newConsumerMetrics(registerer prometheus.Registerer) *consumerMetrics {
+	labels := []string{"queue", "event_type"}
+
+	m := &consumerMetrics{
+		received: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: observability.MetricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "messages_received_total",
+			Help:      "Number of messages received from the queue.",
+		}, labels),
+		handled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: observability.MetricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "messages_handled_total",
+			Help:      "Number of messages handled successfully and acknowledged.",
+		}, labels),
+		failed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: observability.MetricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "messages_failed_total",
+			Help:      "Number of messages that failed to deserialize, handle or acknowledge.",
+		}, labels),
+		filtered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: observability.MetricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "messages_filtered_total",
+			Help:      "Number of messages rejected by the consumer's filter and acknowledged without being handled.",
+		}, labels),
+		deadLettered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: observability.MetricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "messages_dead_lettered_total",
+			Help:      "Number of messages that failed on their final receive and will be moved to the dead-letter queue.",
+		}, labels),
+		handlerDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: observability.MetricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "handler_duration_seconds",
+			Help:      "Time spent in the event handler.",
+			Buckets:   prometheus.DefBuckets,
+		}, labels),
+		queueMessages: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: observability.MetricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "queue_messages",
+			Help:      "Approximate number of messages in the queue by state (visible, in_flight, delayed), sampled from GetQueueAttributes.",
+		}, append(labels, "state")),
+		oldestMessage: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: observability.MetricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "oldest_message_age_seconds",
+			Help:      "Age of the oldest message in the most recent receive, based on its SentTimestamp.",
+		}, labels),
+		pollers: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: observability.MetricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "pollers",
+			Help:      "Number of goroutines polling the queue, which changes over time when autoscaling is enabled.",
+		}, []string{"queue"}),
+	}
+
+	registerer.MustRegister(
+		m.received,
+		m.handled,
+		m.failed,
+		m.filtered,
+		m.deadLettered,
+		m.handlerDuration,
+		m.queueMessages,
+		m.oldestMessage,
+		m.pollers,
+	)
+
+	return m
+}
+
+// metrics is shared by every consumer in the process, since collectors can only be registered once
+var metrics = newConsumerMetrics(observability.Registry)
@@ -0,0 +1,255 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	mockaws "github.com/cgund98/go-postgres-api-template/internal/infrastructure/aws"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/deserializer"
+	"github.com/cgund98/go-postgres-api-template/internal/observability"
+)
+
+const (
+	defaultMaxStarvation       = 30 * time.Second
+	defaultIdleWaitTimeSeconds = 1
+
+	// pausedBackoff is how long a poller waits before checking again when every queue is paused
+	pausedBackoff = 1 * time.Second
+)
+
+// PriorityQueue is a queue polled by a PriorityConsumer
+type PriorityQueue struct {
+	// Weight is the queue's share of polls while every queue has messages. Defaults to 1.
+	Weight int
+
+	// Options configures the queue's consumer. Concurrency and WaitTimeSeconds are ignored:
+	// the priority consumer polls every queue from its own goroutines.
+	Options SQSConsumerOptions
+}
+
+type PriorityConsumerOptions struct {
+	Queues []PriorityQueue
+
+	// Concurrency is the number of goroutines polling and handling messages in parallel. Defaults to 1.
+	Concurrency *int
+
+	// MaxStarvation is the longest a queue with a low weight goes without being polled,
+	// for when handlers are slow enough that weighted turns alone would leave it waiting
+	MaxStarvation *time.Duration
+
+	// IdleWaitTimeSeconds is how long the highest weighted queue is long polled once every queue
+	// came back empty. Kept short so traffic arriving on the other queues is picked up quickly.
+	IdleWaitTimeSeconds *int64
+}
+
+// PriorityConsumer polls several SQS queues carrying the same events and feeds them to one handler.
+// Queues are chosen by smooth weighted round robin: with weights 3 and 1, a busy high-priority queue is
+// polled three times for every poll of the low-priority queue, interleaved rather than in bursts.
+// Empty queues give up their turn immediately, so the high-priority queue gets every poll while the
+// other queues are empty, and a backlog of bulk traffic only ever takes its share of the pollers.
+// Keep the batch size of low-priority queues small to bound how long one of their batches holds a poller.
+//
+// Each queue is an SQSConsumer and can be inspected, paused and resumed on its own; see Consumers.
+type PriorityConsumer[T events.Event] struct {
+	queues              []*priorityQueue[T]
+	concurrency         int
+	maxStarvation       time.Duration
+	idleWaitTimeSeconds int64
+	logger              *slog.Logger
+
+	mu sync.Mutex
+}
+
+// priorityQueue holds the scheduling state of one queue
+type priorityQueue[T events.Event] struct {
+	consumer *SQSConsumer[T]
+	weight   int
+
+	// current is the queue's smooth weighted round robin counter
+	current int
+
+	lastPolledAt time.Time
+}
+
+// NewPriorityConsumer creates a consumer polling the given queues by weight
+func NewPriorityConsumer[T events.Event](sqsClient mockaws.SQSClientInterface, options PriorityConsumerOptions) (*PriorityConsumer[T], error) {
+	if len(options.Queues) == 0 {
+		return nil, errors.New("priority consumer needs at least one queue")
+	}
+
+	var concurrency = 1
+	var maxStarvation = defaultMaxStarvation
+	var idleWaitTimeSeconds = int64(defaultIdleWaitTimeSeconds)
+
+	if options.Concurrency != nil && *options.Concurrency > 0 {
+		concurrency = *options.Concurrency
+	}
+
+	if options.MaxStarvation != nil {
+		maxStarvation = *options.MaxStarvation
+	}
+
+	if options.IdleWaitTimeSeconds != nil {
+		idleWaitTimeSeconds = *options.IdleWaitTimeSeconds
+	}
+
+	queues := make([]*priorityQueue[T], 0, len(options.Queues))
+	for _, queue := range options.Queues {
+		weight := 1
+		if queue.Weight > 0 {
+			weight = queue.Weight
+		}
+		queues = append(queues, &priorityQueue[T]{
+			consumer: NewSQSConsumer[T](sqsClient, queue.Options),
+			weight:   weight,
+		})
+	}
+
+	return &PriorityConsumer[T]{
+		queues:              queues,
+		concurrency:         concurrency,
+		maxStarvation:       maxStarvation,
+		idleWaitTimeSeconds: idleWaitTimeSeconds,
+		logger:              observability.Logger.With("consumer", "priority"),
+	}, nil
+}
+
+// Consumers returns the consumer of each queue, in the order they were configured
+func (c *PriorityConsumer[T]) Consumers() []*SQSConsumer[T] {
+	consumers := make([]*SQSConsumer[T], len(c.queues))
+	for i, queue := range c.queues {
+		consumers[i] = queue.consumer
+	}
+	return consumers
+}
+
+// Start starts consuming messages from every queue. This will begin in new goroutines and return immediately.
+func (c *PriorityConsumer[T]) Start(ctx context.Context, deserializer deserializer.Deserializer[T], handler events.Handler[T]) {
+	for _, queue := range c.queues {
+		queue.consumer.startQueueAttributesSampler(ctx)
+		queue.consumer.setRunning()
+	}
+
+	c.logger.Info("starting priority consumer", "queues", len(c.queues), "concurrency", c.concurrency)
+
+	var wg sync.WaitGroup
+	wg.Add(c.concurrency)
+	for range c.concurrency {
+		go func() {
+			defer wg.Done()
+			c.poll(ctx, deserializer, handler)
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		for _, queue := range c.queues {
+			queue.consumer.setStopped()
+		}
+		c.logger.Info("priority consumer context canceled, stopping")
+	}()
+}
+
+// poll repeatedly polls the next queue until the context is canceled.
+// Once a full round of turns has come back empty, so every queue was polled at least once,
+// the highest weighted queue is long polled instead of spinning through empty receives.
+func (c *PriorityConsumer[T]) poll(ctx context.Context, deserializer deserializer.Deserializer[T], handler events.Handler[T]) {
+	empty := 0
+	for ctx.Err() == nil {
+		var queue *priorityQueue[T]
+		var waitTimeSeconds int64
+		idle := empty >= c.activeWeight()
+		if idle {
+			queue = c.highest()
+			waitTimeSeconds = c.idleWaitTimeSeconds
+		} else {
+			queue = c.next(time.Now())
+		}
+
+		if queue == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(pausedBackoff):
+			}
+			continue
+		}
+
+		if queue.consumer.pollSingleMessages(ctx, deserializer, handler, waitTimeSeconds) > 0 || idle {
+			empty = 0
+		} else {
+			empty++
+		}
+	}
+}
+
+// next picks the queue to poll by smooth weighted round robin, skipping paused queues.
+// A queue that has not been polled within maxStarvation is picked first.
+// Returns nil if every queue is paused.
+func (c *PriorityConsumer[T]) next(now time.Time) *priorityQueue[T] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var starved, selected *priorityQueue[T]
+	total := 0
+	for _, queue := range c.queues {
+		if queue.consumer.paused() {
+			continue
+		}
+		if c.maxStarvation > 0 && !queue.lastPolledAt.IsZero() && now.Sub(queue.lastPolledAt) > c.maxStarvation {
+			if starved == nil || queue.lastPolledAt.Before(starved.lastPolledAt) {
+				starved = queue
+			}
+		}
+
+		queue.current += queue.weight
+		total += queue.weight
+		if selected == nil || queue.current > selected.current {
+			selected = queue
+		}
+	}
+
+	if starved != nil {
+		selected = starved
+	}
+	if selected != nil {
+		selected.current -= total
+		selected.lastPolledAt = now
+	}
+	return selected
+}
+
+// highest returns the unpaused queue with the highest weight, or nil if every queue is paused
+func (c *PriorityConsumer[T]) highest() *priorityQueue[T] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var selected *priorityQueue[T]
+	for _, queue := range c.queues {
+		if queue.consumer.paused() {
+			continue
+		}
+		if selected == nil || queue.weight > selected.weight {
+			selected = queue
+		}
+	}
+	if selected != nil {
+		selected.lastPolledAt = time.Now()
+	}
+	return selected
+}
+
+// activeWeight returns the total weight of the queues that are not paused,
+// which is the number of turns in one round of smooth weighted round robin
+func (c *PriorityConsumer[T]) activeWeight() int {
+	total := 0
+	for _, queue := range c.queues {
+		if !queue.consumer.paused() {
+			total += queue.weight
+		}
+	}
+	return total
+}
@@ -0,0 +1,175 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/cgund98/go-postgres-api-template/internal/domain/user/events"
+)
+
+func TestPriorityConsumer_next(t *testing.T) {
+	queue := func(name string, weight int) PriorityQueue {
+		return PriorityQueue{
+			Weight:  weight,
+			Options: SQSConsumerOptions{QueueURL: "https://sqs.us-east-1.amazonaws.com/123456789/" + name},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		queues        []PriorityQueue
+		paused        []int
+		starved       int
+		picks         int
+		expectedOrder string
+	}{
+		{
+			name:          "interleaves queues by weight",
+			queues:        []PriorityQueue{queue("high", 3), queue("low", 1)},
+			picks:         8,
+			expectedOrder: "high,high,low,high,high,high,low,high",
+		},
+		{
+			name:          "defaults weights to 1",
+			queues:        []PriorityQueue{queue("a", 0), queue("b", 0)},
+			picks:         4,
+			expectedOrder: "a,b,a,b",
+		},
+		{
+			name:          "skips paused queues",
+			queues:        []PriorityQueue{queue("high", 3), queue("low", 1)},
+			paused:        []int{0},
+			picks:         3,
+			expectedOrder: "low,low,low",
+		},
+		{
+			name:          "returns nothing when every queue is paused",
+			queues:        []PriorityQueue{queue("high", 3), queue("low", 1)},
+			paused:        []int{0, 1},
+			picks:         2,
+			expectedOrder: ",",
+		},
+		{
+			name:          "picks a starved queue first",
+			queues:        []PriorityQueue{queue("high", 100), queue("low", 1)},
+			starved:       1,
+			picks:         3,
+			expectedOrder: "low,high,high",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consumer, err := NewPriorityConsumer[*events.UserCreatedEvent](&mockSQSClient{}, PriorityConsumerOptions{Queues: tt.queues})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, i := range tt.paused {
+				consumer.queues[i].consumer.Pause()
+			}
+
+			now := time.Now()
+			if tt.starved > 0 {
+				consumer.queues[tt.starved].lastPolledAt = now.Add(-2 * defaultMaxStarvation)
+			}
+
+			order := make([]string, tt.picks)
+			for i := range order {
+				if queue := consumer.next(now); queue != nil {
+					order[i] = queue.consumer.Name()
+				}
+			}
+			if actual := strings.Join(order, ","); actual != tt.expectedOrder {
+				t.Errorf("expected order %s, got %s", tt.expectedOrder, actual)
+			}
+		})
+	}
+}
+
+func TestNewPriorityConsumer(t *testing.T) {
+	if _, err := NewPriorityConsumer[*events.UserCreatedEvent](&mockSQSClient{}, PriorityConsumerOptions{}); err == nil {
+		t.Error("expected error for a priority consumer without queues")
+	}
+}
+
+// concurrentSQSClient is a mockSQSClient safe to share between the samplers of several queues
+type concurrentSQSClient struct {
+	*mockSQSClient
+}
+
+func (m concurrentSQSClient) GetQueueAttributes(_ *sqs.GetQueueAttributesInput) (*sqs.GetQueueAttributesOutput, error) {
+	return &sqs.GetQueueAttributesOutput{}, nil
+}
+
+func TestPriorityConsumer_Start(t *testing.T) {
+	const receives = 8
+	body := `{"event_id":"test-id","event_type":"user.created","timestamp":"2023-01-01T00:00:00Z","user_id":"user-123","email":"test@example.com"}`
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var polls []string
+	lowDelivered := false
+	mockClient := &mockSQSClient{
+		receiveMessageFunc: func(input *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			queueName := queueNameFromURL(*input.QueueUrl)
+			polls = append(polls, fmt.Sprintf("%s:%d", queueName, *input.WaitTimeSeconds))
+			if len(polls) == receives {
+				cancel()
+			}
+
+			if queueName == "low" && !lowDelivered {
+				lowDelivered = true
+				return &sqs.ReceiveMessageOutput{Messages: []*sqs.Message{
+					{Body: aws.String(body), ReceiptHandle: aws.String("receipt-handle-1")},
+				}}, nil
+			}
+			return &sqs.ReceiveMessageOutput{}, nil
+		},
+	}
+
+	consumer, err := NewPriorityConsumer[*events.UserCreatedEvent](concurrentSQSClient{mockClient}, PriorityConsumerOptions{
+		Queues: []PriorityQueue{
+			{Weight: 3, Options: SQSConsumerOptions{QueueURL: "https://sqs.us-east-1.amazonaws.com/123456789/high"}},
+			{Weight: 1, Options: SQSConsumerOptions{QueueURL: "https://sqs.us-east-1.amazonaws.com/123456789/low"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	handler := &mockHandler{}
+	consumer.Start(ctx, &mockDeserializer{}, handler)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for consumer.Consumers()[0].Status().State != StateStopped {
+		if time.Now().After(deadline) {
+			t.Fatal("priority consumer did not stop")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// Every turn is a short poll until a full round comes back empty,
+	// then the highest weighted queue is long polled
+	expected := "high:0,high:0,low:0,high:0,high:0,high:0,low:0,high:1"
+	if actual := strings.Join(polls[:receives], ","); actual != expected {
+		t.Errorf("expected polls %s, got %s", expected, actual)
+	}
+	if handler.callCount != 1 {
+		t.Errorf("expected 1 handled event, got %d", handler.callCount)
+	}
+}
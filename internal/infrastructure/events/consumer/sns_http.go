@@ -0,0 +1,229 @@
+package consumer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/deserializer"
+	"github.com/cgund98/go-postgres-api-template/internal/observability"
+)
+
+const (
+	defaultSNSHTTPTimeout = 10 * time.Second
+
+	// maxSNSMessageSize is the SNS maximum payload of 256 KiB plus room for the envelope
+	maxSNSMessageSize = 512 * 1024
+
+	snsTypeNotification             = "Notification"
+	snsTypeSubscriptionConfirmation = "SubscriptionConfirmation"
+	snsTypeUnsubscribeConfirmation  = "UnsubscribeConfirmation"
+
+	// snsRawDeliveryHeader is set by SNS when raw message delivery is enabled on the subscription
+	snsRawDeliveryHeader = "x-amz-sns-rawdelivery"
+)
+
+// SNSMessage is the JSON document SNS posts to HTTP/S subscriptions
+type SNSMessage struct {
+	Type             string `json:"Type"`
+	MessageID        string `json:"MessageId"`
+	Token            string `json:"Token,omitempty"`
+	TopicARN         string `json:"TopicArn"`
+	Subject          string `json:"Subject,omitempty"`
+	Message          string `json:"Message"`
+	Timestamp        string `json:"Timestamp"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+	SubscribeURL     string `json:"SubscribeURL,omitempty"`
+	UnsubscribeURL   string `json:"UnsubscribeURL,omitempty"`
+}
+
+type SNSHTTPHandlerOptions struct {
+	// Name identifies the subscription in logs and labels its metrics
+	Name string
+
+	// TopicARNs are the topics messages are accepted from. Messages from any other topic are rejected,
+	// since anyone can subscribe the endpoint to a topic they own and SNS would sign those messages too.
+	TopicARNs []string
+
+	// VerifySignatures checks that messages are signed by SNS. Defaults to true.
+	// Only disable it for LocalStack, whose signing certificates are not served by AWS.
+	VerifySignatures *bool
+
+	// ConfirmSubscriptions visits the SubscribeURL of subscription confirmations from allowed topics,
+	// when it is the SNS endpoint of the topic's region. Defaults to true.
+	ConfirmSubscriptions *bool
+
+	// HTTPClient fetches signing certificates and confirms subscriptions. Defaults to a client with a 10 second timeout.
+	HTTPClient *http.Client
+}
+
+// SNSHTTPHandler receives events pushed by an SNS HTTP/S subscription and dispatches them to a handler.
+// It is an alternative to SQS polling for low-latency handlers; raw message delivery must be disabled
+// on the subscription so the signature can be verified.
+//
+// The response status drives the SNS delivery policy:
+//   - 2xx: the message was handled
+//   - 4xx: the message can never be handled (bad signature, unknown topic, undecodable event), so it is not retried
+//   - 5xx: the handler or a dependency failed, and SNS retries the delivery
+type SNSHTTPHandler[T events.Event] struct {
+	name                 string
+	topicARNs            []string
+	verifySignatures     bool
+	confirmSubscriptions bool
+	httpClient           *http.Client
+	verifier             *snsVerifier
+	deserializer         deserializer.Deserializer[T]
+	handler              events.Handler[T]
+	logger               *slog.Logger
+}
+
+// NewSNSHTTPHandler creates a handler for an SNS HTTP/S subscription
+func NewSNSHTTPHandler[T events.Event](deserializer deserializer.Deserializer[T], handler events.Handler[T], options SNSHTTPHandlerOptions) *SNSHTTPHandler[T] {
+	verifySignatures := true
+	confirmSubscriptions := true
+	httpClient := &http.Client{Timeout: defaultSNSHTTPTimeout}
+
+	if options.VerifySignatures != nil {
+		verifySignatures = *options.VerifySignatures
+	}
+
+	if options.ConfirmSubscriptions != nil {
+		confirmSubscriptions = *options.ConfirmSubscriptions
+	}
+
+	if options.HTTPClient != nil {
+		httpClient = options.HTTPClient
+	}
+
+	return &SNSHTTPHandler[T]{
+		name:                 options.Name,
+		topicARNs:            options.TopicARNs,
+		verifySignatures:     verifySignatures,
+		confirmSubscriptions: confirmSubscriptions,
+		httpClient:           httpClient,
+		verifier:             newSNSVerifier(httpClient),
+		deserializer:         deserializer,
+		handler:              handler,
+		logger:               observability.Logger.With("subscription", options.Name),
+	}
+}
+
+// ServeHTTP handles a POST from SNS
+func (h *SNSHTTPHandler[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Header.Get(snsRawDeliveryHeader) == "true" {
+		h.reject(w, http.StatusBadRequest, errors.New("raw message delivery is enabled, so the message can't be verified"))
+		return
+	}
+
+	var message SNSMessage
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxSNSMessageSize)).Decode(&message); err != nil {
+		h.reject(w, http.StatusBadRequest, fmt.Errorf("failed to decode sns message: %w", err))
+		return
+	}
+
+	if len(h.topicARNs) > 0 && !slices.Contains(h.topicARNs, message.TopicARN) {
+		h.reject(w, http.StatusForbidden, fmt.Errorf("message from unexpected topic %s", message.TopicARN))
+		return
+	}
+
+	if h.verifySignatures {
+		if err := h.verifier.Verify(&message); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, ErrInvalidSignature) {
+				status = http.StatusForbidden
+			}
+			h.reject(w, status, err)
+			return
+		}
+	}
+
+	switch message.Type {
+	case snsTypeNotification:
+		h.handleNotification(w, r, &message)
+	case snsTypeSubscriptionConfirmation:
+		h.confirmSubscription(w, &message)
+	case snsTypeUnsubscribeConfirmation:
+		// Resubscribing is left to provisioning, so an intentional unsubscribe sticks
+		h.logger.Warn("sns subscription was unsubscribed", "topicArn", message.TopicARN)
+		w.WriteHeader(http.StatusOK)
+	default:
+		h.reject(w, http.StatusBadRequest, fmt.Errorf("unknown sns message type %q", message.Type))
+	}
+}
+
+// handleNotification decodes the event in the notification and dispatches it to the handler
+func (h *SNSHTTPHandler[T]) handleNotification(w http.ResponseWriter, r *http.Request, message *SNSMessage) {
+	event, err := h.deserializer.Deserialize([]byte(message.Message))
+	if err != nil {
+		metrics.received.WithLabelValues(h.name, unknownEventType).Inc()
+		metrics.failed.WithLabelValues(h.name, unknownEventType).Inc()
+		h.reject(w, http.StatusBadRequest, fmt.Errorf("failed to deserialize event: %w", err))
+		return
+	}
+	eventType := event.Type()
+	metrics.received.WithLabelValues(h.name, eventType).Inc()
+
+	start := time.Now()
+	err = h.handler.Handle(r.Context(), event)
+	metrics.handlerDuration.WithLabelValues(h.name, eventType).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.failed.WithLabelValues(h.name, eventType).Inc()
+		h.reject(w, http.StatusInternalServerError, fmt.Errorf("failed to handle event (event_id=%s): %w", event.EventID(), err))
+		return
+	}
+
+	metrics.handled.WithLabelValues(h.name, eventType).Inc()
+	w.WriteHeader(http.StatusOK)
+}
+
+// confirmSubscription visits the SubscribeURL to confirm the subscription
+func (h *SNSHTTPHandler[T]) confirmSubscription(w http.ResponseWriter, message *SNSMessage) {
+	if !h.confirmSubscriptions {
+		h.logger.Info("ignoring sns subscription confirmation", "topicArn", message.TopicARN)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// The URL is checked even when signatures aren't verified, so an unsigned message can't make the worker fetch arbitrary URLs
+	if err := checkSubscribeURL(message.SubscribeURL, message.TopicARN); err != nil {
+		h.reject(w, http.StatusBadRequest, err)
+		return
+	}
+
+	response, err := h.httpClient.Get(message.SubscribeURL)
+	if err != nil {
+		h.reject(w, http.StatusInternalServerError, fmt.Errorf("failed to confirm subscription: %w", err))
+		return
+	}
+	_ = response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		h.reject(w, http.StatusInternalServerError, fmt.Errorf("failed to confirm subscription: status %d", response.StatusCode))
+		return
+	}
+
+	h.logger.Info("confirmed sns subscription", "topicArn", message.TopicARN)
+	w.WriteHeader(http.StatusOK)
+}
+
+// reject logs the error and responds with the status
+func (h *SNSHTTPHandler[T]) reject(w http.ResponseWriter, status int, err error) {
+	h.logger.Error("failed to process sns message", "status", status, "error", err)
+	http.Error(w, http.StatusText(status), status)
+}
+
+// Make sure the handler implements the http.Handler interface
+var _ http.Handler = &SNSHTTPHandler[events.Event]{}
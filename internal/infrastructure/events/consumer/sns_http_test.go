@@ -0,0 +1,244 @@
+package consumer
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cgund98/go-postgres-api-template/internal/domain/user/events"
+)
+
+const (
+	testSNSTopicARN     = "arn:aws:sns:us-east-1:000000000000:events-topic"
+	testSigningCertURL  = "https://sns.us-east-1.amazonaws.com/SimpleNotificationService-test.pem"
+	testSubscribeURL    = "https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription&Token=token"
+	testForeignTopicARN = "arn:aws:sns:us-east-1:111111111111:other-topic"
+)
+
+// roundTripperFunc serves the HTTP requests made by the handler in tests
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// newTestSigner creates a signing key and a self-signed certificate in PEM format
+func newTestSigner(t *testing.T) (*rsa.PrivateKey, []byte) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	return key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// signSNSMessage signs the message the way SNS does
+func signSNSMessage(t *testing.T, key *rsa.PrivateKey, message *SNSMessage) {
+	t.Helper()
+	hash := crypto.SHA1
+	if message.SignatureVersion == "2" {
+		hash = crypto.SHA256
+	}
+	digest := hash.New()
+	digest.Write([]byte(message.stringToSign()))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, hash, digest.Sum(nil))
+	if err != nil {
+		t.Fatalf("failed to sign message: %v", err)
+	}
+	message.Signature = base64.StdEncoding.EncodeToString(signature)
+}
+
+func TestSNSHTTPHandler_ServeHTTP(t *testing.T) {
+	key, certificate := newTestSigner(t)
+
+	event, err := json.Marshal(events.NewUserCreatedEvent("user-123", "test@example.com"))
+	if err != nil {
+		t.Fatalf("failed to marshal event: %v", err)
+	}
+
+	tests := []struct {
+		name              string
+		message           SNSMessage
+		tamper            func(*SNSMessage)
+		rawDelivery       bool
+		skipVerification  bool
+		handleError       error
+		expectedStatus    int
+		expectedHandled   int
+		expectedConfirmed int
+	}{
+		{
+			name:            "dispatches notifications signed with SHA1",
+			message:         SNSMessage{Type: snsTypeNotification, SignatureVersion: "1"},
+			expectedStatus:  http.StatusOK,
+			expectedHandled: 1,
+		},
+		{
+			name:            "dispatches notifications signed with SHA256",
+			message:         SNSMessage{Type: snsTypeNotification, SignatureVersion: "2", Subject: "user"},
+			expectedStatus:  http.StatusOK,
+			expectedHandled: 1,
+		},
+		{
+			name:           "rejects tampered notifications",
+			message:        SNSMessage{Type: snsTypeNotification, SignatureVersion: "1"},
+			tamper:         func(m *SNSMessage) { m.Message = strings.Replace(m.Message, "user-123", "user-456", 1) },
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "rejects notifications from other topics",
+			message:        SNSMessage{Type: snsTypeNotification, SignatureVersion: "1", TopicARN: testForeignTopicARN},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "rejects signing certificates not served by SNS",
+			message:        SNSMessage{Type: snsTypeNotification, SignatureVersion: "1", SigningCertURL: "https://example.com/cert.pem"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "rejects unsupported signature versions",
+			message:        SNSMessage{Type: snsTypeNotification, SignatureVersion: "3"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "rejects raw message delivery",
+			message:        SNSMessage{Type: snsTypeNotification, SignatureVersion: "1"},
+			rawDelivery:    true,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "rejects events that can't be decoded without retrying",
+			message:        SNSMessage{Type: snsTypeNotification, SignatureVersion: "1", Message: "not json"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:            "returns a server error so SNS retries when the handler fails",
+			message:         SNSMessage{Type: snsTypeNotification, SignatureVersion: "1"},
+			handleError:     errors.New("database unavailable"),
+			expectedStatus:  http.StatusInternalServerError,
+			expectedHandled: 1,
+		},
+		{
+			name:              "confirms subscriptions",
+			message:           SNSMessage{Type: snsTypeSubscriptionConfirmation, SignatureVersion: "1", Token: "token", SubscribeURL: testSubscribeURL},
+			expectedStatus:    http.StatusOK,
+			expectedConfirmed: 1,
+		},
+		{
+			name:           "does not confirm subscriptions outside SNS",
+			message:        SNSMessage{Type: snsTypeSubscriptionConfirmation, SignatureVersion: "1", Token: "token", SubscribeURL: "https://example.com/confirm"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:             "does not confirm subscriptions outside SNS without signature verification",
+			message:          SNSMessage{Type: snsTypeSubscriptionConfirmation, SignatureVersion: "1", Token: "token", SubscribeURL: "http://169.254.169.254/latest/meta-data/"},
+			skipVerification: true,
+			expectedStatus:   http.StatusBadRequest,
+		},
+		{
+			name:              "confirms subscriptions without signature verification",
+			message:           SNSMessage{Type: snsTypeSubscriptionConfirmation, SignatureVersion: "1", Token: "token", SubscribeURL: testSubscribeURL},
+			skipVerification:  true,
+			expectedStatus:    http.StatusOK,
+			expectedConfirmed: 1,
+		},
+		{
+			name:           "does not confirm subscriptions in another region than the topic",
+			message:        SNSMessage{Type: snsTypeSubscriptionConfirmation, SignatureVersion: "1", Token: "token", SubscribeURL: "https://sns.eu-west-1.amazonaws.com/?Action=ConfirmSubscription&Token=token"},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			confirmed := 0
+			httpClient := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				body := ""
+				switch r.URL.String() {
+				case testSigningCertURL:
+					body = string(certificate)
+				case testSubscribeURL:
+					confirmed++
+				default:
+					t.Errorf("unexpected request to %s", r.URL)
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+			})}
+
+			handler := &mockHandler{handleFunc: func(context.Context, *events.UserCreatedEvent) error {
+				return tt.handleError
+			}}
+			verifySignatures := !tt.skipVerification
+			snsHandler := NewSNSHTTPHandler(&mockDeserializer{}, handler, SNSHTTPHandlerOptions{
+				Name:             "user-created-push",
+				TopicARNs:        []string{testSNSTopicARN},
+				VerifySignatures: &verifySignatures,
+				HTTPClient:       httpClient,
+			})
+
+			message := tt.message
+			message.MessageID = "message-1"
+			message.Timestamp = "2026-01-01T00:00:00.000Z"
+			if message.TopicARN == "" {
+				message.TopicARN = testSNSTopicARN
+			}
+			if message.SigningCertURL == "" {
+				message.SigningCertURL = testSigningCertURL
+			}
+			if message.Message == "" {
+				message.Message = string(event)
+			}
+			signSNSMessage(t, key, &message)
+			if tt.tamper != nil {
+				tt.tamper(&message)
+			}
+
+			body, err := json.Marshal(message)
+			if err != nil {
+				t.Fatalf("failed to marshal message: %v", err)
+			}
+			request := httptest.NewRequest(http.MethodPost, "/sns/user-created", bytes.NewReader(body))
+			if tt.rawDelivery {
+				request.Header.Set(snsRawDeliveryHeader, "true")
+			}
+			recorder := httptest.NewRecorder()
+
+			snsHandler.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, recorder.Code)
+			}
+			if handler.callCount != tt.expectedHandled {
+				t.Errorf("expected handler to be called %d times, got %d", tt.expectedHandled, handler.callCount)
+			}
+			if confirmed != tt.expectedConfirmed {
+				t.Errorf("expected %d subscription confirmations, got %d", tt.expectedConfirmed, confirmed)
+			}
+		})
+	}
+}
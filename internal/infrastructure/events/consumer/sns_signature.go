@@ -0,0 +1,174 @@
+package consumer
+
+import (
+	"crypto"
+	"crypto/rsa"
+	_ "crypto/sha1" //nolint:gosec // Registers crypto.SHA1, used by SignatureVersion 1
+	_ "crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// maxCertificateSize limits how much of a signing certificate response is read
+const maxCertificateSize = 64 * 1024
+
+// ErrInvalidSignature is returned when an SNS message is not signed by SNS
+var ErrInvalidSignature = errors.New("invalid sns message signature")
+
+// snsHostPattern matches the hosts SNS signing certificates and subscription URLs are served from
+var snsHostPattern = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// snsVerifier checks SNS message signatures, caching signing certificates by URL
+type snsVerifier struct {
+	httpClient *http.Client
+
+	mu           sync.Mutex
+	certificates map[string]*rsa.PublicKey
+}
+
+func newSNSVerifier(httpClient *http.Client) *snsVerifier {
+	return &snsVerifier{
+		httpClient:   httpClient,
+		certificates: map[string]*rsa.PublicKey{},
+	}
+}
+
+// Verify checks that the message was signed by SNS.
+// Returns ErrInvalidSignature if the signature does not match, or another error if the certificate can't be fetched.
+func (v *snsVerifier) Verify(message *SNSMessage) error {
+	var hash crypto.Hash
+	switch message.SignatureVersion {
+	case "1":
+		hash = crypto.SHA1
+	case "2":
+		hash = crypto.SHA256
+	default:
+		return fmt.Errorf("%w: unsupported signature version %q", ErrInvalidSignature, message.SignatureVersion)
+	}
+
+	if err := checkSNSURL(message.SigningCertURL); err != nil {
+		return fmt.Errorf("%w: signing certificate %v", ErrInvalidSignature, err)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(message.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	key, err := v.certificate(message.SigningCertURL)
+	if err != nil {
+		return err
+	}
+
+	digest := hash.New()
+	digest.Write([]byte(message.stringToSign()))
+	if err := rsa.VerifyPKCS1v15(key, hash, digest.Sum(nil), signature); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return nil
+}
+
+// certificate returns the public key of the signing certificate, downloading it on first use
+func (v *snsVerifier) certificate(certURL string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	key, ok := v.certificates[certURL]
+	v.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	response, err := v.httpClient.Get(certURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch signing certificate: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch signing certificate: status %d", response.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxCertificateSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing certificate: %w", err)
+	}
+
+	block, _ := pem.Decode(body)
+	if block == nil {
+		return nil, fmt.Errorf("%w: signing certificate is not PEM encoded", ErrInvalidSignature)
+	}
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	key, ok = certificate.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: signing certificate does not have an RSA key", ErrInvalidSignature)
+	}
+
+	v.mu.Lock()
+	v.certificates[certURL] = key
+	v.mu.Unlock()
+	return key, nil
+}
+
+// checkSNSURL makes sure a URL in a message points at SNS over HTTPS,
+// so a forged message can't make the worker fetch arbitrary URLs
+func checkSNSURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if parsed.Scheme != "https" || !snsHostPattern.MatchString(parsed.Hostname()) {
+		return fmt.Errorf("url %s is not an https SNS endpoint", rawURL)
+	}
+	return nil
+}
+
+// checkSubscribeURL makes sure a SubscribeURL points at SNS over HTTPS in the region of the topic.
+// It applies whether or not signatures are verified, since confirming fetches the URL from inside the network.
+func checkSubscribeURL(rawURL, topicARN string) error {
+	if err := checkSNSURL(rawURL); err != nil {
+		return err
+	}
+
+	// Topic ARNs are arn:<partition>:sns:<region>:<account>:<name>
+	arn := strings.Split(topicARN, ":")
+	parsed, _ := url.Parse(rawURL)
+	if len(arn) != 6 || !strings.HasPrefix(parsed.Hostname(), "sns."+arn[3]+".") {
+		return fmt.Errorf("url %s is not the SNS endpoint of topic %s", rawURL, topicARN)
+	}
+	return nil
+}
+
+// stringToSign builds the canonical string SNS signs for the message type.
+// Fields are in byte order, each as "Name\nValue\n", and Subject is only included when set.
+func (m *SNSMessage) stringToSign() string {
+	fields := [][2]string{{"Message", m.Message}, {"MessageId", m.MessageID}}
+	if m.Type == snsTypeNotification {
+		if m.Subject != "" {
+			fields = append(fields, [2]string{"Subject", m.Subject})
+		}
+		fields = append(fields, [2]string{"Timestamp", m.Timestamp}, [2]string{"TopicArn", m.TopicARN}, [2]string{"Type", m.Type})
+	} else {
+		fields = append(fields,
+			[2]string{"SubscribeURL", m.SubscribeURL},
+			[2]string{"Timestamp", m.Timestamp},
+			[2]string{"Token", m.Token},
+			[2]string{"TopicArn", m.TopicARN},
+			[2]string{"Type", m.Type},
+		)
+	}
+
+	var builder strings.Builder
+	for _, field := range fields {
+		builder.WriteString(field[0] + "\n" + field[1] + "\n")
+	}
+	return builder.String()
+}
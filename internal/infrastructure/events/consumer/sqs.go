@@ -2,8 +2,12 @@ package consumer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -16,27 +20,87 @@ import (
 )
 
 const (
-	errorBackoff               = 1 * time.Second
-	defaultMaxNumberOfMessages = 10
-	defaultVisibilityTimeout   = 30
-	defaultWaitTimeSeconds     = 20
+	errorBackoff                   = 1 * time.Second
+	defaultMaxNumberOfMessages     = 10
+	defaultVisibilityTimeout       = 30
+	defaultWaitTimeSeconds         = 20
+	defaultQueueAttributesInterval = 15 * time.Second
+
+	// maxDeleteBatchSize is the maximum number of entries SQS accepts in a single DeleteMessageBatch call
+	maxDeleteBatchSize = 10
+
+	// eventTypeAttribute is the message attribute set by the publisher with the event type
+	eventTypeAttribute = "event_type"
+
+	// allMessageAttributes requests every message attribute on receive
+	allMessageAttributes = "All"
+
+	// errorAttribute is the message attribute holding the reason a message was dead-lettered
+	errorAttribute = "error"
+
+	// maxErrorAttributeLength keeps dead-letter reasons readable without approaching the SQS message size limit
+	maxErrorAttributeLength = 1024
 )
 
 type SQSConsumerOptions struct {
-	QueueURL            string
+	QueueURL string
+
+	// Name identifies the consumer in the worker's admin endpoints. Defaults to the queue name.
+	Name string
+
 	MaxNumberOfMessages *int64
 	VisibilityTimeout   *int64
 	WaitTimeSeconds     *int64
+
+	// EventType labels queue-level metrics (depth, oldest message age) for this consumer.
+	// Message-level metrics use the event type of each message instead.
+	EventType string
+
+	// MaxReceiveCount should match the maxReceiveCount of the queue's redrive policy.
+	// When set, messages that fail on their final receive are counted as dead-lettered.
+	MaxReceiveCount *int64
+
+	// QueueAttributesInterval controls how often queue depth is sampled via GetQueueAttributes
+	QueueAttributesInterval *time.Duration
+
+	// Concurrency is the number of goroutines polling and handling messages in parallel. Defaults to 1.
+	Concurrency *int
+
+	// Autoscaling scales the number of polling goroutines between a min and a max with the queue backlog
+	// and handler latency, re-evaluated every QueueAttributesInterval. Concurrency is ignored when set.
+	Autoscaling *AutoscalingOptions
+
+	// DeadLetterQueueURL is where messages that fail with a non-retryable error (see events.NonRetryable)
+	// are sent straight away, with the reason in the error message attribute. When empty, they are retried
+	// like any other failure until the queue's redrive policy moves them.
+	DeadLetterQueueURL string
+
+	// Filter decides which events are handled. Events it rejects are acknowledged without
+	// invoking the handler and counted as filtered. When set, every message attribute is received.
+	Filter events.Filter
 }
 
 // SQSConsumer implements Consumer using AWS SQS
 type SQSConsumer[T events.Event] struct {
-	queueURL            string
-	sqsClient           mockaws.SQSClientInterface
-	maxNumberOfMessages int64
-	visibilityTimeout   int64
-	waitTimeSeconds     int64
-	logger              *slog.Logger
+	queueURL                string
+	queueName               string
+	deadLetterQueueURL      string
+	filter                  events.Filter
+	eventType               string
+	sqsClient               mockaws.SQSClientInterface
+	maxNumberOfMessages     int64
+	visibilityTimeout       int64
+	waitTimeSeconds         int64
+	maxReceiveCount         int64
+	queueAttributesInterval time.Duration
+	concurrency             int
+	autoscaler              *autoscaler
+	logger                  *slog.Logger
+
+	name     string
+	mu       sync.Mutex
+	status   Status
+	resumeCh chan struct{}
 }
 
 // NewSQSConsumer creates a new SQS consumer
@@ -44,6 +108,9 @@ func NewSQSConsumer[T events.Event](sqsClient mockaws.SQSClientInterface, option
 	var maxNumberOfMessages = int64(defaultMaxNumberOfMessages)
 	var visibilityTimeout = int64(defaultVisibilityTimeout)
 	var waitTimeSeconds = int64(defaultWaitTimeSeconds)
+	var maxReceiveCount int64
+	var queueAttributesInterval = defaultQueueAttributesInterval
+	var concurrency = 1
 
 	if options.MaxNumberOfMessages != nil {
 		maxNumberOfMessages = *options.MaxNumberOfMessages
@@ -57,18 +124,56 @@ func NewSQSConsumer[T events.Event](sqsClient mockaws.SQSClientInterface, option
 		waitTimeSeconds = *options.WaitTimeSeconds
 	}
 
+	if options.MaxReceiveCount != nil {
+		maxReceiveCount = *options.MaxReceiveCount
+	}
+
+	if options.QueueAttributesInterval != nil {
+		queueAttributesInterval = *options.QueueAttributesInterval
+	}
+
+	if options.Concurrency != nil && *options.Concurrency > 0 {
+		concurrency = *options.Concurrency
+	}
+
+	var scaler *autoscaler
+	if options.Autoscaling != nil {
+		scaler = newAutoscaler(*options.Autoscaling)
+		concurrency = scaler.minConcurrency
+	}
+
+	queueName := queueNameFromURL(options.QueueURL)
+	name := queueName
+	if options.Name != "" {
+		name = options.Name
+	}
+
 	logger := observability.Logger.With("queueURL", options.QueueURL)
 
 	return &SQSConsumer[T]{
-		queueURL:            options.QueueURL,
-		maxNumberOfMessages: maxNumberOfMessages,
-		visibilityTimeout:   visibilityTimeout,
-		waitTimeSeconds:     waitTimeSeconds,
-		sqsClient:           sqsClient,
-		logger:              logger,
+		name:                    name,
+		queueURL:                options.QueueURL,
+		queueName:               queueName,
+		deadLetterQueueURL:      options.DeadLetterQueueURL,
+		filter:                  options.Filter,
+		eventType:               options.EventType,
+		maxNumberOfMessages:     maxNumberOfMessages,
+		visibilityTimeout:       visibilityTimeout,
+		waitTimeSeconds:         waitTimeSeconds,
+		maxReceiveCount:         maxReceiveCount,
+		queueAttributesInterval: queueAttributesInterval,
+		concurrency:             concurrency,
+		autoscaler:              scaler,
+		sqsClient:               sqsClient,
+		logger:                  logger,
 	}
 }
 
+// queueNameFromURL extracts the queue name from a queue URL for use as a metric label
+func queueNameFromURL(queueURL string) string {
+	return queueURL[strings.LastIndex(queueURL, "/")+1:]
+}
+
 // Ack deletes a message from SQS
 func (c *SQSConsumer[T]) Ack(_ context.Context, messageID string) error {
 
@@ -83,147 +188,687 @@ func (c *SQSConsumer[T]) Ack(_ context.Context, messageID string) error {
 	return nil
 }
 
-// BatchAck deletes a batch of messages from SQS
+// BatchAckError is returned by BatchAck when SQS fails to delete some of the messages
+type BatchAckError struct {
+	// Failed maps the receipt handle of each message that could not be deleted to the reason
+	Failed map[string]string
+}
+
+func (e *BatchAckError) Error() string {
+	return fmt.Sprintf("failed to delete %d sqs messages", len(e.Failed))
+}
+
+// BatchAck deletes a batch of messages from SQS.
+// Messages are deleted in chunks of up to 10, the most SQS accepts per request.
+// If only some messages could be deleted, a *BatchAckError listing the failures is returned.
 func (c *SQSConsumer[T]) BatchAck(_ context.Context, messageIDs []string) error {
-	entries := make([]*sqs.DeleteMessageBatchRequestEntry, len(messageIDs))
-	for i, messageID := range messageIDs {
-		entries[i] = &sqs.DeleteMessageBatchRequestEntry{
-			Id:            aws.String(messageID),
-			ReceiptHandle: aws.String(messageID),
+	failed := map[string]string{}
+
+	for start := 0; start < len(messageIDs); start += maxDeleteBatchSize {
+		chunk := messageIDs[start:min(start+maxDeleteBatchSize, len(messageIDs))]
+
+		// Entry IDs only need to be unique within the request, and receipt handles
+		// are too long to use as IDs, so we use the position in the chunk
+		entries := make([]*sqs.DeleteMessageBatchRequestEntry, len(chunk))
+		for i, messageID := range chunk {
+			entries[i] = &sqs.DeleteMessageBatchRequestEntry{
+				Id:            aws.String(strconv.Itoa(i)),
+				ReceiptHandle: aws.String(messageID),
+			}
 		}
-	}
 
-	_, err := c.sqsClient.DeleteMessageBatch(&sqs.DeleteMessageBatchInput{
-		QueueUrl: aws.String(c.queueURL),
-		Entries:  entries,
-	})
+		output, err := c.sqsClient.DeleteMessageBatch(&sqs.DeleteMessageBatchInput{
+			QueueUrl: aws.String(c.queueURL),
+			Entries:  entries,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete sqs messages: %w", err)
+		}
 
-	if err != nil {
-		return fmt.Errorf("failed to delete sqs messages: %w", err)
+		for _, entry := range output.Failed {
+			i, err := strconv.Atoi(aws.StringValue(entry.Id))
+			if err != nil || i < 0 || i >= len(chunk) {
+				return fmt.Errorf("sqs returned unknown batch entry id %q", aws.StringValue(entry.Id))
+			}
+			failed[chunk[i]] = aws.StringValue(entry.Message)
+		}
+	}
+
+	if len(failed) > 0 {
+		return &BatchAckError{Failed: failed}
 	}
 
 	return nil
 }
 
-// processBatchOfSingleMessages retrieves a batch of sqs messages from SQS
-// and processes them one by one
-func (c *SQSConsumer[T]) processBatchOfSingleMessages(ctx context.Context, deserializer deserializer.Deserializer[T], handler events.Handler[T]) {
-	message, err := c.sqsClient.ReceiveMessage(&sqs.ReceiveMessageInput{
+// receiveMessages retrieves a batch of sqs messages along with the attributes needed for metrics,
+// or every attribute when the consumer has a filter. It long polls for up to waitTimeSeconds.
+func (c *SQSConsumer[T]) receiveMessages(waitTimeSeconds int64) ([]*sqs.Message, error) {
+	messageAttributeNames := []string{eventTypeAttribute}
+	if c.filter != nil {
+		messageAttributeNames = []string{allMessageAttributes}
+	}
+
+	output, err := c.sqsClient.ReceiveMessage(&sqs.ReceiveMessageInput{
 		QueueUrl:            aws.String(c.queueURL),
 		MaxNumberOfMessages: aws.Int64(c.maxNumberOfMessages),
 		VisibilityTimeout:   aws.Int64(c.visibilityTimeout),
-		WaitTimeSeconds:     aws.Int64(c.waitTimeSeconds),
+		WaitTimeSeconds:     aws.Int64(waitTimeSeconds),
+		AttributeNames: aws.StringSlice([]string{
+			sqs.MessageSystemAttributeNameApproximateReceiveCount,
+			sqs.MessageSystemAttributeNameSentTimestamp,
+		}),
+		MessageAttributeNames: aws.StringSlice(messageAttributeNames),
 	})
+	c.recordPoll(err)
+	if err != nil {
+		return nil, err
+	}
+
+	c.recordOldestMessageAge(output.Messages)
+
+	for _, message := range output.Messages {
+		metrics.received.WithLabelValues(c.queueName, messageEventType(message)).Inc()
+	}
+
+	return output.Messages, nil
+}
+
+// processBatchOfSingleMessages retrieves a batch of sqs messages from SQS
+// and processes them one by one
+func (c *SQSConsumer[T]) processBatchOfSingleMessages(ctx context.Context, deserializer deserializer.Deserializer[T], handler events.Handler[T]) {
+	c.pollSingleMessages(ctx, deserializer, handler, c.waitTimeSeconds)
+}
+
+// pollSingleMessages long polls for up to waitTimeSeconds and processes the received messages one by one.
+// Returns the number of messages received.
+func (c *SQSConsumer[T]) pollSingleMessages(ctx context.Context, deserializer deserializer.Deserializer[T], handler events.Handler[T], waitTimeSeconds int64) int {
+	messages, err := c.receiveMessages(waitTimeSeconds)
 	if err != nil {
 		c.logger.Error("failed to receive sqs messages", "error", err)
 		time.Sleep(errorBackoff)
-		return
+		return 0
 	}
 
-	if len(message.Messages) == 0 {
-		return
+	if len(messages) == 0 {
+		return 0
 	}
 
-	for _, message := range message.Messages {
+	for _, message := range messages {
+		eventType := messageEventType(message)
+
 		event, err := deserializer.Deserialize([]byte(*message.Body))
 		if err != nil {
 			c.logger.Error("failed to deserialize event", "error", err)
-			return
+			if c.fail(message, eventType, err) {
+				continue
+			}
+			return len(messages)
 		}
+		eventType = event.Type()
 
+		if c.filtered(message, event) {
+			if err := c.Ack(ctx, *message.ReceiptHandle); err != nil {
+				c.logger.Error("failed to ack filtered sqs message", "error", err)
+				return len(messages)
+			}
+			metrics.filtered.WithLabelValues(c.queueName, eventType).Inc()
+			continue
+		}
+
+		start := time.Now()
 		err = handler.Handle(ctx, event)
+		c.recordHandlerDuration(eventType, 1, time.Since(start))
 		if err != nil {
 			c.logger.Error("failed to handle event", "error", err)
-			return
+			if c.fail(message, eventType, err) {
+				continue
+			}
+			return len(messages)
 		}
 
 		err = c.Ack(ctx, *message.ReceiptHandle)
 		if err != nil {
 			c.logger.Error("failed to ack sqs message", "error", err)
-			return
+			metrics.failed.WithLabelValues(c.queueName, eventType).Inc()
+			return len(messages)
 		}
+		metrics.handled.WithLabelValues(c.queueName, eventType).Inc()
 	}
 
+	return len(messages)
 }
 
 // Start starts consuming messages from SQS. This will begin in a new goroutine and return immediately.
 func (c *SQSConsumer[T]) Start(ctx context.Context, deserializer deserializer.Deserializer[T], handler events.Handler[T]) {
+	c.startQueueAttributesSampler(ctx)
+
+	c.logger.Info("starting sqs consumer", "concurrency", c.concurrency)
+	c.startPollers(ctx, func() {
+		c.processBatchOfSingleMessages(ctx, deserializer, handler)
+	})
+}
+
+// processBatchOfMessages retrieves a batch of sqs messages from SQS and hands them to the batch handler.
+// Events the handler reports as successful are deleted together; failed events are left on the queue to be retried.
+func (c *SQSConsumer[T]) processBatchOfMessages(ctx context.Context, deserializer deserializer.Deserializer[T], handler events.BatchHandler[T]) {
+	received, err := c.receiveMessages(c.waitTimeSeconds)
+	if err != nil {
+		c.logger.Error("failed to receive sqs messages", "error", err)
+		time.Sleep(errorBackoff)
+		return
+	}
+
+	if len(received) == 0 {
+		return
+	}
+
+	// Deserialize the messages into events
+	// Messages that fail to deserialize are left on the queue rather than failing the whole batch
+	messages := make([]*sqs.Message, 0, len(received))
+	batch := make([]T, 0, len(received))
+	filtered := map[string]string{}
+	for _, message := range received {
+		event, err := deserializer.Deserialize([]byte(*message.Body))
+		if err != nil {
+			c.logger.Error("failed to deserialize event", "error", err)
+			c.fail(message, messageEventType(message), err)
+			continue
+		}
+		if c.filtered(message, event) {
+			filtered[*message.ReceiptHandle] = event.Type()
+			continue
+		}
+		messages = append(messages, message)
+		batch = append(batch, event)
+	}
+	c.ackFiltered(ctx, filtered)
+
+	if len(batch) == 0 {
+		return
+	}
+
+	// Handle the events
+	start := time.Now()
+	result := handler.HandleBatch(ctx, batch)
+	elapsed := time.Since(start)
+	for _, event := range batch {
+		metrics.handlerDuration.WithLabelValues(c.queueName, event.Type()).Observe(elapsed.Seconds())
+	}
+	if c.autoscaler != nil {
+		c.autoscaler.recordHandled(len(batch), elapsed)
+	}
+
+	if len(result) != len(batch) {
+		c.logger.Error("batch handler returned wrong number of results, retrying batch", "events", len(batch), "results", len(result))
+		result = events.BatchFailure(len(batch), errors.New("batch handler returned wrong number of results"))
+	}
+
+	// Collect the successfully handled messages
+	eventTypes := map[string]string{}
+	receiptHandles := make([]string, 0, len(batch))
+	for i, message := range messages {
+		eventType := batch[i].Type()
+		if result[i] != nil {
+			c.logger.Error("failed to handle event", "error", result[i], "event_id", batch[i].EventID())
+			c.fail(message, eventType, result[i])
+			continue
+		}
+		eventTypes[*message.ReceiptHandle] = eventType
+		receiptHandles = append(receiptHandles, *message.ReceiptHandle)
+	}
+
+	if len(receiptHandles) == 0 {
+		return
+	}
+
+	// Ack the successful messages
+	// If some deletes fail, only those messages are counted as failed; they will be redelivered
+	var ackErr *BatchAckError
+	err = c.BatchAck(ctx, receiptHandles)
+	if err != nil && !errors.As(err, &ackErr) {
+		c.logger.Error("failed to ack sqs messages", "error", err)
+		for _, receiptHandle := range receiptHandles {
+			metrics.failed.WithLabelValues(c.queueName, eventTypes[receiptHandle]).Inc()
+		}
+		return
+	}
+
+	for _, receiptHandle := range receiptHandles {
+		if ackErr != nil {
+			if reason, ok := ackErr.Failed[receiptHandle]; ok {
+				c.logger.Error("failed to ack sqs message", "error", reason)
+				metrics.failed.WithLabelValues(c.queueName, eventTypes[receiptHandle]).Inc()
+				continue
+			}
+		}
+		metrics.handled.WithLabelValues(c.queueName, eventTypes[receiptHandle]).Inc()
+	}
+}
+
+// StartBatch starts consuming messages from SQS in batch mode. This will begin in a new goroutine and return immediately.
+func (c *SQSConsumer[T]) StartBatch(ctx context.Context, deserializer deserializer.Deserializer[T], handler events.BatchHandler[T]) {
+	c.startQueueAttributesSampler(ctx)
+
+	c.logger.Info("starting sqs consumer in batch mode", "queue_url", c.queueURL, "concurrency", c.concurrency)
+	c.startPollers(ctx, func() {
+		c.processBatchOfMessages(ctx, deserializer, handler)
+	})
+}
+
+// startPollers runs poll in a loop on each of the consumer's goroutines until the context is canceled.
+// With autoscaling, goroutines are added and removed as the backlog changes.
+// The consumer is marked as stopped once every goroutine has returned.
+func (c *SQSConsumer[T]) startPollers(ctx context.Context, poll func()) {
+	c.setRunning()
+
+	var wg sync.WaitGroup
+	var pollers []context.CancelFunc
+
+	// Pollers are stopped through their own context once they finish their current poll,
+	// while messages are still handled with the consumer's context
+	addPoller := func() {
+		pollerCtx, cancel := context.WithCancel(ctx)
+		pollers = append(pollers, cancel)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c.waitWhilePaused(pollerCtx) {
+				poll()
+			}
+		}()
+	}
+	resize := func(size int) {
+		for len(pollers) < size {
+			addPoller()
+		}
+		for len(pollers) > size {
+			pollers[len(pollers)-1]()
+			pollers = pollers[:len(pollers)-1]
+		}
+		c.setConcurrency(size)
+	}
+
+	resize(max(c.concurrency, 1))
+
+	scalerDone := make(chan struct{})
 	go func() {
-		c.logger.Info("starting sqs consumer")
+		defer close(scalerDone)
+		if c.autoscaler == nil {
+			return
+		}
+
+		ticker := time.NewTicker(c.queueAttributesInterval)
+		defer ticker.Stop()
 		for {
 			select {
 			case <-ctx.Done():
-				c.logger.Info("sqs consumer context canceled, stopping")
 				return
-			default:
-				c.processBatchOfSingleMessages(ctx, deserializer, handler)
+			case <-ticker.C:
+			}
+
+			desired, backlog, latency := c.autoscaler.decide(len(pollers))
+			if desired != len(pollers) {
+				c.logger.Info("scaling sqs consumer",
+					"from", len(pollers), "to", desired, "backlog", backlog, "handler_latency", latency)
+				resize(desired)
 			}
 		}
 	}()
+
+	go func() {
+		<-scalerDone
+		wg.Wait()
+		c.setStopped()
+		c.logger.Info("sqs consumer context canceled, stopping")
+	}()
 }
 
-func (c *SQSConsumer[T]) processBatchOfMessages(ctx context.Context, deserializer deserializer.Deserializer[T], handler events.BatchHandler[T]) {
-	message, err := c.sqsClient.ReceiveMessage(&sqs.ReceiveMessageInput{
-		QueueUrl:            aws.String(c.queueURL),
-		MaxNumberOfMessages: aws.Int64(c.maxNumberOfMessages),
-		VisibilityTimeout:   aws.Int64(c.visibilityTimeout),
-		WaitTimeSeconds:     aws.Int64(c.waitTimeSeconds),
-	})
+/** -------------------------------- Control -------------------------------- */
+
+// Name returns the name of the consumer
+func (c *SQSConsumer[T]) Name() string {
+	return c.name
+}
+
+// Status returns a snapshot of the consumer's state
+func (c *SQSConsumer[T]) Status() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := c.status
+	status.Name = c.name
+	status.QueueURL = c.queueURL
+	status.EventType = c.eventType
+	if status.State == "" {
+		status.State = StateIdle
+	}
+	if status.State == StateRunning && c.resumeCh != nil {
+		status.State = StatePaused
+	}
+	return status
+}
+
+// Pause stops the consumer from polling until Resume is called.
+// A receive that is already in progress completes and its messages are handled.
+func (c *SQSConsumer[T]) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.resumeCh == nil {
+		c.resumeCh = make(chan struct{})
+		c.logger.Info("pausing sqs consumer")
+	}
+}
+
+// Resume restarts polling after Pause
+func (c *SQSConsumer[T]) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.resumeCh != nil {
+		close(c.resumeCh)
+		c.resumeCh = nil
+		c.logger.Info("resuming sqs consumer")
+	}
+}
+
+// waitWhilePaused blocks while the consumer is paused.
+// Returns false once the context is canceled and the consumer should stop.
+func (c *SQSConsumer[T]) waitWhilePaused(ctx context.Context) bool {
+	c.mu.Lock()
+	resumeCh := c.resumeCh
+	c.mu.Unlock()
+
+	if resumeCh == nil {
+		return ctx.Err() == nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-resumeCh:
+		return ctx.Err() == nil
+	}
+}
+
+// paused reports whether the consumer is paused, without blocking
+func (c *SQSConsumer[T]) paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.resumeCh != nil
+}
+
+// setConcurrency records the number of polling goroutines
+func (c *SQSConsumer[T]) setConcurrency(concurrency int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.status.Concurrency = concurrency
+	metrics.pollers.WithLabelValues(c.queueName).Set(float64(concurrency))
+}
 
+// setRunning marks the consumer as started
+func (c *SQSConsumer[T]) setRunning() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.status.State = StateRunning
+	c.status.StartedAt = time.Now()
+}
+
+// setStopped marks the consumer as stopped
+func (c *SQSConsumer[T]) setStopped() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.status.State = StateStopped
+}
+
+// recordPoll records the outcome of a receive for readiness checks
+func (c *SQSConsumer[T]) recordPoll(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
 	if err != nil {
-		c.logger.Error("failed to receive sqs messages", "error", err)
-		time.Sleep(errorBackoff)
+		c.status.LastError = err.Error()
+		c.status.LastErrorAt = now
+		c.status.ConsecutiveErrors++
 		return
 	}
+	c.status.LastPollAt = now
+	c.status.ConsecutiveErrors = 0
+}
+
+/** -------------------------------- Filtering -------------------------------- */
+
+// filtered reports whether the consumer's filter rejects the event, meaning it should be acked without being handled
+func (c *SQSConsumer[T]) filtered(message *sqs.Message, event T) bool {
+	return c.filter != nil && !c.filter(messageAttributes(message), event)
+}
 
-	if len(message.Messages) == 0 {
+// ackFiltered deletes the filtered messages, keyed by receipt handle with their event type.
+// Messages that can't be deleted are redelivered and filtered again.
+func (c *SQSConsumer[T]) ackFiltered(ctx context.Context, filtered map[string]string) {
+	if len(filtered) == 0 {
 		return
 	}
 
-	// Deserialize the messages into events
-	events := make([]T, 0, len(message.Messages))
-	for _, message := range message.Messages {
-		event, err := deserializer.Deserialize([]byte(*message.Body))
+	receiptHandles := make([]string, 0, len(filtered))
+	for receiptHandle := range filtered {
+		receiptHandles = append(receiptHandles, receiptHandle)
+	}
+
+	var ackErr *BatchAckError
+	err := c.BatchAck(ctx, receiptHandles)
+	if err != nil && !errors.As(err, &ackErr) {
+		c.logger.Error("failed to ack filtered sqs messages", "error", err)
+		return
+	}
+
+	for receiptHandle, eventType := range filtered {
+		if ackErr != nil {
+			if reason, ok := ackErr.Failed[receiptHandle]; ok {
+				c.logger.Error("failed to ack filtered sqs message", "error", reason)
+				continue
+			}
+		}
+		metrics.filtered.WithLabelValues(c.queueName, eventType).Inc()
+	}
+}
+
+// messageAttributes returns the string and number message attributes of the message
+func messageAttributes(message *sqs.Message) map[string]string {
+	attributes := make(map[string]string, len(message.MessageAttributes))
+	for name, value := range message.MessageAttributes {
+		if value != nil && value.StringValue != nil {
+			attributes[name] = *value.StringValue
+		}
+	}
+	return attributes
+}
+
+/** -------------------------------- Dead-lettering -------------------------------- */
+
+// fail records a message that could not be processed. Non-retryable failures are moved to the
+// dead-letter queue when one is configured; everything else is left on the queue to be retried.
+// Returns true if the message was dead-lettered.
+func (c *SQSConsumer[T]) fail(message *sqs.Message, eventType string, err error) bool {
+	if c.deadLetterQueueURL == "" || !events.IsNonRetryable(err) {
+		c.recordFailure(message, eventType)
+		return false
+	}
+
+	if dlqErr := c.deadLetter(message, err); dlqErr != nil {
+		c.logger.Error("failed to dead-letter sqs message", "error", dlqErr)
+		c.recordFailure(message, eventType)
+		return false
+	}
+
+	metrics.failed.WithLabelValues(c.queueName, eventType).Inc()
+	metrics.deadLettered.WithLabelValues(c.queueName, eventType).Inc()
+	return true
+}
+
+// deadLetter copies the message to the dead-letter queue with the failure reason, then deletes it from the queue.
+// If the delete fails the message is redelivered and may be dead-lettered twice, which is safe for a DLQ.
+func (c *SQSConsumer[T]) deadLetter(message *sqs.Message, reason error) error {
+	attributes := map[string]*sqs.MessageAttributeValue{}
+	for name, value := range message.MessageAttributes {
+		attributes[name] = value
+	}
+	errorMessage := reason.Error()
+	if len(errorMessage) > maxErrorAttributeLength {
+		errorMessage = errorMessage[:maxErrorAttributeLength]
+	}
+	attributes[errorAttribute] = &sqs.MessageAttributeValue{
+		DataType:    aws.String("String"),
+		StringValue: aws.String(errorMessage),
+	}
+
+	input := &sqs.SendMessageInput{
+		QueueUrl:          aws.String(c.deadLetterQueueURL),
+		MessageBody:       message.Body,
+		MessageAttributes: attributes,
+	}
+	if strings.HasSuffix(c.deadLetterQueueURL, ".fifo") {
+		input.MessageGroupId = message.MessageId
+		input.MessageDeduplicationId = message.MessageId
+	}
+	if _, err := c.sqsClient.SendMessage(input); err != nil {
+		return err
+	}
+
+	_, err := c.sqsClient.DeleteMessage(&sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(c.queueURL),
+		ReceiptHandle: message.ReceiptHandle,
+	})
+	return err
+}
+
+/** -------------------------------- Metrics -------------------------------- */
+
+// recordHandlerDuration records the time spent handling messages, for metrics and autoscaling
+func (c *SQSConsumer[T]) recordHandlerDuration(eventType string, messages int, elapsed time.Duration) {
+	metrics.handlerDuration.WithLabelValues(c.queueName, eventType).Observe(elapsed.Seconds())
+	if c.autoscaler != nil {
+		c.autoscaler.recordHandled(messages, elapsed)
+	}
+}
+
+// recordFailure counts a failed message, and counts it as dead-lettered
+// if this was its last receive before the redrive policy moves it to the DLQ
+func (c *SQSConsumer[T]) recordFailure(message *sqs.Message, eventType string) {
+	metrics.failed.WithLabelValues(c.queueName, eventType).Inc()
+
+	if c.maxReceiveCount > 0 && messageReceiveCount(message) >= c.maxReceiveCount {
+		metrics.deadLettered.WithLabelValues(c.queueName, eventType).Inc()
+	}
+}
+
+// recordOldestMessageAge sets the oldest message age gauge from the SentTimestamp of the received messages.
+// SQS only exposes the age of the oldest message through CloudWatch, so we approximate it from what we receive.
+func (c *SQSConsumer[T]) recordOldestMessageAge(messages []*sqs.Message) {
+	var oldest time.Duration
+	now := time.Now()
+
+	for _, message := range messages {
+		sentTimestamp, ok := message.Attributes[sqs.MessageSystemAttributeNameSentTimestamp]
+		if !ok || sentTimestamp == nil {
+			continue
+		}
+		millis, err := strconv.ParseInt(*sentTimestamp, 10, 64)
 		if err != nil {
-			c.logger.Error("failed to deserialize event", "error", err)
-			return
+			continue
+		}
+		if age := now.Sub(time.UnixMilli(millis)); age > oldest {
+			oldest = age
 		}
-		events = append(events, event)
 	}
 
-	// Handle the events
-	err = handler.HandleBatch(ctx, events)
+	metrics.oldestMessage.WithLabelValues(c.queueName, c.eventType).Set(oldest.Seconds())
+}
+
+// sampleQueueAttributes records the approximate queue depth reported by GetQueueAttributes
+func (c *SQSConsumer[T]) sampleQueueAttributes() error {
+	output, err := c.sqsClient.GetQueueAttributes(&sqs.GetQueueAttributesInput{
+		QueueUrl: aws.String(c.queueURL),
+		AttributeNames: aws.StringSlice([]string{
+			sqs.QueueAttributeNameApproximateNumberOfMessages,
+			sqs.QueueAttributeNameApproximateNumberOfMessagesNotVisible,
+			sqs.QueueAttributeNameApproximateNumberOfMessagesDelayed,
+		}),
+	})
 	if err != nil {
-		c.logger.Error("failed to handle events", "error", err)
-		return
+		return fmt.Errorf("failed to get sqs queue attributes: %w", err)
 	}
 
-	// Ack the messages
-	for _, message := range message.Messages {
-		err = c.Ack(ctx, *message.ReceiptHandle)
+	states := map[string]string{
+		sqs.QueueAttributeNameApproximateNumberOfMessages:           "visible",
+		sqs.QueueAttributeNameApproximateNumberOfMessagesNotVisible: "in_flight",
+		sqs.QueueAttributeNameApproximateNumberOfMessagesDelayed:    "delayed",
+	}
+	for attribute, state := range states {
+		value, ok := output.Attributes[attribute]
+		if !ok || value == nil {
+			continue
+		}
+		count, err := strconv.ParseFloat(*value, 64)
 		if err != nil {
-			c.logger.Error("failed to ack sqs message", "error", err)
-			return
+			return fmt.Errorf("failed to parse sqs queue attribute %s: %w", attribute, err)
+		}
+		metrics.queueMessages.WithLabelValues(c.queueName, c.eventType, state).Set(count)
+		if state == "visible" && c.autoscaler != nil {
+			c.autoscaler.recordBacklog(count)
 		}
 	}
 
+	return nil
 }
 
-// StartBatch starts consuming messages from SQS in batch mode. This will begin in a new goroutine and return immediately.
-func (c *SQSConsumer[T]) StartBatch(ctx context.Context, deserializer deserializer.Deserializer[T], handler events.BatchHandler[T]) {
+// startQueueAttributesSampler periodically samples queue attributes until the context is canceled
+func (c *SQSConsumer[T]) startQueueAttributesSampler(ctx context.Context) {
 	go func() {
-		c.logger.Info("starting sqs consumer in batch mode", "queue_url", c.queueURL)
+		ticker := time.NewTicker(c.queueAttributesInterval)
+		defer ticker.Stop()
+
 		for {
+			if err := c.sampleQueueAttributes(); err != nil {
+				c.logger.Error("failed to sample sqs queue attributes", "error", err)
+			}
+
 			select {
 			case <-ctx.Done():
-				c.logger.Info("sqs consumer context canceled, stopping")
 				return
-			default:
-				c.processBatchOfMessages(ctx, deserializer, handler)
+			case <-ticker.C:
 			}
 		}
 	}()
 }
 
-// Make sure the consumer implements the Consumer interface
+// messageEventType returns the event type message attribute set by the publisher, if any
+func messageEventType(message *sqs.Message) string {
+	if attribute, ok := message.MessageAttributes[eventTypeAttribute]; ok && attribute.StringValue != nil {
+		return *attribute.StringValue
+	}
+	return unknownEventType
+}
+
+// messageReceiveCount returns how many times the message has been received, or 0 if unknown
+func messageReceiveCount(message *sqs.Message) int64 {
+	value, ok := message.Attributes[sqs.MessageSystemAttributeNameApproximateReceiveCount]
+	if !ok || value == nil {
+		return 0
+	}
+	count, err := strconv.ParseInt(*value, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+// Make sure the consumer implements the Consumer and Controller interfaces
 var _ Consumer[events.Event] = &SQSConsumer[events.Event]{}
+var _ Controller = &SQSConsumer[events.Event]{}
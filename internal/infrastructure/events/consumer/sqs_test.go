@@ -4,13 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 
 	"github.com/cgund98/go-postgres-api-template/internal/domain/user/events"
+	baseEvents "github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
 )
 
 // mockSQSClient is a mock implementation of SQS client
@@ -18,9 +24,14 @@ type mockSQSClient struct {
 	deleteMessageFunc           func(*sqs.DeleteMessageInput) (*sqs.DeleteMessageOutput, error)
 	deleteMessageBatchFunc      func(*sqs.DeleteMessageBatchInput) (*sqs.DeleteMessageBatchOutput, error)
 	receiveMessageFunc          func(*sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error)
+	getQueueAttributesFunc      func(*sqs.GetQueueAttributesInput) (*sqs.GetQueueAttributesOutput, error)
+	sendMessageFunc             func(*sqs.SendMessageInput) (*sqs.SendMessageOutput, error)
 	deleteMessageCallCount      int
 	deleteMessageBatchCallCount int
 	receiveMessageCallCount     int
+	getQueueAttributesCallCount int
+	sendMessageCallCount        int
+	lastSendMessageInput        *sqs.SendMessageInput
 }
 
 func (m *mockSQSClient) DeleteMessage(input *sqs.DeleteMessageInput) (*sqs.DeleteMessageOutput, error) {
@@ -47,6 +58,23 @@ func (m *mockSQSClient) ReceiveMessage(input *sqs.ReceiveMessageInput) (*sqs.Rec
 	return &sqs.ReceiveMessageOutput{}, nil
 }
 
+func (m *mockSQSClient) GetQueueAttributes(input *sqs.GetQueueAttributesInput) (*sqs.GetQueueAttributesOutput, error) {
+	m.getQueueAttributesCallCount++
+	if m.getQueueAttributesFunc != nil {
+		return m.getQueueAttributesFunc(input)
+	}
+	return &sqs.GetQueueAttributesOutput{}, nil
+}
+
+func (m *mockSQSClient) SendMessage(input *sqs.SendMessageInput) (*sqs.SendMessageOutput, error) {
+	m.sendMessageCallCount++
+	m.lastSendMessageInput = input
+	if m.sendMessageFunc != nil {
+		return m.sendMessageFunc(input)
+	}
+	return &sqs.SendMessageOutput{}, nil
+}
+
 // mockHandler is a mock implementation of Handler
 type mockHandler struct {
 	handleFunc func(context.Context, *events.UserCreatedEvent) error
@@ -65,18 +93,18 @@ func (m *mockHandler) Handle(ctx context.Context, event *events.UserCreatedEvent
 
 // mockBatchHandler is a mock implementation of BatchHandler
 type mockBatchHandler struct {
-	handleBatchFunc func(context.Context, []*events.UserCreatedEvent) error
+	handleBatchFunc func(context.Context, []*events.UserCreatedEvent) baseEvents.BatchResult
 	callCount       int
 	lastEvents      []*events.UserCreatedEvent
 }
 
-func (m *mockBatchHandler) HandleBatch(ctx context.Context, eventList []*events.UserCreatedEvent) error {
+func (m *mockBatchHandler) HandleBatch(ctx context.Context, eventList []*events.UserCreatedEvent) baseEvents.BatchResult {
 	m.callCount++
 	m.lastEvents = eventList
 	if m.handleBatchFunc != nil {
 		return m.handleBatchFunc(ctx, eventList)
 	}
-	return nil
+	return baseEvents.NewBatchResult(len(eventList))
 }
 
 // mockDeserializer is a mock implementation of Deserializer
@@ -158,10 +186,12 @@ func TestSQSConsumer_Ack(t *testing.T) {
 
 func TestSQSConsumer_BatchAck(t *testing.T) {
 	tests := []struct {
-		name          string
-		messageIDs    []string
-		mockFunc      func(*sqs.DeleteMessageBatchInput) (*sqs.DeleteMessageBatchOutput, error)
-		expectedError bool
+		name               string
+		messageIDs         []string
+		mockFunc           func(*sqs.DeleteMessageBatchInput) (*sqs.DeleteMessageBatchOutput, error)
+		expectedError      bool
+		expectedFailed     []string
+		expectedBatchCalls int
 	}{
 		{
 			name:       "successfully acks batch of messages",
@@ -173,18 +203,61 @@ func TestSQSConsumer_BatchAck(t *testing.T) {
 				if len(input.Entries) != 3 {
 					t.Errorf("unexpected number of entries: %d", len(input.Entries))
 				}
+				ids := map[string]bool{}
 				for i, entry := range input.Entries {
-					expectedID := input.Entries[i].Id
-					if entry.Id == nil || *entry.Id != *expectedID {
-						t.Errorf("entry %d ID mismatch", i)
+					if entry.Id == nil || len(*entry.Id) == 0 || len(*entry.Id) > 80 {
+						t.Errorf("entry %d has invalid ID", i)
+					} else if ids[*entry.Id] {
+						t.Errorf("entry %d has duplicate ID %s", i, *entry.Id)
+					} else {
+						ids[*entry.Id] = true
 					}
-					if entry.ReceiptHandle == nil || *entry.ReceiptHandle != *expectedID {
+					expectedHandle := fmt.Sprintf("handle-%d", i+1)
+					if entry.ReceiptHandle == nil || *entry.ReceiptHandle != expectedHandle {
 						t.Errorf("entry %d receipt handle mismatch", i)
 					}
 				}
 				return &sqs.DeleteMessageBatchOutput{}, nil
 			},
-			expectedError: false,
+			expectedError:      false,
+			expectedBatchCalls: 1,
+		},
+		{
+			name:       "uses short entry IDs for long receipt handles",
+			messageIDs: []string{strings.Repeat("a", 200)},
+			mockFunc: func(input *sqs.DeleteMessageBatchInput) (*sqs.DeleteMessageBatchOutput, error) {
+				if len(*input.Entries[0].Id) > 80 {
+					t.Errorf("entry ID exceeds 80 characters: %d", len(*input.Entries[0].Id))
+				}
+				return &sqs.DeleteMessageBatchOutput{}, nil
+			},
+			expectedBatchCalls: 1,
+		},
+		{
+			name:       "splits batches larger than 10 messages",
+			messageIDs: []string{"h1", "h2", "h3", "h4", "h5", "h6", "h7", "h8", "h9", "h10", "h11", "h12"},
+			mockFunc: func(input *sqs.DeleteMessageBatchInput) (*sqs.DeleteMessageBatchOutput, error) {
+				if len(input.Entries) > 10 {
+					t.Errorf("batch exceeds 10 entries: %d", len(input.Entries))
+				}
+				return &sqs.DeleteMessageBatchOutput{}, nil
+			},
+			expectedBatchCalls: 2,
+		},
+		{
+			name:       "reports partial failures",
+			messageIDs: []string{"handle-1", "handle-2", "handle-3"},
+			mockFunc: func(input *sqs.DeleteMessageBatchInput) (*sqs.DeleteMessageBatchOutput, error) {
+				return &sqs.DeleteMessageBatchOutput{
+					Successful: []*sqs.DeleteMessageBatchResultEntry{{Id: input.Entries[0].Id}, {Id: input.Entries[2].Id}},
+					Failed: []*sqs.BatchResultErrorEntry{
+						{Id: input.Entries[1].Id, Code: aws.String("ReceiptHandleIsInvalid"), Message: aws.String("invalid receipt handle")},
+					},
+				}, nil
+			},
+			expectedError:      true,
+			expectedFailed:     []string{"handle-2"},
+			expectedBatchCalls: 1,
 		},
 		{
 			name:       "returns error when SQS batch delete fails",
@@ -192,7 +265,8 @@ func TestSQSConsumer_BatchAck(t *testing.T) {
 			mockFunc: func(_ *sqs.DeleteMessageBatchInput) (*sqs.DeleteMessageBatchOutput, error) {
 				return nil, errors.New("SQS batch delete failed")
 			},
-			expectedError: true,
+			expectedError:      true,
+			expectedBatchCalls: 1,
 		},
 		{
 			name:       "handles empty batch",
@@ -203,7 +277,8 @@ func TestSQSConsumer_BatchAck(t *testing.T) {
 				}
 				return &sqs.DeleteMessageBatchOutput{}, nil
 			},
-			expectedError: false,
+			expectedError:      false,
+			expectedBatchCalls: 0,
 		},
 	}
 
@@ -224,14 +299,28 @@ func TestSQSConsumer_BatchAck(t *testing.T) {
 				if err == nil {
 					t.Error("expected error but got nil")
 				}
-			} else {
-				if err != nil {
-					t.Errorf("unexpected error: %v", err)
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if tt.expectedFailed != nil {
+				var ackErr *BatchAckError
+				if !errors.As(err, &ackErr) {
+					t.Fatalf("expected BatchAckError, got %v", err)
+				}
+				if len(ackErr.Failed) != len(tt.expectedFailed) {
+					t.Errorf("expected %d failed messages, got %d", len(tt.expectedFailed), len(ackErr.Failed))
 				}
-				if len(tt.messageIDs) > 0 && mockClient.deleteMessageBatchCallCount != 1 {
-					t.Errorf("expected DeleteMessageBatch to be called once, got %d", mockClient.deleteMessageBatchCallCount)
+				for _, receiptHandle := range tt.expectedFailed {
+					if _, ok := ackErr.Failed[receiptHandle]; !ok {
+						t.Errorf("expected %s to be reported as failed", receiptHandle)
+					}
 				}
 			}
+
+			if mockClient.deleteMessageBatchCallCount != tt.expectedBatchCalls {
+				t.Errorf("expected DeleteMessageBatch to be called %d times, got %d", tt.expectedBatchCalls, mockClient.deleteMessageBatchCallCount)
+			}
 		})
 	}
 }
@@ -368,14 +457,17 @@ func TestSQSConsumer_processBatchOfSingleMessages(t *testing.T) {
 
 func TestSQSConsumer_processBatchOfMessages(t *testing.T) {
 	tests := []struct {
-		name                 string
-		sqsMessages          []*sqs.Message
-		sqsError             error
-		deserializeError     error
-		handlerError         error
-		ackError             error
-		expectedHandlerCalls int
-		expectedAckCalls     int
+		name                   string
+		sqsMessages            []*sqs.Message
+		sqsError               error
+		deserializeError       error
+		handlerResult          func(n int) baseEvents.BatchResult
+		ackError               error
+		expectedHandlerCalls   int
+		expectedHandlerEvents  int
+		expectedBatchAckCalls  int
+		expectedAckedHandles   []string
+		expectedSingleAckCalls int
 	}{
 		{
 			name: "successfully processes batch of messages",
@@ -389,20 +481,20 @@ func TestSQSConsumer_processBatchOfMessages(t *testing.T) {
 					ReceiptHandle: aws.String("receipt-handle-2"),
 				},
 			},
-			expectedHandlerCalls: 1,
-			expectedAckCalls:     2,
+			expectedHandlerCalls:  1,
+			expectedHandlerEvents: 2,
+			expectedBatchAckCalls: 1,
+			expectedAckedHandles:  []string{"receipt-handle-1", "receipt-handle-2"},
 		},
 		{
 			name:                 "handles SQS receive error",
 			sqsError:             errors.New("SQS receive failed"),
 			expectedHandlerCalls: 0,
-			expectedAckCalls:     0,
 		},
 		{
 			name:                 "handles empty message batch",
 			sqsMessages:          []*sqs.Message{},
 			expectedHandlerCalls: 0,
-			expectedAckCalls:     0,
 		},
 		{
 			name: "handles deserialization error",
@@ -414,7 +506,6 @@ func TestSQSConsumer_processBatchOfMessages(t *testing.T) {
 			},
 			deserializeError:     errors.New("deserialization failed"),
 			expectedHandlerCalls: 0,
-			expectedAckCalls:     0,
 		},
 		{
 			name: "handles handler error",
@@ -424,9 +515,53 @@ func TestSQSConsumer_processBatchOfMessages(t *testing.T) {
 					ReceiptHandle: aws.String("receipt-handle-1"),
 				},
 			},
-			handlerError:         errors.New("handler failed"),
-			expectedHandlerCalls: 1,
-			expectedAckCalls:     0, // Should not ack if handler fails
+			handlerResult: func(n int) baseEvents.BatchResult {
+				return baseEvents.BatchFailure(n, errors.New("handler failed"))
+			},
+			expectedHandlerCalls:  1,
+			expectedHandlerEvents: 1,
+			expectedBatchAckCalls: 0, // Should not ack if handler fails
+		},
+		{
+			name: "acks only successful events",
+			sqsMessages: []*sqs.Message{
+				{
+					Body:          aws.String(`{"event_id":"test-id-1","event_type":"user.created","timestamp":"2023-01-01T00:00:00Z","user_id":"user-123","email":"test1@example.com"}`),
+					ReceiptHandle: aws.String("receipt-handle-1"),
+				},
+				{
+					Body:          aws.String(`{"event_id":"test-id-2","event_type":"user.created","timestamp":"2023-01-01T00:00:00Z","user_id":"user-456","email":"test2@example.com"}`),
+					ReceiptHandle: aws.String("receipt-handle-2"),
+				},
+				{
+					Body:          aws.String(`{"event_id":"test-id-3","event_type":"user.created","timestamp":"2023-01-01T00:00:00Z","user_id":"user-789","email":"test3@example.com"}`),
+					ReceiptHandle: aws.String("receipt-handle-3"),
+				},
+			},
+			handlerResult: func(n int) baseEvents.BatchResult {
+				result := baseEvents.NewBatchResult(n)
+				result[1] = errors.New("handler failed")
+				return result
+			},
+			expectedHandlerCalls:  1,
+			expectedHandlerEvents: 3,
+			expectedBatchAckCalls: 1,
+			expectedAckedHandles:  []string{"receipt-handle-1", "receipt-handle-3"},
+		},
+		{
+			name: "retries whole batch when handler returns wrong number of results",
+			sqsMessages: []*sqs.Message{
+				{
+					Body:          aws.String(`{"event_id":"test-id","event_type":"user.created","timestamp":"2023-01-01T00:00:00Z","user_id":"user-123","email":"test@example.com"}`),
+					ReceiptHandle: aws.String("receipt-handle-1"),
+				},
+			},
+			handlerResult: func(_ int) baseEvents.BatchResult {
+				return nil
+			},
+			expectedHandlerCalls:  1,
+			expectedHandlerEvents: 1,
+			expectedBatchAckCalls: 0,
 		},
 		{
 			name: "handles ack error",
@@ -436,14 +571,17 @@ func TestSQSConsumer_processBatchOfMessages(t *testing.T) {
 					ReceiptHandle: aws.String("receipt-handle-1"),
 				},
 			},
-			ackError:             errors.New("ack failed"),
-			expectedHandlerCalls: 1,
-			expectedAckCalls:     1,
+			ackError:              errors.New("ack failed"),
+			expectedHandlerCalls:  1,
+			expectedHandlerEvents: 1,
+			expectedBatchAckCalls: 1,
+			expectedAckedHandles:  []string{"receipt-handle-1"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			var ackedHandles []string
 			mockClient := &mockSQSClient{
 				receiveMessageFunc: func(_ *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
 					if tt.sqsError != nil {
@@ -453,17 +591,23 @@ func TestSQSConsumer_processBatchOfMessages(t *testing.T) {
 						Messages: tt.sqsMessages,
 					}, nil
 				},
-				deleteMessageFunc: func(_ *sqs.DeleteMessageInput) (*sqs.DeleteMessageOutput, error) {
+				deleteMessageBatchFunc: func(input *sqs.DeleteMessageBatchInput) (*sqs.DeleteMessageBatchOutput, error) {
+					for _, entry := range input.Entries {
+						ackedHandles = append(ackedHandles, *entry.ReceiptHandle)
+					}
 					if tt.ackError != nil {
 						return nil, tt.ackError
 					}
-					return &sqs.DeleteMessageOutput{}, nil
+					return &sqs.DeleteMessageBatchOutput{}, nil
 				},
 			}
 
 			mockBatchHandler := &mockBatchHandler{
-				handleBatchFunc: func(_ context.Context, _ []*events.UserCreatedEvent) error {
-					return tt.handlerError
+				handleBatchFunc: func(_ context.Context, eventList []*events.UserCreatedEvent) baseEvents.BatchResult {
+					if tt.handlerResult != nil {
+						return tt.handlerResult(len(eventList))
+					}
+					return baseEvents.NewBatchResult(len(eventList))
 				},
 			}
 
@@ -493,8 +637,419 @@ func TestSQSConsumer_processBatchOfMessages(t *testing.T) {
 				t.Errorf("expected batch handler to be called %d times, got %d", tt.expectedHandlerCalls, mockBatchHandler.callCount)
 			}
 
-			if mockClient.deleteMessageCallCount != tt.expectedAckCalls {
-				t.Errorf("expected Ack to be called %d times, got %d", tt.expectedAckCalls, mockClient.deleteMessageCallCount)
+			if len(mockBatchHandler.lastEvents) != tt.expectedHandlerEvents {
+				t.Errorf("expected batch handler to receive %d events, got %d", tt.expectedHandlerEvents, len(mockBatchHandler.lastEvents))
+			}
+
+			if mockClient.deleteMessageBatchCallCount != tt.expectedBatchAckCalls {
+				t.Errorf("expected BatchAck to be called %d times, got %d", tt.expectedBatchAckCalls, mockClient.deleteMessageBatchCallCount)
+			}
+
+			if mockClient.deleteMessageCallCount != 0 {
+				t.Errorf("expected no single message acks in batch mode, got %d", mockClient.deleteMessageCallCount)
+			}
+
+			if strings.Join(ackedHandles, ",") != strings.Join(tt.expectedAckedHandles, ",") {
+				t.Errorf("expected acked receipt handles %v, got %v", tt.expectedAckedHandles, ackedHandles)
+			}
+		})
+	}
+}
+
+func TestSQSConsumer_metrics(t *testing.T) {
+	tests := []struct {
+		name                 string
+		receiveCount         string
+		maxReceiveCount      int64
+		handlerError         error
+		expectedHandled      float64
+		expectedFailed       float64
+		expectedDeadLettered float64
+	}{
+		{
+			name:            "counts handled message",
+			receiveCount:    "1",
+			maxReceiveCount: 3,
+			expectedHandled: 1,
+		},
+		{
+			name:            "counts failed message that will be retried",
+			receiveCount:    "1",
+			maxReceiveCount: 3,
+			handlerError:    errors.New("handler failed"),
+			expectedFailed:  1,
+		},
+		{
+			name:                 "counts failed message on final receive as dead-lettered",
+			receiveCount:         "3",
+			maxReceiveCount:      3,
+			handlerError:         errors.New("handler failed"),
+			expectedFailed:       1,
+			expectedDeadLettered: 1,
+		},
+		{
+			name:           "does not count dead-lettered without max receive count",
+			receiveCount:   "10",
+			handlerError:   errors.New("handler failed"),
+			expectedFailed: 1,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Use a unique queue per test case so metric series don't collide,
+			// and reset the series so the test can run more than once per process
+			queueName := fmt.Sprintf("metrics-test-queue-%d", i)
+			labels := []string{queueName, "user.created"}
+			metrics.received.DeleteLabelValues(labels...)
+			metrics.handled.DeleteLabelValues(labels...)
+			metrics.failed.DeleteLabelValues(labels...)
+			metrics.deadLettered.DeleteLabelValues(labels...)
+
+			mockClient := &mockSQSClient{
+				receiveMessageFunc: func(_ *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
+					return &sqs.ReceiveMessageOutput{
+						Messages: []*sqs.Message{
+							{
+								Body:          aws.String(`{"event_id":"test-id","event_type":"user.created","timestamp":"2023-01-01T00:00:00Z","user_id":"user-123","email":"test@example.com"}`),
+								ReceiptHandle: aws.String("receipt-handle-1"),
+								Attributes: map[string]*string{
+									sqs.MessageSystemAttributeNameApproximateReceiveCount: aws.String(tt.receiveCount),
+									sqs.MessageSystemAttributeNameSentTimestamp:           aws.String(strconv.FormatInt(time.Now().Add(-time.Minute).UnixMilli(), 10)),
+								},
+								MessageAttributes: map[string]*sqs.MessageAttributeValue{
+									"event_type": {DataType: aws.String("String"), StringValue: aws.String("user.created")},
+								},
+							},
+						},
+					}, nil
+				},
+			}
+
+			handler := &mockHandler{
+				handleFunc: func(_ context.Context, _ *events.UserCreatedEvent) error {
+					return tt.handlerError
+				},
+			}
+
+			consumer := &SQSConsumer[*events.UserCreatedEvent]{
+				queueURL:            "https://sqs.us-east-1.amazonaws.com/123456789/" + queueName,
+				queueName:           queueName,
+				eventType:           "user.created",
+				sqsClient:           mockClient,
+				maxNumberOfMessages: 1,
+				maxReceiveCount:     tt.maxReceiveCount,
+				logger:              slog.Default(),
+			}
+
+			consumer.processBatchOfSingleMessages(context.Background(), &mockDeserializer{}, handler)
+
+			if got := testutil.ToFloat64(metrics.received.WithLabelValues(labels...)); got != 1 {
+				t.Errorf("expected 1 received message, got %v", got)
+			}
+			if got := testutil.ToFloat64(metrics.handled.WithLabelValues(labels...)); got != tt.expectedHandled {
+				t.Errorf("expected %v handled messages, got %v", tt.expectedHandled, got)
+			}
+			if got := testutil.ToFloat64(metrics.failed.WithLabelValues(labels...)); got != tt.expectedFailed {
+				t.Errorf("expected %v failed messages, got %v", tt.expectedFailed, got)
+			}
+			if got := testutil.ToFloat64(metrics.deadLettered.WithLabelValues(labels...)); got != tt.expectedDeadLettered {
+				t.Errorf("expected %v dead-lettered messages, got %v", tt.expectedDeadLettered, got)
+			}
+			if got := testutil.ToFloat64(metrics.oldestMessage.WithLabelValues(labels...)); got < 60 {
+				t.Errorf("expected oldest message age of at least 60s, got %v", got)
+			}
+		})
+	}
+}
+
+func TestSQSConsumer_sampleQueueAttributes(t *testing.T) {
+	mockClient := &mockSQSClient{
+		getQueueAttributesFunc: func(input *sqs.GetQueueAttributesInput) (*sqs.GetQueueAttributesOutput, error) {
+			if *input.QueueUrl != "https://sqs.us-east-1.amazonaws.com/123456789/attributes-test-queue" {
+				t.Errorf("unexpected queue URL: %s", *input.QueueUrl)
+			}
+			return &sqs.GetQueueAttributesOutput{
+				Attributes: map[string]*string{
+					sqs.QueueAttributeNameApproximateNumberOfMessages:           aws.String("42"),
+					sqs.QueueAttributeNameApproximateNumberOfMessagesNotVisible: aws.String("3"),
+					sqs.QueueAttributeNameApproximateNumberOfMessagesDelayed:    aws.String("0"),
+				},
+			}, nil
+		},
+	}
+
+	consumer := &SQSConsumer[*events.UserCreatedEvent]{
+		queueURL:  "https://sqs.us-east-1.amazonaws.com/123456789/attributes-test-queue",
+		queueName: "attributes-test-queue",
+		eventType: "user.created",
+		sqsClient: mockClient,
+	}
+
+	if err := consumer.sampleQueueAttributes(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]float64{"visible": 42, "in_flight": 3, "delayed": 0}
+	for state, want := range expected {
+		got := testutil.ToFloat64(metrics.queueMessages.WithLabelValues("attributes-test-queue", "user.created", state))
+		if got != want {
+			t.Errorf("expected %s messages to be %v, got %v", state, want, got)
+		}
+	}
+
+	mockClient.getQueueAttributesFunc = func(_ *sqs.GetQueueAttributesInput) (*sqs.GetQueueAttributesOutput, error) {
+		return nil, errors.New("SQS get attributes failed")
+	}
+	if err := consumer.sampleQueueAttributes(); err == nil {
+		t.Error("expected error but got nil")
+	}
+}
+
+func TestSQSConsumer_PauseResume(t *testing.T) {
+	// Each receive reports whether the consumer was paused when it polled, then waits to be released,
+	// so the test decides when every poll completes
+	received := make(chan bool)
+	release := make(chan struct{})
+
+	var consumer *SQSConsumer[*events.UserCreatedEvent]
+	mockClient := &mockSQSClient{
+		receiveMessageFunc: func(_ *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
+			received <- consumer.Status().State == StatePaused
+			<-release
+			return &sqs.ReceiveMessageOutput{}, nil
+		},
+		getQueueAttributesFunc: func(_ *sqs.GetQueueAttributesInput) (*sqs.GetQueueAttributesOutput, error) {
+			return &sqs.GetQueueAttributesOutput{}, nil
+		},
+	}
+
+	consumer = NewSQSConsumer[*events.UserCreatedEvent](mockClient, SQSConsumerOptions{
+		QueueURL: "https://sqs.us-east-1.amazonaws.com/123456789/test-queue",
+	})
+	if consumer.Name() != "test-queue" {
+		t.Errorf("expected name to default to the queue name, got %s", consumer.Name())
+	}
+	if state := consumer.Status().State; state != StateIdle {
+		t.Errorf("expected state %s before start, got %s", StateIdle, state)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	consumer.Start(ctx, &mockDeserializer{}, &mockHandler{})
+
+	if paused := <-received; paused {
+		t.Error("expected the first poll before pausing")
+	}
+	if state := consumer.Status().State; state != StateRunning {
+		t.Errorf("expected state %s after start, got %s", StateRunning, state)
+	}
+
+	// Pause while a receive is in progress, which completes and is the last poll until Resume
+	consumer.Pause()
+	if state := consumer.Status().State; state != StatePaused {
+		t.Errorf("expected state %s after pause, got %s", StatePaused, state)
+	}
+	release <- struct{}{}
+
+	// The next poll only happens after Resume, and a poll made while paused would report it
+	consumer.Resume()
+	if paused := <-received; paused {
+		t.Error("expected no polls while paused")
+	}
+	if state := consumer.Status().State; state != StateRunning {
+		t.Errorf("expected state %s after resume, got %s", StateRunning, state)
+	}
+
+	cancel()
+	release <- struct{}{}
+	waitFor(t, func() bool { return consumer.Status().State == StateStopped })
+}
+
+// waitFor polls the condition until it is true or the test times out
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 1s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSQSConsumer_deadLetter(t *testing.T) {
+	validBody := `{"event_id":"test-id","event_type":"user.created","timestamp":"2023-01-01T00:00:00Z","user_id":"user-123","email":"test@example.com"}`
+
+	tests := []struct {
+		name                 string
+		deadLetterQueueURL   string
+		deserializeError     error
+		sendError            error
+		expectedSendCalls    int
+		expectedDeleteCalls  int
+		expectedHandlerCalls int
+	}{
+		{
+			name:                 "dead-letters non-retryable failures and moves on to the next message",
+			deadLetterQueueURL:   "https://sqs.us-east-1.amazonaws.com/123456789/test-queue-dlq",
+			deserializeError:     baseEvents.NonRetryable(errors.New("payload failed schema validation")),
+			expectedSendCalls:    1,
+			expectedDeleteCalls:  2,
+			expectedHandlerCalls: 1,
+		},
+		{
+			name:               "leaves retryable failures on the queue",
+			deadLetterQueueURL: "https://sqs.us-east-1.amazonaws.com/123456789/test-queue-dlq",
+			deserializeError:   errors.New("temporary failure"),
+		},
+		{
+			name:             "leaves non-retryable failures on the queue without a dead-letter queue",
+			deserializeError: baseEvents.NonRetryable(errors.New("payload failed schema validation")),
+		},
+		{
+			name:               "leaves the message on the queue when dead-lettering fails",
+			deadLetterQueueURL: "https://sqs.us-east-1.amazonaws.com/123456789/test-queue-dlq",
+			deserializeError:   baseEvents.NonRetryable(errors.New("payload failed schema validation")),
+			sendError:          errors.New("SQS send failed"),
+			expectedSendCalls:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &mockSQSClient{
+				receiveMessageFunc: func(_ *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
+					return &sqs.ReceiveMessageOutput{Messages: []*sqs.Message{
+						{MessageId: aws.String("message-1"), Body: aws.String("invalid"), ReceiptHandle: aws.String("receipt-handle-1")},
+						{MessageId: aws.String("message-2"), Body: aws.String(validBody), ReceiptHandle: aws.String("receipt-handle-2")},
+					}}, nil
+				},
+				sendMessageFunc: func(_ *sqs.SendMessageInput) (*sqs.SendMessageOutput, error) {
+					return &sqs.SendMessageOutput{}, tt.sendError
+				},
+			}
+
+			mockHandler := &mockHandler{}
+			mockDeserializer := &mockDeserializer{
+				deserializeFunc: func(data []byte) (*events.UserCreatedEvent, error) {
+					if string(data) == "invalid" {
+						return nil, tt.deserializeError
+					}
+					var event events.UserCreatedEvent
+					err := json.Unmarshal(data, &event)
+					return &event, err
+				},
+			}
+
+			consumer := NewSQSConsumer[*events.UserCreatedEvent](mockClient, SQSConsumerOptions{
+				QueueURL:           "https://sqs.us-east-1.amazonaws.com/123456789/test-queue",
+				DeadLetterQueueURL: tt.deadLetterQueueURL,
+			})
+
+			consumer.processBatchOfSingleMessages(context.Background(), mockDeserializer, mockHandler)
+
+			if mockClient.sendMessageCallCount != tt.expectedSendCalls {
+				t.Errorf("expected %d sends to the dead-letter queue, got %d", tt.expectedSendCalls, mockClient.sendMessageCallCount)
+			}
+			if mockClient.deleteMessageCallCount != tt.expectedDeleteCalls {
+				t.Errorf("expected %d deletes, got %d", tt.expectedDeleteCalls, mockClient.deleteMessageCallCount)
+			}
+			if mockHandler.callCount != tt.expectedHandlerCalls {
+				t.Errorf("expected handler to be called %d times, got %d", tt.expectedHandlerCalls, mockHandler.callCount)
+			}
+			if tt.expectedSendCalls > 0 {
+				input := mockClient.lastSendMessageInput
+				if *input.QueueUrl != tt.deadLetterQueueURL || *input.MessageBody != "invalid" {
+					t.Errorf("unexpected dead-letter message: %+v", input)
+				}
+				if reason := input.MessageAttributes[errorAttribute]; reason == nil || !strings.Contains(*reason.StringValue, "schema validation") {
+					t.Errorf("expected the failure reason in the error attribute, got %+v", input.MessageAttributes)
+				}
+			}
+		})
+	}
+}
+
+func TestSQSConsumer_filter(t *testing.T) {
+	body := `{"event_id":"test-id","event_type":"user.created","timestamp":"2023-01-01T00:00:00Z","user_id":"user-123","email":"test@example.com"}`
+	message := func(receiptHandle, tenant string) *sqs.Message {
+		return &sqs.Message{
+			Body:          aws.String(body),
+			ReceiptHandle: aws.String(receiptHandle),
+			MessageAttributes: map[string]*sqs.MessageAttributeValue{
+				"event_type": {DataType: aws.String("String"), StringValue: aws.String("user.created")},
+				"tenant":     {DataType: aws.String("String"), StringValue: aws.String(tenant)},
+			},
+		}
+	}
+
+	tests := []struct {
+		name                 string
+		batch                bool
+		expectedHandled      int
+		expectedDeleteCalls  int
+		expectedBatchDeletes int
+	}{
+		{
+			name:                "acks filtered messages without handling them",
+			expectedHandled:     1,
+			expectedDeleteCalls: 2,
+		},
+		{
+			name:                 "acks filtered messages without handling them in batch mode",
+			batch:                true,
+			expectedHandled:      1,
+			expectedBatchDeletes: 2,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queueName := fmt.Sprintf("filter-test-queue-%d", i)
+			labels := []string{queueName, "user.created"}
+			metrics.filtered.DeleteLabelValues(labels...)
+
+			var receiveInput *sqs.ReceiveMessageInput
+			mockClient := &mockSQSClient{
+				receiveMessageFunc: func(input *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
+					receiveInput = input
+					return &sqs.ReceiveMessageOutput{Messages: []*sqs.Message{
+						message("receipt-handle-1", "acme"),
+						message("receipt-handle-2", "globex"),
+					}}, nil
+				},
+			}
+
+			consumer := NewSQSConsumer[*events.UserCreatedEvent](mockClient, SQSConsumerOptions{
+				QueueURL: "https://sqs.us-east-1.amazonaws.com/123456789/" + queueName,
+				Filter:   baseEvents.MatchAttribute("tenant", "acme"),
+			})
+
+			handled := 0
+			if tt.batch {
+				handler := &mockBatchHandler{}
+				consumer.processBatchOfMessages(context.Background(), &mockDeserializer{}, handler)
+				handled = len(handler.lastEvents)
+			} else {
+				handler := &mockHandler{}
+				consumer.processBatchOfSingleMessages(context.Background(), &mockDeserializer{}, handler)
+				handled = handler.callCount
+			}
+
+			if handled != tt.expectedHandled {
+				t.Errorf("expected %d handled events, got %d", tt.expectedHandled, handled)
+			}
+			if mockClient.deleteMessageCallCount != tt.expectedDeleteCalls {
+				t.Errorf("expected %d deletes, got %d", tt.expectedDeleteCalls, mockClient.deleteMessageCallCount)
+			}
+			if mockClient.deleteMessageBatchCallCount != tt.expectedBatchDeletes {
+				t.Errorf("expected %d batch deletes, got %d", tt.expectedBatchDeletes, mockClient.deleteMessageBatchCallCount)
+			}
+			if got := testutil.ToFloat64(metrics.filtered.WithLabelValues(labels...)); got != 1 {
+				t.Errorf("expected 1 filtered message, got %v", got)
+			}
+			if names := aws.StringValueSlice(receiveInput.MessageAttributeNames); len(names) != 1 || names[0] != allMessageAttributes {
+				t.Errorf("expected every message attribute to be received, got %v", names)
 			}
 		})
 	}
@@ -0,0 +1,138 @@
+package consumer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrConsumerNotFound is returned when looking up a consumer name that is not in the group
+	ErrConsumerNotFound = errors.New("consumer not found")
+
+	// ErrDuplicateConsumer is returned when adding a consumer whose name is already in the group
+	ErrDuplicateConsumer = errors.New("consumer already exists")
+)
+
+// State is the lifecycle state of a consumer
+type State string
+
+const (
+	// StateIdle means the consumer has not been started
+	StateIdle State = "idle"
+
+	// StateRunning means the consumer is polling for messages
+	StateRunning State = "running"
+
+	// StatePaused means the consumer has been paused and is not polling
+	StatePaused State = "paused"
+
+	// StateStopped means the consumer's context was canceled
+	StateStopped State = "stopped"
+)
+
+// Status is a point-in-time snapshot of a consumer
+type Status struct {
+	Name      string    `json:"name"`
+	QueueURL  string    `json:"queue_url"`
+	EventType string    `json:"event_type,omitempty"`
+	State     State     `json:"state"`
+	StartedAt time.Time `json:"started_at,omitzero"`
+
+	// Concurrency is the number of goroutines polling the queue
+	Concurrency int `json:"concurrency"`
+
+	// LastPollAt is when the consumer last received from the queue without error, including empty receives
+	LastPollAt time.Time `json:"last_poll_at,omitzero"`
+
+	LastError         string    `json:"last_error,omitempty"`
+	LastErrorAt       time.Time `json:"last_error_at,omitzero"`
+	ConsecutiveErrors int       `json:"consecutive_errors"`
+}
+
+// Controller is implemented by consumers that can be inspected and controlled at runtime
+type Controller interface {
+	Name() string
+	Status() Status
+	Pause()
+	Resume()
+}
+
+// Group holds the consumers of a worker by name
+type Group struct {
+	mu          sync.RWMutex
+	controllers map[string]Controller
+}
+
+// NewGroup creates a new, empty consumer group
+func NewGroup() *Group {
+	return &Group{
+		controllers: map[string]Controller{},
+	}
+}
+
+// Add adds consumers to the group.
+// Returns an error if a consumer with the same name is already in the group.
+func (g *Group) Add(controllers ...Controller) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, controller := range controllers {
+		if _, ok := g.controllers[controller.Name()]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicateConsumer, controller.Name())
+		}
+		g.controllers[controller.Name()] = controller
+	}
+	return nil
+}
+
+// Get returns the consumer with the given name
+func (g *Group) Get(name string) (Controller, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	controller, ok := g.controllers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrConsumerNotFound, name)
+	}
+	return controller, nil
+}
+
+// Statuses returns the status of every consumer in the group, sorted by name
+func (g *Group) Statuses() []Status {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(g.controllers))
+	for _, controller := range g.controllers {
+		statuses = append(statuses, controller.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// Unready returns the statuses of running or stopped consumers that have not polled
+// successfully within maxPollAge. Paused and idle consumers are never unready.
+func (g *Group) Unready(now time.Time, maxPollAge time.Duration) []Status {
+	var unready []Status
+	for _, status := range g.Statuses() {
+		switch status.State {
+		case StateStopped:
+			unready = append(unready, status)
+		case StateRunning:
+			// Consumers that have not polled yet are given maxPollAge from when they started
+			lastPoll := status.LastPollAt
+			if lastPoll.IsZero() {
+				lastPoll = status.StartedAt
+			}
+			if now.Sub(lastPoll) > maxPollAge {
+				unready = append(unready, status)
+			}
+		}
+	}
+	return unready
+}
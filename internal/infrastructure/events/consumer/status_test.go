@@ -0,0 +1,87 @@
+package consumer
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// mockController is a mock implementation of Controller
+type mockController struct {
+	status Status
+}
+
+func (m *mockController) Name() string   { return m.status.Name }
+func (m *mockController) Status() Status { return m.status }
+func (m *mockController) Pause()         { m.status.State = StatePaused }
+func (m *mockController) Resume()        { m.status.State = StateRunning }
+
+func TestGroup_Unready(t *testing.T) {
+	now := time.Now()
+	maxPollAge := time.Minute
+
+	tests := []struct {
+		name            string
+		status          Status
+		expectedUnready bool
+	}{
+		{
+			name:   "running consumer that polled recently is ready",
+			status: Status{State: StateRunning, StartedAt: now.Add(-time.Hour), LastPollAt: now.Add(-time.Second)},
+		},
+		{
+			name:            "running consumer that has not polled recently is unready",
+			status:          Status{State: StateRunning, StartedAt: now.Add(-time.Hour), LastPollAt: now.Add(-2 * time.Minute)},
+			expectedUnready: true,
+		},
+		{
+			name:   "recently started consumer without a poll is ready",
+			status: Status{State: StateRunning, StartedAt: now.Add(-time.Second)},
+		},
+		{
+			name:            "consumer that never polled after starting is unready",
+			status:          Status{State: StateRunning, StartedAt: now.Add(-2 * time.Minute)},
+			expectedUnready: true,
+		},
+		{
+			name:   "paused consumer is ready",
+			status: Status{State: StatePaused, StartedAt: now.Add(-time.Hour), LastPollAt: now.Add(-time.Hour)},
+		},
+		{
+			name:            "stopped consumer is unready",
+			status:          Status{State: StateStopped, LastPollAt: now},
+			expectedUnready: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.status.Name = "test-consumer"
+			group := NewGroup()
+			if err := group.Add(&mockController{status: tt.status}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			unready := group.Unready(now, maxPollAge)
+			if tt.expectedUnready && len(unready) != 1 {
+				t.Errorf("expected consumer to be unready")
+			}
+			if !tt.expectedUnready && len(unready) != 0 {
+				t.Errorf("expected consumer to be ready, got %+v", unready)
+			}
+		})
+	}
+}
+
+func TestGroup_Add(t *testing.T) {
+	group := NewGroup()
+	if err := group.Add(&mockController{status: Status{Name: "a"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := group.Add(&mockController{status: Status{Name: "a"}}); !errors.Is(err, ErrDuplicateConsumer) {
+		t.Errorf("expected ErrDuplicateConsumer, got %v", err)
+	}
+	if _, err := group.Get("missing"); !errors.Is(err, ErrConsumerNotFound) {
+		t.Errorf("expected ErrConsumerNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package deserializer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+)
+
+// ErrUnexpectedEventType is returned when a message contains an event type the deserializer was not set up for
+var ErrUnexpectedEventType = errors.New("unexpected event type")
+
+// RegistryDeserializer decodes any event registered in an events.Registry.
+// It reads the event_type field of the payload to find the Go type to decode into.
+type RegistryDeserializer[T events.Event] struct {
+	registry   *events.Registry
+	eventTypes []string
+}
+
+// NewRegistryDeserializer creates a deserializer for the given event types.
+// If no event types are given, every registered event type is accepted.
+// Returns an error if an event type is not registered or does not decode into T,
+// so misconfiguration is caught at startup rather than when the first message arrives.
+func NewRegistryDeserializer[T events.Event](registry *events.Registry, eventTypes ...string) (*RegistryDeserializer[T], error) {
+	target := reflect.TypeOf((*T)(nil)).Elem()
+
+	for _, eventType := range eventTypes {
+		registration, err := registry.Lookup(eventType)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := registration.New().(T); !ok {
+			return nil, fmt.Errorf("event type %s decodes into %T, which is not a %s", eventType, registration.New(), target)
+		}
+	}
+
+	return &RegistryDeserializer[T]{
+		registry:   registry,
+		eventTypes: eventTypes,
+	}, nil
+}
+
+// Deserialize decodes the payload into the Go type registered for its event type
+func (d *RegistryDeserializer[T]) Deserialize(data []byte) (T, error) {
+	var zero T
+
+	var metadata events.EventMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return zero, err
+	}
+
+	if len(d.eventTypes) > 0 && !slices.Contains(d.eventTypes, metadata.EventType) {
+		return zero, fmt.Errorf("%w: %s", ErrUnexpectedEventType, metadata.EventType)
+	}
+
+	registration, err := d.registry.Lookup(metadata.EventType)
+	if err != nil {
+		return zero, err
+	}
+
+	evt, ok := registration.New().(T)
+	if !ok {
+		return zero, fmt.Errorf("%w: %s", ErrUnexpectedEventType, metadata.EventType)
+	}
+
+	if err := json.Unmarshal(data, evt); err != nil {
+		return zero, err
+	}
+	return evt, nil
+}
+
+// Make sure the deserializer implements the Deserializer interface
+var _ Deserializer[events.Event] = &RegistryDeserializer[events.Event]{}
@@ -0,0 +1,85 @@
+package deserializer
+
+import (
+	"errors"
+	"testing"
+
+	userEvents "github.com/cgund98/go-postgres-api-template/internal/domain/user/events"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+)
+
+func newUserEventRegistry(t *testing.T) *events.Registry {
+	t.Helper()
+
+	registry := events.NewRegistry()
+	if err := userEvents.RegisterEvents(registry); err != nil {
+		t.Fatalf("failed to register user events: %v", err)
+	}
+	return registry
+}
+
+func TestNewRegistryDeserializer(t *testing.T) {
+	registry := newUserEventRegistry(t)
+
+	if _, err := NewRegistryDeserializer[*userEvents.UserCreatedEvent](registry, userEvents.EventTypeUserCreated); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := NewRegistryDeserializer[events.Event](registry, userEvents.EventTypeUserCreated, userEvents.EventTypeUserDeleted); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := NewRegistryDeserializer[*userEvents.UserCreatedEvent](registry, "user.unknown"); !errors.Is(err, events.ErrEventTypeNotRegistered) {
+		t.Errorf("expected ErrEventTypeNotRegistered, got %v", err)
+	}
+
+	if _, err := NewRegistryDeserializer[*userEvents.UserCreatedEvent](registry, userEvents.EventTypeUserDeleted); err == nil {
+		t.Error("expected error for event type that decodes into a different Go type")
+	}
+}
+
+func TestRegistryDeserializer_Deserialize(t *testing.T) {
+	registry := newUserEventRegistry(t)
+
+	anyDeserializer, err := NewRegistryDeserializer[events.Event](registry)
+	if err != nil {
+		t.Fatalf("failed to create deserializer: %v", err)
+	}
+
+	event, err := anyDeserializer.Deserialize([]byte(`{"event_id":"test-id","event_type":"user.deleted","timestamp":"2023-01-01T00:00:00Z","user_id":"user-123"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deleted, ok := event.(*userEvents.UserDeletedEvent)
+	if !ok {
+		t.Fatalf("expected *UserDeletedEvent, got %T", event)
+	}
+	if deleted.UserID != "user-123" || deleted.EventID() != "test-id" {
+		t.Errorf("unexpected event: %+v", deleted)
+	}
+
+	if _, err := anyDeserializer.Deserialize([]byte(`{"event_type":"user.unknown"}`)); !errors.Is(err, events.ErrEventTypeNotRegistered) {
+		t.Errorf("expected ErrEventTypeNotRegistered, got %v", err)
+	}
+
+	if _, err := anyDeserializer.Deserialize([]byte("invalid json")); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+
+	createdDeserializer, err := NewRegistryDeserializer[*userEvents.UserCreatedEvent](registry, userEvents.EventTypeUserCreated)
+	if err != nil {
+		t.Fatalf("failed to create deserializer: %v", err)
+	}
+
+	created, err := createdDeserializer.Deserialize([]byte(`{"event_id":"test-id","event_type":"user.created","timestamp":"2023-01-01T00:00:00Z","user_id":"user-123","email":"test@example.com"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created.Email != "test@example.com" {
+		t.Errorf("unexpected email: %s", created.Email)
+	}
+
+	if _, err := createdDeserializer.Deserialize([]byte(`{"event_type":"user.deleted","user_id":"user-123"}`)); !errors.Is(err, ErrUnexpectedEventType) {
+		t.Errorf("expected ErrUnexpectedEventType, got %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package deserializer
+
+import (
+	"encoding/json"
+
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/schema"
+)
+
+// ValidatingDeserializer validates payloads against the schema of their event type before decoding them.
+// Invalid payloads are returned as non-retryable errors, so consumers dead-letter them straight away.
+type ValidatingDeserializer[T events.Event] struct {
+	deserializer Deserializer[T]
+	validator    *schema.Validator
+}
+
+func NewValidatingDeserializer[T events.Event](deserializer Deserializer[T], validator *schema.Validator) *ValidatingDeserializer[T] {
+	return &ValidatingDeserializer[T]{
+		deserializer: deserializer,
+		validator:    validator,
+	}
+}
+
+func (d *ValidatingDeserializer[T]) Deserialize(data []byte) (T, error) {
+	var zero T
+
+	var metadata events.EventMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return zero, events.NonRetryable(err)
+	}
+
+	if err := d.validator.Validate(metadata.EventType, data); err != nil {
+		return zero, err
+	}
+	return d.deserializer.Deserialize(data)
+}
+
+// Make sure the deserializer implements the Deserializer interface
+var _ Deserializer[events.Event] = &ValidatingDeserializer[events.Event]{}
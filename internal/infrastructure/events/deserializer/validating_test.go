@@ -0,0 +1,89 @@
+package deserializer
+
+import (
+	"encoding/json"
+	"testing"
+
+	userEvents "github.com/cgund98/go-postgres-api-template/internal/domain/user/events"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/schema"
+)
+
+func TestValidatingDeserializer_Deserialize(t *testing.T) {
+	registry := events.NewRegistry()
+	if err := userEvents.RegisterEvents(registry); err != nil {
+		t.Fatalf("failed to register events: %v", err)
+	}
+
+	marshal := func(payload any) []byte {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatalf("failed to marshal payload: %v", err)
+		}
+		return data
+	}
+
+	tests := []struct {
+		name          string
+		data          []byte
+		expectedError bool
+	}{
+		{
+			name: "decodes well formed changes",
+			data: marshal(userEvents.NewUserUpdatedEvent("user-123", map[string]any{
+				"email": map[string]any{"old": "old@example.com", "new": "new@example.com"},
+			})),
+		},
+		{
+			name: "rejects changes without old and new values",
+			data: marshal(userEvents.NewUserUpdatedEvent("user-123", map[string]any{
+				"email": "new@example.com",
+			})),
+			expectedError: true,
+		},
+		{
+			name:          "rejects changes that aren't a map",
+			data:          marshal(map[string]any{"event_id": "event-1", "event_type": userEvents.EventTypeUserUpdated, "timestamp": "2026-01-01T00:00:00Z", "user_id": "user-123", "changes": []string{"email"}}),
+			expectedError: true,
+		},
+		{
+			name:          "rejects payloads that aren't JSON",
+			data:          []byte("not json"),
+			expectedError: true,
+		},
+		{
+			name:          "rejects unregistered event types",
+			data:          marshal(map[string]any{"event_id": "event-1", "event_type": "user.archived", "timestamp": "2026-01-01T00:00:00Z"}),
+			expectedError: true,
+		},
+	}
+
+	deserializer := NewValidatingDeserializer[*userEvents.UserUpdatedEvent](
+		NewJSONDeserializer[*userEvents.UserUpdatedEvent](),
+		schema.NewValidator(registry, schema.ValidatorOptions{}),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := deserializer.Deserialize(tt.data)
+
+			if !tt.expectedError {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if event == nil || event.UserID != "user-123" {
+					t.Errorf("expected the decoded event of user-123, got %+v", event)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			// Consumers dead-letter non-retryable errors instead of redelivering the message
+			if !events.IsNonRetryable(err) {
+				t.Errorf("expected a non-retryable error, got %v", err)
+			}
+		})
+	}
+}
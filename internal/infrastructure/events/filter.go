@@ -0,0 +1,81 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	// ErrFilterNotRegistered is returned when looking up a filter name that has not been registered
+	ErrFilterNotRegistered = errors.New("filter not registered")
+
+	// ErrDuplicateFilter is returned when registering a filter name more than once
+	ErrDuplicateFilter = errors.New("filter already registered")
+)
+
+// Filter decides whether a consumed event should be handled, given the message attributes
+// set by the publisher and the decoded event. Events it rejects are acknowledged without
+// being handled, which complements SNS filter policies with checks on the event body.
+type Filter func(attributes map[string]string, event Event) bool
+
+// MatchEventTypes accepts events of any of the given types
+func MatchEventTypes(eventTypes ...string) Filter {
+	return func(_ map[string]string, event Event) bool {
+		return slices.Contains(eventTypes, event.Type())
+	}
+}
+
+// MatchAttribute accepts messages whose attribute has the given value
+func MatchAttribute(name, value string) Filter {
+	return func(attributes map[string]string, _ Event) bool {
+		actual, ok := attributes[name]
+		return ok && actual == value
+	}
+}
+
+// MatchAll accepts events accepted by every filter
+func MatchAll(filters ...Filter) Filter {
+	return func(attributes map[string]string, event Event) bool {
+		for _, filter := range filters {
+			if !filter(attributes, event) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// FilterRegistry holds filters by name so subscriptions in the config file can refer to them
+type FilterRegistry struct {
+	filters registry[string, Filter]
+}
+
+// NewFilterRegistry creates a new, empty filter registry
+func NewFilterRegistry() *FilterRegistry {
+	return &FilterRegistry{
+		filters: newRegistry[string, Filter](ErrDuplicateFilter, ErrFilterNotRegistered),
+	}
+}
+
+// Register adds a filter to the registry under the given name
+func (r *FilterRegistry) Register(name string, filter Filter) error {
+	if name == "" {
+		return errors.New("filter registration is missing a name")
+	}
+	if filter == nil {
+		return fmt.Errorf("filter %s is nil", name)
+	}
+
+	return r.filters.add(name, filter)
+}
+
+// Lookup returns the filter registered under the given name
+func (r *FilterRegistry) Lookup(name string) (Filter, error) {
+	return r.filters.lookup(name)
+}
+
+// Names returns the names of all registered filters, sorted
+func (r *FilterRegistry) Names() []string {
+	return r.filters.keys()
+}
@@ -0,0 +1,94 @@
+package events
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFilters(t *testing.T) {
+	event := &testEvent{EventMetadata: EventMetadata{EventType: "test.event"}}
+
+	tests := []struct {
+		name       string
+		filter     Filter
+		attributes map[string]string
+		expected   bool
+	}{
+		{
+			name:     "event type matches",
+			filter:   MatchEventTypes("other.event", "test.event"),
+			expected: true,
+		},
+		{
+			name:     "event type does not match",
+			filter:   MatchEventTypes("other.event"),
+			expected: false,
+		},
+		{
+			name:       "attribute matches",
+			filter:     MatchAttribute("tenant", "acme"),
+			attributes: map[string]string{"tenant": "acme"},
+			expected:   true,
+		},
+		{
+			name:       "attribute has another value",
+			filter:     MatchAttribute("tenant", "acme"),
+			attributes: map[string]string{"tenant": "globex"},
+			expected:   false,
+		},
+		{
+			name:     "attribute is missing",
+			filter:   MatchAttribute("tenant", ""),
+			expected: false,
+		},
+		{
+			name:       "all filters match",
+			filter:     MatchAll(MatchEventTypes("test.event"), MatchAttribute("tenant", "acme")),
+			attributes: map[string]string{"tenant": "acme"},
+			expected:   true,
+		},
+		{
+			name:       "one filter does not match",
+			filter:     MatchAll(MatchEventTypes("test.event"), MatchAttribute("tenant", "globex")),
+			attributes: map[string]string{"tenant": "acme"},
+			expected:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := tt.filter(tt.attributes, event); actual != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFilterRegistry(t *testing.T) {
+	registry := NewFilterRegistry()
+	filter := MatchEventTypes("test.event")
+
+	if err := registry.Register("test", filter); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := registry.Register("test", filter); !errors.Is(err, ErrDuplicateFilter) {
+		t.Errorf("expected ErrDuplicateFilter, got %v", err)
+	}
+	if err := registry.Register("", filter); err == nil {
+		t.Error("expected error for filter without a name")
+	}
+	if err := registry.Register("nil", nil); err == nil {
+		t.Error("expected error for nil filter")
+	}
+
+	if _, err := registry.Lookup("missing"); !errors.Is(err, ErrFilterNotRegistered) {
+		t.Errorf("expected ErrFilterNotRegistered, got %v", err)
+	}
+	if _, err := registry.Lookup("test"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if names := registry.Names(); len(names) != 1 || names[0] != "test" {
+		t.Errorf("unexpected names: %v", names)
+	}
+}
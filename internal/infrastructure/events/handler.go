@@ -9,6 +9,37 @@ type Handler[T Event] interface {
 	Handle(ctx context.Context, event T) error
 }
 
+// BatchHandler defines the interface for handling consumed events in batches
 type BatchHandler[T Event] interface {
-	HandleBatch(ctx context.Context, events []T) error
+	// HandleBatch processes the events and returns the outcome of each one
+	HandleBatch(ctx context.Context, events []T) BatchResult
+}
+
+// BatchResult holds the outcome of each event in a batch, in the same order as the events.
+// A nil entry means the event was handled successfully; failed events are retried.
+type BatchResult []error
+
+// NewBatchResult creates a result for a batch of n events where every event succeeded
+func NewBatchResult(n int) BatchResult {
+	return make(BatchResult, n)
+}
+
+// BatchFailure creates a result for a batch of n events where every event failed with err
+func BatchFailure(n int, err error) BatchResult {
+	result := make(BatchResult, n)
+	for i := range result {
+		result[i] = err
+	}
+	return result
+}
+
+// Failed returns the number of events that failed
+func (r BatchResult) Failed() int {
+	failed := 0
+	for _, err := range r {
+		if err != nil {
+			failed++
+		}
+	}
+	return failed
 }
@@ -0,0 +1,96 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	// ErrHandlerNotRegistered is returned when looking up a handler name that has not been registered
+	ErrHandlerNotRegistered = errors.New("handler not registered")
+
+	// ErrDuplicateHandler is returned when registering a handler name more than once
+	ErrDuplicateHandler = errors.New("handler already registered")
+
+	// ErrHandlerTypeMismatch is returned when a handler receives an event of a type it does not handle
+	ErrHandlerTypeMismatch = errors.New("handler does not accept event type")
+)
+
+// HandlerRegistry holds event handlers by name so consumers can be wired from configuration
+type HandlerRegistry struct {
+	handlers registry[string, registeredHandler]
+}
+
+// registeredHandler is a handler with its event type erased
+type registeredHandler struct {
+	handler Handler[Event]
+	goType  reflect.Type
+}
+
+// NewHandlerRegistry creates a new, empty handler registry
+func NewHandlerRegistry() *HandlerRegistry {
+	return &HandlerRegistry{
+		handlers: newRegistry[string, registeredHandler](ErrDuplicateHandler, ErrHandlerNotRegistered),
+	}
+}
+
+// RegisterHandler adds a typed handler to the registry under the given name.
+// This is a function rather than a method because Go methods cannot have type parameters.
+func RegisterHandler[T Event](registry *HandlerRegistry, name string, handler Handler[T]) error {
+	if name == "" {
+		return errors.New("handler registration is missing a name")
+	}
+
+	return registry.handlers.add(name, registeredHandler{
+		handler: &handlerAdapter[T]{name: name, next: handler},
+		goType:  reflect.TypeOf((*T)(nil)).Elem(),
+	})
+}
+
+// Lookup returns the handler registered under the given name
+func (r *HandlerRegistry) Lookup(name string) (Handler[Event], error) {
+	registered, err := r.handlers.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return registered.handler, nil
+}
+
+// Validate checks that the named handler accepts every given event type,
+// so a subscription routing the wrong events to a handler is caught at startup
+func (r *HandlerRegistry) Validate(name string, registrations ...Registration) error {
+	registered, err := r.handlers.lookup(name)
+	if err != nil {
+		return err
+	}
+
+	for _, registration := range registrations {
+		if !reflect.TypeOf(registration.New()).AssignableTo(registered.goType) {
+			return fmt.Errorf("%w: handler %s handles %s, not %s",
+				ErrHandlerTypeMismatch, name, registered.goType, registration.Type)
+		}
+	}
+	return nil
+}
+
+// Names returns the names of all registered handlers, sorted
+func (r *HandlerRegistry) Names() []string {
+	return r.handlers.keys()
+}
+
+// handlerAdapter lets a typed handler receive events through the Handler[Event] interface
+type handlerAdapter[T Event] struct {
+	name string
+	next Handler[T]
+}
+
+// Handle asserts the event to the handler's type before handling it
+func (a *handlerAdapter[T]) Handle(ctx context.Context, event Event) error {
+	typed, ok := event.(T)
+	if !ok {
+		return fmt.Errorf("%w: handler %s received %T", ErrHandlerTypeMismatch, a.name, event)
+	}
+	return a.next.Handle(ctx, typed)
+}
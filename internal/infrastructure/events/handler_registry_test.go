@@ -0,0 +1,64 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// otherEvent is a second event type for handler type checks
+type otherEvent struct {
+	testEvent
+}
+
+// testHandler counts the events it handles
+type testHandler[T Event] struct {
+	handled int
+}
+
+func (h *testHandler[T]) Handle(_ context.Context, _ T) error {
+	h.handled++
+	return nil
+}
+
+func TestHandlerRegistry(t *testing.T) {
+	registry := NewHandlerRegistry()
+	handler := &testHandler[*testEvent]{}
+
+	if err := RegisterHandler[*testEvent](registry, "test", handler); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := RegisterHandler[*testEvent](registry, "test", handler); !errors.Is(err, ErrDuplicateHandler) {
+		t.Errorf("expected ErrDuplicateHandler, got %v", err)
+	}
+	if err := RegisterHandler[*testEvent](registry, "", handler); err == nil {
+		t.Error("expected error for handler without a name")
+	}
+
+	if _, err := registry.Lookup("missing"); !errors.Is(err, ErrHandlerNotRegistered) {
+		t.Errorf("expected ErrHandlerNotRegistered, got %v", err)
+	}
+
+	testRegistration := Registration{Type: "test.event", New: func() Event { return &testEvent{} }}
+	otherRegistration := Registration{Type: "other.event", New: func() Event { return &otherEvent{} }}
+	if err := registry.Validate("test", testRegistration); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := registry.Validate("test", testRegistration, otherRegistration); !errors.Is(err, ErrHandlerTypeMismatch) {
+		t.Errorf("expected ErrHandlerTypeMismatch, got %v", err)
+	}
+
+	adapted, err := registry.Lookup("test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := adapted.Handle(context.Background(), &testEvent{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := adapted.Handle(context.Background(), &otherEvent{}); !errors.Is(err, ErrHandlerTypeMismatch) {
+		t.Errorf("expected ErrHandlerTypeMismatch, got %v", err)
+	}
+	if handler.handled != 1 {
+		t.Errorf("expected 1 handled event, got %d", handler.handled)
+	}
+}
@@ -0,0 +1,196 @@
+package ordering
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+	"github.com/cgund98/go-postgres-api-template/internal/observability"
+)
+
+var logger = observability.Logger
+
+// DefaultGapTimeout is how long an aggregate waits for its missing events before the gap is accepted
+const DefaultGapTimeout = 5 * time.Minute
+
+var (
+	// ErrSequenceGap is returned when an event arrives before one or more earlier events of its aggregate
+	ErrSequenceGap = errors.New("event sequence gap")
+
+	// ErrStaleEvent is returned when an event arrives after a later event of its aggregate was processed
+	ErrStaleEvent = errors.New("stale event")
+)
+
+// Policy decides what happens to an event that arrives out of order
+type Policy int
+
+const (
+	// Reject returns an error so the message is retried later
+	Reject Policy = iota
+
+	// Drop logs the event and acknowledges it without calling the handler
+	Drop
+)
+
+// HandlerOptions configures an ordered handler
+type HandlerOptions struct {
+	// GapPolicy applies to events whose predecessors have not been processed yet.
+	// Defaults to Reject, so the event is retried once the missing events arrive.
+	GapPolicy *Policy
+
+	// GapTimeout bounds how long an aggregate waits for its missing events under the Reject policy.
+	// Once it has passed, the missing events are logged and skipped, and the event is handled,
+	// so lost events can't block the aggregate forever. Keep it shorter than the time the queue
+	// redelivers a message before moving it to the dead-letter queue. Defaults to DefaultGapTimeout.
+	GapTimeout *time.Duration
+
+	// StalePolicy applies to events at or before the last processed sequence number,
+	// such as redeliveries. Defaults to Drop.
+	StalePolicy *Policy
+}
+
+// Handler decorates an events.Handler with out-of-order detection.
+// Events without a sequence number are passed through unchanged.
+type Handler[T events.Event] struct {
+	next        events.Handler[T]
+	tracker     Tracker
+	gapPolicy   Policy
+	gapTimeout  time.Duration
+	stalePolicy Policy
+	now         func() time.Time
+
+	mu   sync.Mutex
+	gaps map[string]gap
+}
+
+// gap records when the handler first saw an aggregate stuck after a sequence number
+type gap struct {
+	last  int64
+	since time.Time
+}
+
+// NewHandler wraps next so that it only sees the events of each aggregate in sequence order
+func NewHandler[T events.Event](next events.Handler[T], tracker Tracker, options HandlerOptions) *Handler[T] {
+	gapPolicy := Reject
+	if options.GapPolicy != nil {
+		gapPolicy = *options.GapPolicy
+	}
+
+	gapTimeout := DefaultGapTimeout
+	if options.GapTimeout != nil {
+		gapTimeout = *options.GapTimeout
+	}
+
+	stalePolicy := Drop
+	if options.StalePolicy != nil {
+		stalePolicy = *options.StalePolicy
+	}
+
+	return &Handler[T]{
+		next:        next,
+		tracker:     tracker,
+		gapPolicy:   gapPolicy,
+		gapTimeout:  gapTimeout,
+		stalePolicy: stalePolicy,
+		now:         time.Now,
+		gaps:        map[string]gap{},
+	}
+}
+
+// Handle checks the sequence number of the event against the tracker before handling it.
+// The tracker runs the check, the handler and the advance atomically, so two pollers
+// receiving events of the same aggregate can't both pass the check.
+func (h *Handler[T]) Handle(ctx context.Context, event T) error {
+	sequenced, ok := any(event).(events.Sequenced)
+	if !ok || sequenced.SequenceNumber() == 0 {
+		return h.next.Handle(ctx, event)
+	}
+
+	sequence := sequenced.SequenceNumber()
+	last, err := h.process(ctx, event, sequence)
+	if err != nil {
+		return err
+	}
+
+	if sequence > last+1 && h.gapPolicy == Reject && h.gapExpired(event.AggregateID(), last) {
+		logger.Warn("Skipping missing events after the gap timeout",
+			"event_id", event.EventID(),
+			"event_type", event.Type(),
+			"aggregate_id", event.AggregateID(),
+			"first_skipped_sequence", last+1,
+			"last_skipped_sequence", sequence-1,
+			"gap_timeout", h.gapTimeout,
+		)
+		if err := h.tracker.Advance(ctx, event.AggregateID(), sequence-1); err != nil {
+			return err
+		}
+		if last, err = h.process(ctx, event, sequence); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case sequence <= last:
+		return h.outOfOrder(event, h.stalePolicy, ErrStaleEvent, sequence, last)
+	case sequence > last+1:
+		return h.outOfOrder(event, h.gapPolicy, ErrSequenceGap, sequence, last)
+	}
+	return nil
+}
+
+// process hands the event to the tracker, and forgets the gap of its aggregate once it is handled
+func (h *Handler[T]) process(ctx context.Context, event T, sequence int64) (int64, error) {
+	last, err := h.tracker.Process(ctx, event.AggregateID(), sequence, func(ctx context.Context) error {
+		return h.next.Handle(ctx, event)
+	})
+	if err == nil && sequence == last+1 {
+		h.mu.Lock()
+		delete(h.gaps, event.AggregateID())
+		h.mu.Unlock()
+	}
+	return last, err
+}
+
+// gapExpired reports whether the aggregate has been stuck after the last sequence number for longer than the gap timeout.
+// The wait starts over whenever the aggregate makes progress.
+func (h *Handler[T]) gapExpired(aggregateID string, last int64) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.now()
+	g, ok := h.gaps[aggregateID]
+	if !ok || g.last != last {
+		h.gaps[aggregateID] = gap{last: last, since: now}
+		return false
+	}
+	if now.Sub(g.since) < h.gapTimeout {
+		return false
+	}
+
+	delete(h.gaps, aggregateID)
+	return true
+}
+
+// outOfOrder applies the policy to an event that arrived out of order
+func (h *Handler[T]) outOfOrder(event T, policy Policy, reason error, sequence, last int64) error {
+	if policy == Drop {
+		logger.Warn("Dropping out of order event",
+			"reason", reason,
+			"event_id", event.EventID(),
+			"event_type", event.Type(),
+			"aggregate_id", event.AggregateID(),
+			"sequence", sequence,
+			"last_sequence", last,
+		)
+		return nil
+	}
+
+	return fmt.Errorf("%w: event %s of aggregate %s has sequence %d, last processed is %d",
+		reason, event.EventID(), event.AggregateID(), sequence, last)
+}
+
+// Make sure the handler implements the events.Handler interface
+var _ events.Handler[events.Event] = &Handler[events.Event]{}
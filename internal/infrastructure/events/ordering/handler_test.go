@@ -0,0 +1,278 @@
+package ordering
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+)
+
+// testEvent is a minimal sequenced event
+type testEvent struct {
+	events.EventMetadata
+	Aggregate string
+}
+
+func (e *testEvent) Type() string        { return e.EventType }
+func (e *testEvent) EventID() string     { return e.EventMetadata.EventID }
+func (e *testEvent) AggregateID() string { return e.Aggregate }
+
+// mockHandler records the sequence numbers it handled
+type mockHandler struct {
+	mu        sync.Mutex
+	handleErr error
+	handled   []int64
+}
+
+func (m *mockHandler) Handle(_ context.Context, event *testEvent) error {
+	if m.handleErr != nil {
+		return m.handleErr
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handled = append(m.handled, event.Sequence)
+	return nil
+}
+
+func TestHandler_Handle(t *testing.T) {
+	reject := Reject
+	drop := Drop
+
+	tests := []struct {
+		name            string
+		options         HandlerOptions
+		lastSequence    int64
+		sequence        int64
+		handleErr       error
+		expectedErr     error
+		expectedHandled int
+		expectedLast    int64
+	}{
+		{
+			name:            "handles the first event of an aggregate",
+			sequence:        1,
+			expectedHandled: 1,
+			expectedLast:    1,
+		},
+		{
+			name:            "handles the next event in sequence",
+			lastSequence:    3,
+			sequence:        4,
+			expectedHandled: 1,
+			expectedLast:    4,
+		},
+		{
+			name:            "passes through events without a sequence",
+			lastSequence:    3,
+			sequence:        0,
+			expectedHandled: 1,
+			expectedLast:    3,
+		},
+		{
+			name:         "rejects events after a gap by default",
+			lastSequence: 1,
+			sequence:     3,
+			expectedErr:  ErrSequenceGap,
+			expectedLast: 1,
+		},
+		{
+			name:         "rejects an update that arrives before its create",
+			sequence:     2,
+			expectedErr:  ErrSequenceGap,
+			expectedLast: 0,
+		},
+		{
+			name:         "drops events after a gap when configured",
+			options:      HandlerOptions{GapPolicy: &drop},
+			lastSequence: 1,
+			sequence:     3,
+			expectedLast: 1,
+		},
+		{
+			name:         "drops stale events by default",
+			lastSequence: 3,
+			sequence:     2,
+			expectedLast: 3,
+		},
+		{
+			name:         "drops redelivered events by default",
+			lastSequence: 3,
+			sequence:     3,
+			expectedLast: 3,
+		},
+		{
+			name:         "rejects stale events when configured",
+			options:      HandlerOptions{StalePolicy: &reject},
+			lastSequence: 3,
+			sequence:     2,
+			expectedErr:  ErrStaleEvent,
+			expectedLast: 3,
+		},
+		{
+			name:         "does not advance when the handler fails",
+			lastSequence: 1,
+			sequence:     2,
+			handleErr:    errors.New("handler failed"),
+			expectedErr:  errors.New("handler failed"),
+			expectedLast: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			tracker := NewMemoryTracker()
+			if err := tracker.Advance(ctx, "user-123", tt.lastSequence); err != nil {
+				t.Fatalf("failed to seed tracker: %v", err)
+			}
+
+			next := &mockHandler{handleErr: tt.handleErr}
+			handler := NewHandler[*testEvent](next, tracker, tt.options)
+
+			event := &testEvent{EventMetadata: events.NewBaseEvent("test.event"), Aggregate: "user-123"}
+			event.SetSequence(tt.sequence)
+
+			err := handler.Handle(ctx, event)
+
+			switch {
+			case tt.expectedErr == nil && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.expectedErr != nil && err == nil:
+				t.Errorf("expected error %v but got nil", tt.expectedErr)
+			case tt.expectedErr != nil && tt.handleErr == nil && !errors.Is(err, tt.expectedErr):
+				t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+			}
+
+			if len(next.handled) != tt.expectedHandled {
+				t.Errorf("expected %d handled events, got %d", tt.expectedHandled, len(next.handled))
+			}
+
+			last, _ := tracker.Last(ctx, "user-123")
+			if last != tt.expectedLast {
+				t.Errorf("expected last sequence %d, got %d", tt.expectedLast, last)
+			}
+		})
+	}
+}
+
+func TestHandler_Handle_concurrentPollers(t *testing.T) {
+	ctx := context.Background()
+	tracker := NewMemoryTracker()
+	next := &mockHandler{}
+	handler := NewHandler[*testEvent](next, tracker, HandlerOptions{})
+
+	// Every poller receives the same event, as happens when a message is redelivered
+	// while the first delivery is still being handled
+	const pollers = 20
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for range pollers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			event := &testEvent{EventMetadata: events.NewBaseEvent("test.event"), Aggregate: "user-123"}
+			event.SetSequence(1)
+			<-start
+			if err := handler.Handle(ctx, event); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if len(next.handled) != 1 {
+		t.Errorf("expected the event to be handled once, got %d", len(next.handled))
+	}
+	last, _ := tracker.Last(ctx, "user-123")
+	if last != 1 {
+		t.Errorf("expected last sequence 1, got %d", last)
+	}
+}
+
+func TestHandler_Handle_gapTimeout(t *testing.T) {
+	ctx := context.Background()
+	tracker := NewMemoryTracker()
+	if err := tracker.Advance(ctx, "user-123", 1); err != nil {
+		t.Fatalf("failed to seed tracker: %v", err)
+	}
+	next := &mockHandler{}
+	timeout := 5 * time.Minute
+	handler := NewHandler[*testEvent](next, tracker, HandlerOptions{GapTimeout: &timeout})
+
+	start := time.Now()
+	now := start
+	handler.now = func() time.Time { return now }
+
+	// Each step delivers an event at an offset from the first delivery
+	steps := []struct {
+		name         string
+		offset       time.Duration
+		sequence     int64
+		expectedErr  error
+		expectedLast int64
+	}{
+		{
+			name:         "rejects the event after a gap",
+			sequence:     4,
+			expectedErr:  ErrSequenceGap,
+			expectedLast: 1,
+		},
+		{
+			name:         "handles a missing event that arrives in time",
+			offset:       4 * time.Minute,
+			sequence:     2,
+			expectedLast: 2,
+		},
+		{
+			name:         "waits again after the aggregate made progress",
+			offset:       6 * time.Minute,
+			sequence:     4,
+			expectedErr:  ErrSequenceGap,
+			expectedLast: 2,
+		},
+		{
+			name:         "keeps rejecting until the timeout has passed",
+			offset:       10 * time.Minute,
+			sequence:     4,
+			expectedErr:  ErrSequenceGap,
+			expectedLast: 2,
+		},
+		{
+			name:         "skips the missing event once the timeout has passed",
+			offset:       11 * time.Minute,
+			sequence:     4,
+			expectedLast: 4,
+		},
+		{
+			name:         "drops the skipped event if it arrives late",
+			offset:       12 * time.Minute,
+			sequence:     3,
+			expectedLast: 4,
+		},
+	}
+
+	for _, step := range steps {
+		now = start.Add(step.offset)
+		event := &testEvent{EventMetadata: events.NewBaseEvent("test.event"), Aggregate: "user-123"}
+		event.SetSequence(step.sequence)
+
+		err := handler.Handle(ctx, event)
+		if !errors.Is(err, step.expectedErr) {
+			t.Errorf("%s: expected error %v, got %v", step.name, step.expectedErr, err)
+		}
+
+		last, _ := tracker.Last(ctx, "user-123")
+		if last != step.expectedLast {
+			t.Errorf("%s: expected last sequence %d, got %d", step.name, step.expectedLast, last)
+		}
+	}
+
+	expectedHandled := []int64{2, 4}
+	if len(next.handled) != len(expectedHandled) || next.handled[0] != 2 || next.handled[1] != 4 {
+		t.Errorf("expected handled sequences %v, got %v", expectedHandled, next.handled)
+	}
+}
@@ -0,0 +1,172 @@
+package ordering
+
+import (
+	"context"
+
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/db"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/db/postgres"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+)
+
+// sequenceConstraints names the fields protected by the event_sequences table's constraints
+var sequenceConstraints = postgres.Constraints{
+	"event_sequences_pkey": "aggregate_id",
+}
+
+// PostgresSequencer implements events.Sequencer using the event_sequences table.
+// Like repositories, it extracts the transaction from context.Context internally.
+// The row of the aggregate stays locked until the transaction ends, so concurrent
+// writers to the same aggregate receive sequence numbers in commit order.
+type PostgresSequencer struct {
+}
+
+// NewPostgresSequencer creates a new PostgreSQL sequencer
+func NewPostgresSequencer() *PostgresSequencer {
+	return &PostgresSequencer{}
+}
+
+// Next increments and returns the sequence number of the aggregate
+func (s *PostgresSequencer) Next(ctx context.Context, aggregateID string) (int64, error) {
+	tx := postgres.GetTXFromContext(ctx)
+	if tx == nil {
+		return 0, db.ErrNoDBContext
+	}
+
+	query := `
+		INSERT INTO event_sequences (aggregate_id, sequence)
+		VALUES ($1, 1)
+		ON CONFLICT (aggregate_id) DO UPDATE
+		SET sequence = event_sequences.sequence + 1, updated_at = CURRENT_TIMESTAMP
+		RETURNING sequence
+	`
+
+	var sequence int64
+	if err := tx.QueryRow(ctx, query, aggregateID).Scan(&sequence); err != nil {
+		return 0, postgres.TranslateError(err, sequenceConstraints)
+	}
+	return sequence, nil
+}
+
+// Ensure PostgresSequencer implements events.Sequencer
+var _ events.Sequencer = (*PostgresSequencer)(nil)
+
+/** -------------------------------- Tracker -------------------------------- */
+
+// trackerConstraints names the fields protected by the processed_event_sequences table's constraints.
+// Sequence numbers are tracked per subscription, so the primary key spans both columns.
+var trackerConstraints = postgres.Constraints{
+	"processed_event_sequences_pkey": "(subscription, aggregate_id)",
+}
+
+// PostgresTracker implements Tracker using the processed_event_sequences table, so sequence numbers
+// survive restarts and are shared by every worker instance consuming the subscription.
+// Each subscription tracks its own sequence numbers, since subscriptions handle events independently.
+type PostgresTracker struct {
+	txManager    db.TransactionManager
+	subscription string
+}
+
+// NewPostgresTracker creates a new PostgreSQL tracker for the subscription
+func NewPostgresTracker(txManager db.TransactionManager, subscription string) *PostgresTracker {
+	return &PostgresTracker{
+		txManager:    txManager,
+		subscription: subscription,
+	}
+}
+
+// Last implements Tracker
+func (t *PostgresTracker) Last(ctx context.Context, aggregateID string) (int64, error) {
+	var last int64
+
+	err := t.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		tx := postgres.GetTXFromContext(txCtx)
+		if tx == nil {
+			return db.ErrNoDBContext
+		}
+
+		query := `
+			SELECT COALESCE(MAX(sequence), 0)
+			FROM processed_event_sequences
+			WHERE subscription = $1 AND aggregate_id = $2
+		`
+		err := tx.QueryRow(txCtx, query, t.subscription, aggregateID).Scan(&last)
+		return postgres.TranslateError(err, trackerConstraints)
+	}, db.ReadOnly())
+
+	return last, err
+}
+
+// Process implements Tracker. The aggregate's row stays locked until handle returns, so a second
+// poller receiving an event of the same aggregate waits and then sees the advanced sequence number.
+// handle runs in the tracker's transaction, so the handler's own writes commit together with the advance.
+// The transaction isn't retried, since handle may have side effects; the message is redelivered instead.
+func (t *PostgresTracker) Process(ctx context.Context, aggregateID string, sequence int64, handle func(ctx context.Context) error) (int64, error) {
+	var last int64
+
+	err := t.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		tx := postgres.GetTXFromContext(txCtx)
+		if tx == nil {
+			return db.ErrNoDBContext
+		}
+
+		// Create the row first, so the first event of an aggregate is locked like any other
+		insert := `
+			INSERT INTO processed_event_sequences (subscription, aggregate_id, sequence)
+			VALUES ($1, $2, 0)
+			ON CONFLICT (subscription, aggregate_id) DO NOTHING
+		`
+		if _, err := tx.Exec(txCtx, insert, t.subscription, aggregateID); err != nil {
+			return postgres.TranslateError(err, trackerConstraints)
+		}
+
+		lock := `
+			SELECT sequence
+			FROM processed_event_sequences
+			WHERE subscription = $1 AND aggregate_id = $2
+			FOR UPDATE
+		`
+		if err := tx.QueryRow(txCtx, lock, t.subscription, aggregateID).Scan(&last); err != nil {
+			return postgres.TranslateError(err, trackerConstraints)
+		}
+		if sequence != last+1 {
+			return nil
+		}
+
+		if err := handle(txCtx); err != nil {
+			return err
+		}
+
+		advance := `
+			UPDATE processed_event_sequences
+			SET sequence = $3, updated_at = CURRENT_TIMESTAMP
+			WHERE subscription = $1 AND aggregate_id = $2
+		`
+		_, err := tx.Exec(txCtx, advance, t.subscription, aggregateID, sequence)
+		return postgres.TranslateError(err, trackerConstraints)
+	}, db.NonRetryable())
+
+	return last, err
+}
+
+// Advance implements Tracker
+func (t *PostgresTracker) Advance(ctx context.Context, aggregateID string, sequence int64) error {
+	return t.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		tx := postgres.GetTXFromContext(txCtx)
+		if tx == nil {
+			return db.ErrNoDBContext
+		}
+
+		query := `
+			INSERT INTO processed_event_sequences (subscription, aggregate_id, sequence)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (subscription, aggregate_id) DO UPDATE
+			SET sequence = GREATEST(processed_event_sequences.sequence, EXCLUDED.sequence),
+				updated_at = CURRENT_TIMESTAMP
+		`
+		_, err := tx.Exec(txCtx, query, t.subscription, aggregateID, sequence)
+		return postgres.TranslateError(err, trackerConstraints)
+	})
+}
+
+// Ensure PostgresTracker implements Tracker
+var _ Tracker = (*PostgresTracker)(nil)
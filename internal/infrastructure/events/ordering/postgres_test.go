@@ -0,0 +1,60 @@
+package ordering
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/cgund98/go-postgres-api-template/internal/domain"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/db/postgres"
+)
+
+func TestConstraints(t *testing.T) {
+	tests := []struct {
+		name            string
+		constraints     postgres.Constraints
+		err             error
+		expectedErr     error
+		expectedField   string
+		expectedMessage string
+	}{
+		{
+			name:            "sequence primary key names the aggregate",
+			constraints:     sequenceConstraints,
+			err:             &pgconn.PgError{Code: "23505", ConstraintName: "event_sequences_pkey"},
+			expectedErr:     domain.ErrAlreadyExists,
+			expectedField:   "aggregate_id",
+			expectedMessage: "resource already exists: aggregate_id is already in use",
+		},
+		{
+			name:            "tracker primary key names the subscription and the aggregate",
+			constraints:     trackerConstraints,
+			err:             &pgconn.PgError{Code: "23505", ConstraintName: "processed_event_sequences_pkey"},
+			expectedErr:     domain.ErrAlreadyExists,
+			expectedField:   "(subscription, aggregate_id)",
+			expectedMessage: "resource already exists: (subscription, aggregate_id) is already in use",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := postgres.TranslateError(tt.err, tt.constraints)
+
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected %v, got %v", tt.expectedErr, err)
+			}
+			if err.Error() != tt.expectedMessage {
+				t.Errorf("expected message %q, got %q", tt.expectedMessage, err.Error())
+			}
+
+			var constraintErr *postgres.ConstraintError
+			if !errors.As(err, &constraintErr) {
+				t.Fatalf("expected a *postgres.ConstraintError, got %T", err)
+			}
+			if constraintErr.Field != tt.expectedField {
+				t.Errorf("expected field %q, got %q", tt.expectedField, constraintErr.Field)
+			}
+		})
+	}
+}
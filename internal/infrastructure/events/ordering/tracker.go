@@ -0,0 +1,93 @@
+package ordering
+
+import (
+	"context"
+	"sync"
+)
+
+// Tracker records the last sequence number processed for each aggregate
+type Tracker interface {
+	// Last returns the last processed sequence number of the aggregate, or 0 if none has been processed
+	Last(ctx context.Context, aggregateID string) (int64, error)
+
+	// Process calls handle if the sequence number directly follows the last processed one of the aggregate,
+	// and records it as processed once handle succeeds. The check, handle and advance are atomic,
+	// so concurrent calls for the same aggregate run one at a time.
+	// Returns the last processed sequence number the check saw; handle isn't called unless it is sequence-1.
+	Process(ctx context.Context, aggregateID string, sequence int64, handle func(ctx context.Context) error) (int64, error)
+
+	// Advance records the sequence number as processed without handling an event, such as to skip
+	// past events that were lost. It never moves the recorded sequence number backwards.
+	Advance(ctx context.Context, aggregateID string, sequence int64) error
+}
+
+// MemoryTracker is a Tracker that keeps sequence numbers in memory.
+// State is lost on restart and is not shared between worker instances,
+// so use a durable Tracker when ordering must hold across deployments.
+type MemoryTracker struct {
+	mu         sync.Mutex
+	sequences  map[string]int64
+	aggregates map[string]*sync.Mutex
+}
+
+// NewMemoryTracker creates a new in-memory tracker
+func NewMemoryTracker() *MemoryTracker {
+	return &MemoryTracker{
+		sequences:  map[string]int64{},
+		aggregates: map[string]*sync.Mutex{},
+	}
+}
+
+// Last implements Tracker
+func (t *MemoryTracker) Last(_ context.Context, aggregateID string) (int64, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.sequences[aggregateID], nil
+}
+
+// Process implements Tracker. Events of the same aggregate are handled one at a time,
+// while events of other aggregates are handled concurrently.
+func (t *MemoryTracker) Process(ctx context.Context, aggregateID string, sequence int64, handle func(ctx context.Context) error) (int64, error) {
+	lock := t.aggregateLock(aggregateID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	last, err := t.Last(ctx, aggregateID)
+	if err != nil || sequence != last+1 {
+		return last, err
+	}
+
+	if err := handle(ctx); err != nil {
+		return last, err
+	}
+
+	return last, t.Advance(ctx, aggregateID, sequence)
+}
+
+// Advance implements Tracker
+func (t *MemoryTracker) Advance(_ context.Context, aggregateID string, sequence int64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if sequence > t.sequences[aggregateID] {
+		t.sequences[aggregateID] = sequence
+	}
+	return nil
+}
+
+// aggregateLock returns the lock serializing the events of the aggregate
+func (t *MemoryTracker) aggregateLock(aggregateID string) *sync.Mutex {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lock, ok := t.aggregates[aggregateID]
+	if !ok {
+		lock = &sync.Mutex{}
+		t.aggregates[aggregateID] = lock
+	}
+	return lock
+}
+
+// Ensure MemoryTracker implements Tracker
+var _ Tracker = (*MemoryTracker)(nil)
@@ -0,0 +1,39 @@
+package events
+
+import "context"
+
+const (
+	// DefaultPriority is the priority of events published without one
+	DefaultPriority = "default"
+
+	// PriorityAttribute is the message attribute publishers set with the event's priority,
+	// which subscriptions with weighted queues filter on to route each event to one queue
+	PriorityAttribute = "priority"
+)
+
+// priorityKey stores the priority events are published with, see WithPriority
+var priorityKey = &struct{ name string }{"events_priority"}
+
+// Prioritized is implemented by events that carry the priority they were first published with,
+// such as delayed events being republished
+type Prioritized interface {
+	Priority() string
+}
+
+// WithPriority returns a context in which events are published with the given priority,
+// for example "bulk" for events produced by an import, so they go to the subscriptions' bulk queues
+func WithPriority(ctx context.Context, priority string) context.Context {
+	return context.WithValue(ctx, priorityKey, priority)
+}
+
+// PriorityOf returns the priority an event is published with: its own if it carries one,
+// otherwise the priority in the context, otherwise DefaultPriority
+func PriorityOf(ctx context.Context, event Event) string {
+	if prioritized, ok := event.(Prioritized); ok && prioritized.Priority() != "" {
+		return prioritized.Priority()
+	}
+	if priority, ok := ctx.Value(priorityKey).(string); ok && priority != "" {
+		return priority
+	}
+	return DefaultPriority
+}
@@ -0,0 +1,48 @@
+package events
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPriorityOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		event    Event
+		expected string
+	}{
+		{
+			name:     "defaults without a priority",
+			ctx:      context.Background(),
+			event:    &testEvent{},
+			expected: DefaultPriority,
+		},
+		{
+			name:     "uses the priority in the context",
+			ctx:      WithPriority(context.Background(), "bulk"),
+			event:    &testEvent{},
+			expected: "bulk",
+		},
+		{
+			name:     "keeps the priority an event was first published with",
+			ctx:      WithPriority(context.Background(), "bulk"),
+			event:    &RawEvent{EventPriority: "default"},
+			expected: "default",
+		},
+		{
+			name:     "uses the context for a raw event without a priority",
+			ctx:      WithPriority(context.Background(), "bulk"),
+			event:    &RawEvent{},
+			expected: "bulk",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := PriorityOf(tt.ctx, tt.event); actual != tt.expected {
+				t.Errorf("expected priority %q, got %q", tt.expected, actual)
+			}
+		})
+	}
+}
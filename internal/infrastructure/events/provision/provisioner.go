@@ -0,0 +1,443 @@
+package provision
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+
+	awsUtils "github.com/cgund98/go-postgres-api-template/internal/infrastructure/aws"
+	"github.com/cgund98/go-postgres-api-template/internal/observability"
+)
+
+var logger = observability.Logger
+
+// Action is what applying a change does to a resource
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+)
+
+// Kind is the type of resource a change applies to
+type Kind string
+
+const (
+	KindTopic        Kind = "topic"
+	KindQueue        Kind = "queue"
+	KindSubscription Kind = "subscription"
+)
+
+// Change is a single difference between the spec and the current infrastructure
+type Change struct {
+	Action Action
+	Kind   Kind
+	Name   string
+
+	// Attributes are the attributes to set. For updates, only attributes that differ are included.
+	Attributes map[string]string
+
+	// Current holds the current value of each updated attribute
+	Current map[string]string
+
+	// Identifiers needed to apply the change
+	topic           TopicSpec
+	queue           QueueSpec
+	queueURL        string
+	subscriptionARN string
+}
+
+// Plan is the ordered list of changes needed to make the infrastructure match the spec
+type Plan struct {
+	Changes []Change
+}
+
+// Empty reports whether the infrastructure already matches the spec
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// Write prints a human readable diff of the plan
+func (p *Plan) Write(w io.Writer) error {
+	if p.Empty() {
+		_, err := fmt.Fprintln(w, "No changes. Infrastructure matches the spec.")
+		return err
+	}
+
+	for _, change := range p.Changes {
+		symbol := "+"
+		if change.Action == ActionUpdate {
+			symbol = "~"
+		}
+		if _, err := fmt.Fprintf(w, "%s %s %s\n", symbol, change.Kind, change.Name); err != nil {
+			return err
+		}
+		for _, key := range slices.Sorted(maps.Keys(change.Attributes)) {
+			line := fmt.Sprintf("    %s: %s\n", key, change.Attributes[key])
+			if current, ok := change.Current[key]; ok {
+				line = fmt.Sprintf("    %s: %s -> %s\n", key, displayValue(current), change.Attributes[key])
+			}
+			if _, err := io.WriteString(w, line); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\nPlan: %d change(s).\n", len(p.Changes))
+	return err
+}
+
+// Provisioner creates and updates the SNS topics, SQS queues and subscriptions described by a Spec.
+// Applying is idempotent: resources that already match the spec are left untouched.
+type Provisioner struct {
+	sqsClient awsUtils.SQSAdminClientInterface
+	snsClient awsUtils.SNSAdminClientInterface
+}
+
+// NewProvisioner creates a new provisioner
+func NewProvisioner(sqsClient awsUtils.SQSAdminClientInterface, snsClient awsUtils.SNSAdminClientInterface) *Provisioner {
+	return &Provisioner{
+		sqsClient: sqsClient,
+		snsClient: snsClient,
+	}
+}
+
+// Plan compares the spec with the current infrastructure without changing anything
+func (p *Provisioner) Plan(_ context.Context, spec *Spec) (*Plan, error) {
+	plan := &Plan{}
+
+	// Topics, along with the SQS subscriptions of those that exist, keyed by topic and queue ARN
+	subscriptions := map[string]map[string]string{}
+	for _, topic := range spec.Topics {
+		exists, err := p.topicExists(topic)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			attributes := map[string]string{}
+			if topic.FIFO() {
+				attributes["FifoTopic"] = "true"
+			}
+			plan.Changes = append(plan.Changes, Change{Action: ActionCreate, Kind: KindTopic, Name: topic.Name, Attributes: attributes, topic: topic})
+			continue
+		}
+
+		subscriptions[topic.ARN], err = p.listSubscriptions(topic)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Queues
+	for _, queue := range spec.Queues {
+		change, err := p.planQueue(queue)
+		if err != nil {
+			return nil, err
+		}
+		if change != nil {
+			plan.Changes = append(plan.Changes, *change)
+		}
+	}
+
+	// Subscriptions
+	for _, queue := range spec.Queues {
+		for _, subscription := range queue.Subscriptions {
+			change, err := p.planSubscription(queue, subscription, subscriptions[subscription.Topic.ARN][queue.ARN])
+			if err != nil {
+				return nil, err
+			}
+			if change != nil {
+				plan.Changes = append(plan.Changes, *change)
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+// Apply makes the changes in the plan, in order
+func (p *Provisioner) Apply(_ context.Context, plan *Plan) error {
+	for _, change := range plan.Changes {
+		logger.Info("Applying change", "action", change.Action, "kind", change.Kind, "name", change.Name)
+
+		var err error
+		switch change.Kind {
+		case KindTopic:
+			err = p.createTopic(change)
+		case KindQueue:
+			err = p.applyQueue(change)
+		case KindSubscription:
+			err = p.applySubscription(change)
+		default:
+			err = fmt.Errorf("unknown resource kind %s", change.Kind)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to %s %s %s: %w", change.Action, change.Kind, change.Name, err)
+		}
+	}
+	return nil
+}
+
+/** -------------------------------- Topic -------------------------------- */
+
+// topicExists reports whether the topic has been created
+func (p *Provisioner) topicExists(topic TopicSpec) (bool, error) {
+	_, err := p.snsClient.GetTopicAttributes(&sns.GetTopicAttributesInput{
+		TopicArn: aws.String(topic.ARN),
+	})
+	if isAWSError(err, sns.ErrCodeNotFoundException) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get topic %s: %w", topic.Name, err)
+	}
+	return true, nil
+}
+
+// createTopic creates the topic and checks it has the configured ARN
+func (p *Provisioner) createTopic(change Change) error {
+	output, err := p.snsClient.CreateTopic(&sns.CreateTopicInput{
+		Name:       aws.String(change.Name),
+		Attributes: aws.StringMap(change.Attributes),
+	})
+	if err != nil {
+		return err
+	}
+	if arn := aws.StringValue(output.TopicArn); arn != change.topic.ARN {
+		return fmt.Errorf("created topic %s, but the configured ARN is %s", arn, change.topic.ARN)
+	}
+	return nil
+}
+
+/** -------------------------------- Queues -------------------------------- */
+
+// planQueue returns the change needed to create or update the queue, or nil if it matches the spec
+func (p *Provisioner) planQueue(queue QueueSpec) (*Change, error) {
+	desired, err := queue.Attributes()
+	if err != nil {
+		return nil, err
+	}
+
+	urlOutput, err := p.sqsClient.GetQueueUrl(&sqs.GetQueueUrlInput{QueueName: aws.String(queue.Name)})
+	if isAWSError(err, sqs.ErrCodeQueueDoesNotExist) {
+		// FifoQueue can only be set when the queue is created
+		if queue.FIFO() {
+			desired["FifoQueue"] = "true"
+		}
+		return &Change{Action: ActionCreate, Kind: KindQueue, Name: queue.Name, Attributes: desired, queue: queue}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queue %s: %w", queue.Name, err)
+	}
+
+	attributesOutput, err := p.sqsClient.GetQueueAttributes(&sqs.GetQueueAttributesInput{
+		QueueUrl:       urlOutput.QueueUrl,
+		AttributeNames: aws.StringSlice(slices.Collect(maps.Keys(desired))),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attributes of queue %s: %w", queue.Name, err)
+	}
+
+	attributes, current := diffAttributes(desired, aws.StringValueMap(attributesOutput.Attributes))
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+	return &Change{
+		Action:     ActionUpdate,
+		Kind:       KindQueue,
+		Name:       queue.Name,
+		Attributes: attributes,
+		Current:    current,
+		queue:      queue,
+		queueURL:   aws.StringValue(urlOutput.QueueUrl),
+	}, nil
+}
+
+// applyQueue creates the queue or updates its attributes
+func (p *Provisioner) applyQueue(change Change) error {
+	if change.Action == ActionCreate {
+		_, err := p.sqsClient.CreateQueue(&sqs.CreateQueueInput{
+			QueueName:  aws.String(change.Name),
+			Attributes: aws.StringMap(change.Attributes),
+		})
+		return err
+	}
+
+	_, err := p.sqsClient.SetQueueAttributes(&sqs.SetQueueAttributesInput{
+		QueueUrl:   aws.String(change.queueURL),
+		Attributes: aws.StringMap(change.Attributes),
+	})
+	return err
+}
+
+/** -------------------------------- Subscriptions -------------------------------- */
+
+// listSubscriptions returns the ARN of each SQS subscription of the topic, keyed by queue ARN
+func (p *Provisioner) listSubscriptions(topic TopicSpec) (map[string]string, error) {
+	subscriptions := map[string]string{}
+	err := p.snsClient.ListSubscriptionsByTopicPages(&sns.ListSubscriptionsByTopicInput{
+		TopicArn: aws.String(topic.ARN),
+	}, func(page *sns.ListSubscriptionsByTopicOutput, _ bool) bool {
+		for _, subscription := range page.Subscriptions {
+			if aws.StringValue(subscription.Protocol) == "sqs" {
+				subscriptions[aws.StringValue(subscription.Endpoint)] = aws.StringValue(subscription.SubscriptionArn)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions of topic %s: %w", topic.Name, err)
+	}
+	return subscriptions, nil
+}
+
+// planSubscription returns the change needed to subscribe the queue to the topic
+// or update its subscription, or nil if it matches the spec
+func (p *Provisioner) planSubscription(queue QueueSpec, subscription SubscriptionSpec, subscriptionARN string) (*Change, error) {
+	desired, err := subscription.Attributes()
+	if err != nil {
+		return nil, err
+	}
+
+	name := subscription.Topic.Name + " -> " + queue.Name
+	if subscriptionARN == "" {
+		return &Change{Action: ActionCreate, Kind: KindSubscription, Name: name, Attributes: desired, topic: subscription.Topic, queue: queue}, nil
+	}
+
+	output, err := p.snsClient.GetSubscriptionAttributes(&sns.GetSubscriptionAttributesInput{
+		SubscriptionArn: aws.String(subscriptionARN),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription %s: %w", name, err)
+	}
+
+	attributes, current := diffAttributes(desired, aws.StringValueMap(output.Attributes))
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+	return &Change{
+		Action:          ActionUpdate,
+		Kind:            KindSubscription,
+		Name:            name,
+		Attributes:      attributes,
+		Current:         current,
+		topic:           subscription.Topic,
+		queue:           queue,
+		subscriptionARN: subscriptionARN,
+	}, nil
+}
+
+// applySubscription subscribes the queue to the topic or updates the subscription's attributes
+func (p *Provisioner) applySubscription(change Change) error {
+	if change.Action == ActionCreate {
+		_, err := p.snsClient.Subscribe(&sns.SubscribeInput{
+			TopicArn:   aws.String(change.topic.ARN),
+			Protocol:   aws.String("sqs"),
+			Endpoint:   aws.String(change.queue.ARN),
+			Attributes: aws.StringMap(change.Attributes),
+		})
+		return err
+	}
+
+	// SNS only accepts one attribute per call. FilterPolicyScope is set first
+	// so the filter policy is validated against the right scope.
+	keys := slices.Sorted(maps.Keys(change.Attributes))
+	slices.SortStableFunc(keys, func(a, b string) int {
+		return boolToInt(b == "FilterPolicyScope") - boolToInt(a == "FilterPolicyScope")
+	})
+	for _, key := range keys {
+		_, err := p.snsClient.SetSubscriptionAttributes(&sns.SetSubscriptionAttributesInput{
+			SubscriptionArn: aws.String(change.subscriptionARN),
+			AttributeName:   aws.String(key),
+			AttributeValue:  aws.String(change.Attributes[key]),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/** -------------------------------- Helpers -------------------------------- */
+
+// diffAttributes returns the desired attributes that differ from the current ones,
+// along with their current values. JSON attributes such as policies are compared semantically.
+func diffAttributes(desired, current map[string]string) (map[string]string, map[string]string) {
+	changed := map[string]string{}
+	previous := map[string]string{}
+	for key, value := range desired {
+		currentValue, ok := current[key]
+		if ok && equalValues(value, currentValue) {
+			continue
+		}
+		changed[key] = value
+		if ok {
+			previous[key] = currentValue
+		}
+	}
+	return changed, previous
+}
+
+// equalValues compares attribute values, treating JSON documents as equal if they
+// only differ in formatting or in whether numbers are quoted
+func equalValues(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	var aJSON, bJSON any
+	if json.Unmarshal([]byte(a), &aJSON) != nil || json.Unmarshal([]byte(b), &bJSON) != nil {
+		return false
+	}
+	aNormalized, aErr := json.Marshal(stringifyScalars(aJSON))
+	bNormalized, bErr := json.Marshal(stringifyScalars(bJSON))
+	return aErr == nil && bErr == nil && string(aNormalized) == string(bNormalized)
+}
+
+// stringifyScalars converts every scalar in a decoded JSON document to a string
+func stringifyScalars(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = stringifyScalars(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = stringifyScalars(item)
+		}
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// displayValue shows empty attribute values explicitly in plans
+func displayValue(value string) string {
+	if value == "" {
+		return `""`
+	}
+	return value
+}
+
+// isAWSError reports whether err is an AWS error with the given code
+func isAWSError(err error, code string) bool {
+	var awsErr awserr.Error
+	return errors.As(err, &awsErr) && awsErr.Code() == code
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,372 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/cgund98/go-postgres-api-template/internal/config"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+)
+
+const (
+	testTopicARN = "arn:aws:sns:us-east-1:000000000000:events-topic"
+	testQueueURL = "http://sqs.us-east-1.localhost.localstack.cloud:4566/000000000000/"
+)
+
+// mockSQSClient is an in-memory implementation of SQSAdminClientInterface
+type mockSQSClient struct {
+	queues    map[string]map[string]string
+	mutations int
+}
+
+func (m *mockSQSClient) CreateQueue(input *sqs.CreateQueueInput) (*sqs.CreateQueueOutput, error) {
+	m.mutations++
+	m.queues[*input.QueueName] = aws.StringValueMap(input.Attributes)
+	return &sqs.CreateQueueOutput{QueueUrl: aws.String(testQueueURL + *input.QueueName)}, nil
+}
+
+func (m *mockSQSClient) GetQueueUrl(input *sqs.GetQueueUrlInput) (*sqs.GetQueueUrlOutput, error) {
+	if _, ok := m.queues[*input.QueueName]; !ok {
+		return nil, awserr.New(sqs.ErrCodeQueueDoesNotExist, "queue does not exist", nil)
+	}
+	return &sqs.GetQueueUrlOutput{QueueUrl: aws.String(testQueueURL + *input.QueueName)}, nil
+}
+
+func (m *mockSQSClient) GetQueueAttributes(input *sqs.GetQueueAttributesInput) (*sqs.GetQueueAttributesOutput, error) {
+	queue := m.queues[strings.TrimPrefix(*input.QueueUrl, testQueueURL)]
+	attributes := map[string]string{}
+	for _, name := range aws.StringValueSlice(input.AttributeNames) {
+		if value, ok := queue[name]; ok {
+			attributes[name] = value
+		}
+	}
+	return &sqs.GetQueueAttributesOutput{Attributes: aws.StringMap(attributes)}, nil
+}
+
+func (m *mockSQSClient) SetQueueAttributes(input *sqs.SetQueueAttributesInput) (*sqs.SetQueueAttributesOutput, error) {
+	m.mutations++
+	queue := m.queues[strings.TrimPrefix(*input.QueueUrl, testQueueURL)]
+	for name, value := range aws.StringValueMap(input.Attributes) {
+		queue[name] = value
+	}
+	return &sqs.SetQueueAttributesOutput{}, nil
+}
+
+// mockSubscription is an SNS subscription held by mockSNSClient
+type mockSubscription struct {
+	endpoint   string
+	attributes map[string]string
+}
+
+// mockSNSClient is an in-memory implementation of SNSAdminClientInterface
+type mockSNSClient struct {
+	topics        map[string]bool
+	subscriptions map[string]*mockSubscription
+	mutations     int
+}
+
+func (m *mockSNSClient) CreateTopic(input *sns.CreateTopicInput) (*sns.CreateTopicOutput, error) {
+	m.mutations++
+	arn := "arn:aws:sns:us-east-1:000000000000:" + *input.Name
+	m.topics[arn] = true
+	return &sns.CreateTopicOutput{TopicArn: aws.String(arn)}, nil
+}
+
+func (m *mockSNSClient) GetTopicAttributes(input *sns.GetTopicAttributesInput) (*sns.GetTopicAttributesOutput, error) {
+	if !m.topics[*input.TopicArn] {
+		return nil, awserr.New(sns.ErrCodeNotFoundException, "topic does not exist", nil)
+	}
+	return &sns.GetTopicAttributesOutput{}, nil
+}
+
+func (m *mockSNSClient) ListSubscriptionsByTopicPages(input *sns.ListSubscriptionsByTopicInput, fn func(*sns.ListSubscriptionsByTopicOutput, bool) bool) error {
+	page := &sns.ListSubscriptionsByTopicOutput{}
+	for arn, subscription := range m.subscriptions {
+		if !strings.HasPrefix(arn, *input.TopicArn+":") {
+			continue
+		}
+		page.Subscriptions = append(page.Subscriptions, &sns.Subscription{
+			SubscriptionArn: aws.String(arn),
+			Protocol:        aws.String("sqs"),
+			Endpoint:        aws.String(subscription.endpoint),
+		})
+	}
+	fn(page, true)
+	return nil
+}
+
+func (m *mockSNSClient) Subscribe(input *sns.SubscribeInput) (*sns.SubscribeOutput, error) {
+	m.mutations++
+	arn := fmt.Sprintf("%s:%d", *input.TopicArn, len(m.subscriptions))
+	m.subscriptions[arn] = &mockSubscription{endpoint: *input.Endpoint, attributes: aws.StringValueMap(input.Attributes)}
+	return &sns.SubscribeOutput{SubscriptionArn: aws.String(arn)}, nil
+}
+
+func (m *mockSNSClient) GetSubscriptionAttributes(input *sns.GetSubscriptionAttributesInput) (*sns.GetSubscriptionAttributesOutput, error) {
+	return &sns.GetSubscriptionAttributesOutput{Attributes: aws.StringMap(m.subscriptions[*input.SubscriptionArn].attributes)}, nil
+}
+
+func (m *mockSNSClient) SetSubscriptionAttributes(input *sns.SetSubscriptionAttributesInput) (*sns.SetSubscriptionAttributesOutput, error) {
+	m.mutations++
+	m.subscriptions[*input.SubscriptionArn].attributes[*input.AttributeName] = *input.AttributeValue
+	return &sns.SetSubscriptionAttributesOutput{}, nil
+}
+
+// testEvent is a minimal event for the registry
+type testEvent struct {
+	events.EventMetadata
+}
+
+func (e *testEvent) Type() string        { return e.EventType }
+func (e *testEvent) EventID() string     { return e.EventMetadata.EventID }
+func (e *testEvent) AggregateID() string { return "" }
+
+func newTestRegistry(t *testing.T) *events.Registry {
+	t.Helper()
+	registry := events.NewRegistry()
+	for _, eventType := range []string{"user.created", "user.updated"} {
+		if err := registry.Register(events.Registration{Type: eventType, New: func() events.Event { return &testEvent{} }}); err != nil {
+			t.Fatalf("failed to register event: %v", err)
+		}
+	}
+	return registry
+}
+
+func newTestConfig() config.EventsConfig {
+	return config.EventsConfig{
+		TopicARN:      testTopicARN,
+		DelayQueueURL: testQueueURL + "event-delay",
+		Subscriptions: []config.SubscriptionConfig{
+			{
+				Name:            "user-events",
+				QueueURL:        testQueueURL + "user-events",
+				EventTypes:      []string{"user.updated", "user.created"},
+				Handler:         "user-events",
+				MaxReceiveCount: 3,
+			},
+		},
+	}
+}
+
+func TestNewSpec(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	tests := []struct {
+		name           string
+		modify         func(*config.EventsConfig)
+		expectedTopics int
+		expectedQueues string
+
+		// expectedFilterPolicies are the filter policies of the subscribed queues, in order
+		expectedFilterPolicies string
+		expectedError          bool
+	}{
+		{
+			name:                   "derives queues from subscriptions and delay queue",
+			modify:                 func(_ *config.EventsConfig) {},
+			expectedTopics:         1,
+			expectedFilterPolicies: `{"event_type":["user.created","user.updated"]}`,
+		},
+		{
+			name: "subscribes queues to every topic their event types are routed to",
+			modify: func(cfg *config.EventsConfig) {
+				cfg.Routes = []config.RouteConfig{
+					{Pattern: "user.created", TopicARN: testTopicARN + "-created"},
+					{Pattern: "*", TopicARN: testTopicARN},
+				}
+			},
+			expectedTopics:         2,
+			expectedFilterPolicies: `{"event_type":["user.created"]} {"event_type":["user.updated"]}`,
+		},
+		{
+			name: "creates every weighted queue of a subscription",
+			modify: func(cfg *config.EventsConfig) {
+				cfg.Subscriptions[0].Queues = []config.PriorityQueueConfig{
+					{QueueURL: cfg.Subscriptions[0].QueueURL, Priority: "default", Weight: 3},
+					{QueueURL: testQueueURL + "user-events-bulk", Priority: "bulk", Weight: 1},
+				}
+				cfg.Subscriptions[0].QueueURL = ""
+			},
+			expectedTopics: 1,
+			expectedQueues: "user-events-dlq,user-events,user-events-bulk-dlq,user-events-bulk,event-delay-dlq,event-delay",
+			expectedFilterPolicies: `{"event_type":["user.created","user.updated"],"priority":["default"]}` +
+				` {"event_type":["user.created","user.updated"],"priority":["bulk"]}`,
+		},
+		{
+			name: "rejects weighted queues sharing a priority",
+			modify: func(cfg *config.EventsConfig) {
+				cfg.Subscriptions[0].Queues = []config.PriorityQueueConfig{
+					{QueueURL: cfg.Subscriptions[0].QueueURL, Priority: "default", Weight: 3},
+					{QueueURL: testQueueURL + "user-events-bulk", Priority: "default", Weight: 1},
+				}
+				cfg.Subscriptions[0].QueueURL = ""
+			},
+			expectedError: true,
+		},
+		{
+			name: "rejects event types without a route",
+			modify: func(cfg *config.EventsConfig) {
+				cfg.Routes = []config.RouteConfig{{Pattern: "order.*", TopicARN: testTopicARN}}
+			},
+			expectedError: true,
+		},
+		{
+			name:          "rejects unregistered event types",
+			modify:        func(cfg *config.EventsConfig) { cfg.Subscriptions[0].EventTypes = []string{"user.missing"} },
+			expectedError: true,
+		},
+		{
+			name:          "rejects standard queues for FIFO topics",
+			modify:        func(cfg *config.EventsConfig) { cfg.TopicARN += ".fifo" },
+			expectedError: true,
+		},
+		{
+			name:          "requires a topic",
+			modify:        func(cfg *config.EventsConfig) { cfg.TopicARN = "" },
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig()
+			tt.modify(&cfg)
+
+			spec, err := NewSpec(cfg, registry)
+			if tt.expectedError {
+				if err == nil {
+					t.Error("expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			names := make([]string, len(spec.Queues))
+			for i, queue := range spec.Queues {
+				names[i] = queue.Name
+			}
+			expectedQueues := tt.expectedQueues
+			if expectedQueues == "" {
+				expectedQueues = "user-events-dlq,user-events,event-delay-dlq,event-delay"
+			}
+			if strings.Join(names, ",") != expectedQueues {
+				t.Errorf("unexpected queues: %v", names)
+			}
+
+			if len(spec.Topics) != tt.expectedTopics {
+				t.Errorf("expected %d topics, got %+v", tt.expectedTopics, spec.Topics)
+			}
+
+			queue := spec.Queues[1]
+			if len(queue.Subscriptions) != tt.expectedTopics || queue.MaxReceiveCount != 3 || queue.DeadLetterQueue != "arn:aws:sqs:us-east-1:000000000000:user-events-dlq" {
+				t.Errorf("unexpected queue spec: %+v", queue)
+			}
+			var eventTypes []string
+			for _, subscription := range queue.Subscriptions {
+				eventTypes = append(eventTypes, subscription.EventTypes...)
+			}
+			if strings.Join(eventTypes, ",") != "user.created,user.updated" {
+				t.Errorf("expected sorted event types, got %v", eventTypes)
+			}
+
+			var filterPolicies []string
+			for _, queue := range spec.Queues {
+				for _, subscription := range queue.Subscriptions {
+					attributes, err := subscription.Attributes()
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					filterPolicies = append(filterPolicies, attributes["FilterPolicy"])
+				}
+			}
+			if actual := strings.Join(filterPolicies, " "); actual != tt.expectedFilterPolicies {
+				t.Errorf("expected filter policies %s, got %s", tt.expectedFilterPolicies, actual)
+			}
+
+			if len(spec.Queues[len(spec.Queues)-1].Subscriptions) != 0 {
+				t.Error("expected delay queue not to be subscribed to the topic")
+			}
+		})
+	}
+}
+
+func TestProvisioner_PlanApply(t *testing.T) {
+	ctx := context.Background()
+	sqsClient := &mockSQSClient{queues: map[string]map[string]string{}}
+	snsClient := &mockSNSClient{topics: map[string]bool{}, subscriptions: map[string]*mockSubscription{}}
+	provisioner := NewProvisioner(sqsClient, snsClient)
+
+	spec, err := NewSpec(newTestConfig(), newTestRegistry(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Everything is created on the first run
+	plan, err := provisioner.Plan(ctx, spec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sqsClient.mutations+snsClient.mutations != 0 {
+		t.Error("expected plan not to change anything")
+	}
+	// 1 topic, 4 queues, 1 subscription
+	if len(plan.Changes) != 6 {
+		t.Errorf("expected 6 changes, got %d", len(plan.Changes))
+	}
+	for _, change := range plan.Changes {
+		if change.Action != ActionCreate {
+			t.Errorf("expected only creates, got %s %s %s", change.Action, change.Kind, change.Name)
+		}
+	}
+	if err := provisioner.Apply(ctx, plan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Applying again is a no-op, even if AWS quotes numbers in JSON attributes
+	queue := sqsClient.queues["user-events"]
+	queue["RedrivePolicy"] = strings.Replace(queue["RedrivePolicy"], `"maxReceiveCount":3`, `"maxReceiveCount":"3"`, 1)
+	plan, err = provisioner.Plan(ctx, spec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !plan.Empty() {
+		t.Errorf("expected empty plan, got %+v", plan.Changes)
+	}
+
+	// Drift is detected and corrected
+	for _, subscription := range snsClient.subscriptions {
+		subscription.attributes["FilterPolicy"] = `{"event_type":["user.created"]}`
+	}
+	queue["VisibilityTimeout"] = "60"
+	plan, err = provisioner.Plan(ctx, spec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Changes) != 2 {
+		t.Fatalf("expected 2 changes, got %+v", plan.Changes)
+	}
+	for _, change := range plan.Changes {
+		if change.Action != ActionUpdate || len(change.Attributes) != 1 {
+			t.Errorf("expected a single attribute update, got %+v", change)
+		}
+	}
+	if err := provisioner.Apply(ctx, plan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	plan, err = provisioner.Plan(ctx, spec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !plan.Empty() {
+		t.Errorf("expected empty plan after correcting drift, got %+v", plan.Changes)
+	}
+}
@@ -0,0 +1,302 @@
+package provision
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cgund98/go-postgres-api-template/internal/config"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/publisher"
+)
+
+const (
+	// defaultMaxReceiveCount is how many times a message is received before it is moved to the DLQ
+	defaultMaxReceiveCount = 5
+
+	// defaultVisibilityTimeout matches the SQS default
+	defaultVisibilityTimeout = 30
+
+	// deadLetterRetentionPeriod keeps dead-lettered messages for the SQS maximum of 14 days
+	deadLetterRetentionPeriod = 14 * 24 * 60 * 60
+
+	// fifoSuffix is the name suffix SNS and SQS require for FIFO topics and queues
+	fifoSuffix = ".fifo"
+
+	// eventTypeAttribute is the message attribute set by the publisher with the event type
+	eventTypeAttribute = "event_type"
+)
+
+// Spec is the messaging infrastructure the application needs
+type Spec struct {
+	Topics []TopicSpec
+	Queues []QueueSpec
+}
+
+// TopicSpec describes an SNS topic events are published to
+type TopicSpec struct {
+	ARN  string
+	Name string
+}
+
+// FIFO reports whether the topic is a FIFO topic
+func (t TopicSpec) FIFO() bool {
+	return strings.HasSuffix(t.Name, fifoSuffix)
+}
+
+// QueueSpec describes an SQS queue and its subscriptions to topics
+type QueueSpec struct {
+	Name              string
+	ARN               string
+	VisibilityTimeout int64
+
+	// DeadLetterQueue is the ARN of the queue failed messages are moved to, if any
+	DeadLetterQueue string
+	MaxReceiveCount int64
+
+	// RetentionPeriod is how long messages are kept, in seconds. Zero keeps the SQS default.
+	RetentionPeriod int64
+
+	// Subscriptions deliver events from topics to the queue
+	Subscriptions []SubscriptionSpec
+}
+
+// SubscriptionSpec describes the subscription of a queue to a topic
+type SubscriptionSpec struct {
+	Topic TopicSpec
+
+	// EventTypes are the event types routed to the topic that the queue receives
+	EventTypes []string
+
+	// Priority restricts the queue to events published with this priority. Empty receives every priority.
+	Priority string
+}
+
+// FIFO reports whether the queue is a FIFO queue
+func (q QueueSpec) FIFO() bool {
+	return strings.HasSuffix(q.Name, fifoSuffix)
+}
+
+// Attributes returns the SQS queue attributes the queue should have
+func (q QueueSpec) Attributes() (map[string]string, error) {
+	attributes := map[string]string{
+		"VisibilityTimeout": strconv.FormatInt(q.VisibilityTimeout, 10),
+	}
+
+	if q.RetentionPeriod > 0 {
+		attributes["MessageRetentionPeriod"] = strconv.FormatInt(q.RetentionPeriod, 10)
+	}
+
+	if q.DeadLetterQueue != "" {
+		redrivePolicy, err := json.Marshal(map[string]any{
+			"deadLetterTargetArn": q.DeadLetterQueue,
+			"maxReceiveCount":     q.MaxReceiveCount,
+		})
+		if err != nil {
+			return nil, err
+		}
+		attributes["RedrivePolicy"] = string(redrivePolicy)
+	}
+
+	// Allow the topics to deliver to the queue. LocalStack doesn't enforce this, AWS does.
+	if len(q.Subscriptions) > 0 {
+		topicARNs := make([]string, len(q.Subscriptions))
+		for i, subscription := range q.Subscriptions {
+			topicARNs[i] = subscription.Topic.ARN
+		}
+
+		policy, err := json.Marshal(map[string]any{
+			"Version": "2012-10-17",
+			"Statement": []map[string]any{{
+				"Effect":    "Allow",
+				"Principal": map[string]string{"Service": "sns.amazonaws.com"},
+				"Action":    "sqs:SendMessage",
+				"Resource":  q.ARN,
+				"Condition": map[string]any{
+					"ArnEquals": map[string][]string{"aws:SourceArn": topicARNs},
+				},
+			}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		attributes["Policy"] = string(policy)
+	}
+
+	return attributes, nil
+}
+
+// Attributes returns the SNS subscription attributes the subscription should have.
+// Messages are filtered on the event_type message attribute, and the priority message attribute for
+// weighted queues, and delivered raw, as the consumers expect.
+func (s SubscriptionSpec) Attributes() (map[string]string, error) {
+	policy := map[string][]string{
+		eventTypeAttribute: s.EventTypes,
+	}
+	if s.Priority != "" {
+		policy[events.PriorityAttribute] = []string{s.Priority}
+	}
+	filterPolicy, err := json.Marshal(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		"FilterPolicy":       string(filterPolicy),
+		"FilterPolicyScope":  "MessageAttributes",
+		"RawMessageDelivery": "true",
+	}, nil
+}
+
+// NewSpec derives the messaging infrastructure from the events config and event registry.
+// Every topic routed to is created. Every subscription gets a queue subscribed to the topics its
+// event types are routed to, with a filter policy on those event types, and a dead-letter queue
+// with a redrive policy. The weighted queues of a subscription also filter on their priority, so each
+// event lands in exactly one of them. The delay queue, if configured, is not subscribed to any topic.
+func NewSpec(cfg config.EventsConfig, registry *events.Registry) (*Spec, error) {
+	routes := make([]publisher.Route, len(cfg.Routes))
+	for i, route := range cfg.Routes {
+		routes[i] = publisher.Route{Pattern: route.Pattern, TopicARN: route.TopicARN}
+	}
+	router, err := publisher.NewRouter(publisher.RouterOptions{Routes: routes, DefaultTopicARN: &cfg.TopicARN})
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &Spec{}
+	topics := map[string]TopicSpec{}
+	for _, topicARN := range router.TopicARNs() {
+		topic := TopicSpec{ARN: topicARN, Name: resourceName(topicARN, ":")}
+		topics[topicARN] = topic
+		spec.Topics = append(spec.Topics, topic)
+	}
+	if len(spec.Topics) == 0 {
+		return nil, errors.New("no event topics are configured")
+	}
+
+	// Queues are created in the region and account of the first topic
+	defaultTopic := spec.Topics[0]
+
+	for _, subscription := range cfg.Subscriptions {
+		if err := subscription.Validate(); err != nil {
+			return nil, err
+		}
+		if err := registry.Validate(subscription.EventTypes...); err != nil {
+			return nil, fmt.Errorf("subscription %s: %w", subscription.Name, err)
+		}
+
+		// Group the event types by the topic they are routed to
+		eventTypes := slices.Clone(subscription.EventTypes)
+		slices.Sort(eventTypes)
+		var subscriptions []SubscriptionSpec
+		for _, eventType := range eventTypes {
+			topicARN, err := router.TopicARN(eventType)
+			if err != nil {
+				return nil, fmt.Errorf("subscription %s: %w", subscription.Name, err)
+			}
+			i := slices.IndexFunc(subscriptions, func(s SubscriptionSpec) bool { return s.Topic.ARN == topicARN })
+			if i < 0 {
+				subscriptions = append(subscriptions, SubscriptionSpec{Topic: topics[topicARN]})
+				i = len(subscriptions) - 1
+			}
+			subscriptions[i].EventTypes = append(subscriptions[i].EventTypes, eventType)
+		}
+
+		maxReceiveCount := subscription.MaxReceiveCount
+		if maxReceiveCount <= 0 {
+			maxReceiveCount = defaultMaxReceiveCount
+		}
+
+		visibilityTimeout := subscription.VisibilityTimeout
+		if visibilityTimeout <= 0 {
+			visibilityTimeout = defaultVisibilityTimeout
+		}
+
+		// Weighted queues of a subscription each receive the events published with their priority
+		for i, queueURL := range subscription.QueueURLs() {
+			name := resourceName(queueURL, "/")
+			queueSubscriptions := slices.Clone(subscriptions)
+			if len(subscription.Queues) > 0 {
+				for j := range queueSubscriptions {
+					queueSubscriptions[j].Priority = subscription.Queues[i].Priority
+				}
+			}
+			for _, s := range subscriptions {
+				if strings.HasSuffix(name, fifoSuffix) != s.Topic.FIFO() {
+					return nil, fmt.Errorf("subscription %s: queue %s must be FIFO if and only if topic %s is FIFO", subscription.Name, name, s.Topic.Name)
+				}
+			}
+
+			topic := subscriptions[0].Topic
+			dlq := deadLetterQueue(topic, name)
+			queue := QueueSpec{
+				Name:              name,
+				ARN:               queueARN(topic, name),
+				VisibilityTimeout: visibilityTimeout,
+				DeadLetterQueue:   dlq.ARN,
+				MaxReceiveCount:   maxReceiveCount,
+				Subscriptions:     queueSubscriptions,
+			}
+
+			spec.Queues = append(spec.Queues, dlq, queue)
+		}
+	}
+
+	if cfg.DelayQueueURL != "" {
+		name := resourceName(cfg.DelayQueueURL, "/")
+		dlq := deadLetterQueue(defaultTopic, name)
+		spec.Queues = append(spec.Queues, dlq, QueueSpec{
+			Name:              name,
+			ARN:               queueARN(defaultTopic, name),
+			VisibilityTimeout: defaultVisibilityTimeout,
+			DeadLetterQueue:   dlq.ARN,
+			MaxReceiveCount:   defaultMaxReceiveCount,
+		})
+	}
+
+	return spec, nil
+}
+
+// DeadLetterQueueURL returns the URL of the dead-letter queue provisioned for a queue
+func DeadLetterQueueURL(queueURL string) string {
+	i := strings.LastIndex(queueURL, "/") + 1
+	return queueURL[:i] + deadLetterQueueName(queueURL[i:])
+}
+
+// deadLetterQueueName returns the name of the dead-letter queue for the named queue
+func deadLetterQueueName(queueName string) string {
+	name := strings.TrimSuffix(queueName, fifoSuffix) + "-dlq"
+	if strings.HasSuffix(queueName, fifoSuffix) {
+		name += fifoSuffix
+	}
+	return name
+}
+
+// deadLetterQueue returns the spec of the dead-letter queue for the named queue
+func deadLetterQueue(topic TopicSpec, queueName string) QueueSpec {
+	name := deadLetterQueueName(queueName)
+
+	return QueueSpec{
+		Name:              name,
+		ARN:               queueARN(topic, name),
+		VisibilityTimeout: defaultVisibilityTimeout,
+		RetentionPeriod:   deadLetterRetentionPeriod,
+	}
+}
+
+// queueARN derives the ARN of a queue in the same region and account as the topic
+func queueARN(topic TopicSpec, queueName string) string {
+	parts := strings.Split(topic.ARN, ":")
+	if len(parts) < 6 {
+		return ""
+	}
+	return strings.Join([]string{"arn", parts[1], "sqs", parts[3], parts[4], queueName}, ":")
+}
+
+// resourceName returns the last segment of an ARN or URL
+func resourceName(identifier, separator string) string {
+	return identifier[strings.LastIndex(identifier, separator)+1:]
+}
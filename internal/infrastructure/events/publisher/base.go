@@ -2,6 +2,7 @@ package publisher
 
 import (
 	"context"
+	"time"
 
 	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
 )
@@ -11,3 +12,14 @@ type Publisher interface {
 	Publish(ctx context.Context, event events.Event) error
 	PublishBatch(ctx context.Context, events []events.Event) error
 }
+
+// ScheduledPublisher defines the interface for publishing events that should be delivered later
+type ScheduledPublisher interface {
+	Publisher
+
+	// PublishAt publishes an event so that it is delivered at (or shortly after) the given time
+	PublishAt(ctx context.Context, event events.Event, at time.Time) error
+
+	// PublishAfter publishes an event so that it is delivered once the given delay has elapsed
+	PublishAfter(ctx context.Context, event events.Event, delay time.Duration) error
+}
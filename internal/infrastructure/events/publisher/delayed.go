@@ -0,0 +1,163 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/db"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/serializer"
+)
+
+// MaxSQSDelay is the longest delay SQS supports via DelaySeconds.
+// Longer delays are stored in the schedule store instead.
+const MaxSQSDelay = 15 * time.Minute
+
+// Envelope is the message body sent to the delay queue.
+// It carries enough information to republish the event once the delay has elapsed.
+type Envelope struct {
+	EventID     string          `json:"event_id"`
+	EventType   string          `json:"event_type"`
+	AggregateID string          `json:"aggregate_id"`
+	Payload     json.RawMessage `json:"payload"`
+	Priority    string          `json:"priority,omitempty"`
+}
+
+// ScheduledEvent is a serialized event waiting in the schedule store for its delivery time
+type ScheduledEvent struct {
+	EventID     string
+	EventType   string
+	AggregateID string
+	Payload     []byte
+	Priority    string
+	DeliverAt   time.Time
+}
+
+// ScheduleStore persists events that are delayed beyond what SQS supports
+type ScheduleStore interface {
+	// Schedule stores an event for delivery at its DeliverAt time
+	Schedule(ctx context.Context, event *ScheduledEvent) error
+
+	// ClaimDue locks and returns up to limit events that are due at the given time
+	ClaimDue(ctx context.Context, now time.Time, limit int) ([]*ScheduledEvent, error)
+
+	// Delete removes delivered events from the store
+	Delete(ctx context.Context, eventIDs []string) error
+}
+
+// SQSSender defines the SQS operations used by the delayed publisher
+type SQSSender interface {
+	SendMessage(input *sqs.SendMessageInput) (*sqs.SendMessageOutput, error)
+}
+
+// DelayedPublisher implements ScheduledPublisher on top of an immediate Publisher.
+// Delays up to MaxSQSDelay are sent to an SQS delay queue using DelaySeconds, and longer
+// delays are written to a ScheduleStore. Without a delay queue, every delay goes to the ScheduleStore.
+// The worker forwards both to the immediate publisher when due.
+type DelayedPublisher struct {
+	Publisher
+	serializer    serializer.Serializer
+	sqsClient     SQSSender
+	delayQueueURL string
+	store         ScheduleStore
+	txManager     db.TransactionManager
+}
+
+// NewDelayedPublisher creates a new delayed publisher. An empty delayQueueURL schedules every delay in the store.
+func NewDelayedPublisher(
+	publisher Publisher,
+	serializer serializer.Serializer,
+	sqsClient SQSSender,
+	delayQueueURL string,
+	store ScheduleStore,
+	txManager db.TransactionManager,
+) *DelayedPublisher {
+	return &DelayedPublisher{
+		Publisher:     publisher,
+		serializer:    serializer,
+		sqsClient:     sqsClient,
+		delayQueueURL: delayQueueURL,
+		store:         store,
+		txManager:     txManager,
+	}
+}
+
+// PublishAt publishes an event for delivery at the given time
+func (p *DelayedPublisher) PublishAt(ctx context.Context, event events.Event, at time.Time) error {
+	return p.PublishAfter(ctx, event, time.Until(at))
+}
+
+// PublishAfter publishes an event for delivery once the delay has elapsed
+func (p *DelayedPublisher) PublishAfter(ctx context.Context, event events.Event, delay time.Duration) error {
+	if delay <= 0 {
+		return p.Publish(ctx, event)
+	}
+
+	data, err := p.serializer.Serialize(event)
+	if err != nil {
+		return fmt.Errorf("failed to serialize event (aggregate_id=%s, event_id=%s, event_type=%s): %w",
+			event.AggregateID(), event.EventID(), event.Type(), err)
+	}
+
+	if delay <= MaxSQSDelay && p.delayQueueURL != "" {
+		return p.sendToDelayQueue(event, data, events.PriorityOf(ctx, event), delay)
+	}
+
+	scheduled := &ScheduledEvent{
+		EventID:     event.EventID(),
+		EventType:   event.Type(),
+		AggregateID: event.AggregateID(),
+		Payload:     data,
+		Priority:    events.PriorityOf(ctx, event),
+		DeliverAt:   time.Now().Add(delay).UTC(),
+	}
+
+	logger.Info("scheduling event for later delivery", "event_id", scheduled.EventID, "event_type", scheduled.EventType, "deliver_at", scheduled.DeliverAt)
+	return p.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		return p.store.Schedule(txCtx, scheduled)
+	})
+}
+
+// sendToDelayQueue sends an event to the SQS delay queue with DelaySeconds set.
+// The envelope keeps the event's priority, so it is republished with it.
+func (p *DelayedPublisher) sendToDelayQueue(event events.Event, data []byte, priority string, delay time.Duration) error {
+	body, err := json.Marshal(Envelope{
+		EventID:     event.EventID(),
+		EventType:   event.Type(),
+		AggregateID: event.AggregateID(),
+		Payload:     data,
+		Priority:    priority,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to serialize delay queue envelope: %w", err)
+	}
+
+	delaySeconds := int64(math.Ceil(delay.Seconds()))
+
+	logger.Info("sending event to delay queue", "queue_url", p.delayQueueURL, "event_id", event.EventID(), "event_type", event.Type(), "delay_seconds", delaySeconds)
+	_, err = p.sqsClient.SendMessage(&sqs.SendMessageInput{
+		QueueUrl:     aws.String(p.delayQueueURL),
+		MessageBody:  aws.String(string(body)),
+		DelaySeconds: aws.Int64(delaySeconds),
+		MessageAttributes: map[string]*sqs.MessageAttributeValue{
+			"event_type": {
+				DataType:    aws.String("String"),
+				StringValue: aws.String(event.Type()),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send event to delay queue: %w", err)
+	}
+
+	return nil
+}
+
+// Make sure the publisher implements the ScheduledPublisher interface
+var _ ScheduledPublisher = &DelayedPublisher{}
@@ -0,0 +1,216 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+
+	userEvents "github.com/cgund98/go-postgres-api-template/internal/domain/user/events"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/db"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/serializer"
+)
+
+// mockPublisher is a mock implementation of Publisher
+type mockPublisher struct {
+	published []events.Event
+}
+
+func (m *mockPublisher) Publish(ctx context.Context, event events.Event) error {
+	return m.PublishBatch(ctx, []events.Event{event})
+}
+
+func (m *mockPublisher) PublishBatch(_ context.Context, eventList []events.Event) error {
+	m.published = append(m.published, eventList...)
+	return nil
+}
+
+// mockSQSSender is a mock implementation of SQSSender
+type mockSQSSender struct {
+	sendMessageFunc func(*sqs.SendMessageInput) (*sqs.SendMessageOutput, error)
+	inputs          []*sqs.SendMessageInput
+}
+
+func (m *mockSQSSender) SendMessage(input *sqs.SendMessageInput) (*sqs.SendMessageOutput, error) {
+	m.inputs = append(m.inputs, input)
+	if m.sendMessageFunc != nil {
+		return m.sendMessageFunc(input)
+	}
+	return &sqs.SendMessageOutput{}, nil
+}
+
+// mockScheduleStore is a mock implementation of ScheduleStore
+type mockScheduleStore struct {
+	scheduled []*ScheduledEvent
+}
+
+func (m *mockScheduleStore) Schedule(_ context.Context, event *ScheduledEvent) error {
+	m.scheduled = append(m.scheduled, event)
+	return nil
+}
+
+func (m *mockScheduleStore) ClaimDue(_ context.Context, _ time.Time, _ int) ([]*ScheduledEvent, error) {
+	return nil, nil
+}
+
+func (m *mockScheduleStore) Delete(_ context.Context, _ []string) error {
+	return nil
+}
+
+// mockTxManager runs the function without a real transaction
+type mockTxManager struct {
+	callCount int
+}
+
+func (m *mockTxManager) WithTransaction(ctx context.Context, fn func(ctx context.Context) error, _ ...db.TxOption) error {
+	m.callCount++
+	return fn(ctx)
+}
+
+func TestDelayedPublisher_PublishAfter(t *testing.T) {
+	tests := []struct {
+		name                 string
+		delay                time.Duration
+		delayQueueURL        *string
+		sendError            error
+		expectedPublished    int
+		expectedSent         int
+		expectedScheduled    int
+		expectedDelaySeconds int64
+		expectedError        bool
+	}{
+		{
+			name:              "publishes immediately without delay",
+			delay:             0,
+			expectedPublished: 1,
+		},
+		{
+			name:                 "sends short delays to the delay queue",
+			delay:                90 * time.Second,
+			expectedSent:         1,
+			expectedDelaySeconds: 90,
+		},
+		{
+			name:                 "rounds partial seconds up",
+			delay:                1500 * time.Millisecond,
+			expectedSent:         1,
+			expectedDelaySeconds: 2,
+		},
+		{
+			name:                 "sends delays at the SQS limit to the delay queue",
+			delay:                MaxSQSDelay,
+			expectedSent:         1,
+			expectedDelaySeconds: 900,
+		},
+		{
+			name:              "schedules delays beyond the SQS limit in the store",
+			delay:             24 * time.Hour,
+			expectedScheduled: 1,
+		},
+		{
+			name:              "schedules short delays in the store without a delay queue",
+			delay:             90 * time.Second,
+			delayQueueURL:     aws.String(""),
+			expectedScheduled: 1,
+		},
+		{
+			name:          "returns error when the delay queue send fails",
+			delay:         time.Minute,
+			sendError:     errors.New("SQS send failed"),
+			expectedSent:  1,
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub := &mockPublisher{}
+			sender := &mockSQSSender{
+				sendMessageFunc: func(_ *sqs.SendMessageInput) (*sqs.SendMessageOutput, error) {
+					return &sqs.SendMessageOutput{}, tt.sendError
+				},
+			}
+			store := &mockScheduleStore{}
+			txManager := &mockTxManager{}
+
+			delayQueueURL := "https://sqs.us-east-1.amazonaws.com/123456789/event-delay"
+			if tt.delayQueueURL != nil {
+				delayQueueURL = *tt.delayQueueURL
+			}
+			delayed := NewDelayedPublisher(pub, serializer.NewJSONSerializer(), sender, delayQueueURL, store, txManager)
+
+			event := userEvents.NewUserCreatedEvent("user-123", "test@example.com")
+			err := delayed.PublishAfter(events.WithPriority(context.Background(), "bulk"), event, tt.delay)
+
+			if tt.expectedError && err == nil {
+				t.Error("expected error but got nil")
+			}
+			if !tt.expectedError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if len(pub.published) != tt.expectedPublished {
+				t.Errorf("expected %d published events, got %d", tt.expectedPublished, len(pub.published))
+			}
+			if len(sender.inputs) != tt.expectedSent {
+				t.Errorf("expected %d delay queue messages, got %d", tt.expectedSent, len(sender.inputs))
+			}
+			if len(store.scheduled) != tt.expectedScheduled {
+				t.Errorf("expected %d scheduled events, got %d", tt.expectedScheduled, len(store.scheduled))
+			}
+
+			if tt.expectedSent > 0 && !tt.expectedError {
+				input := sender.inputs[0]
+				if *input.DelaySeconds != tt.expectedDelaySeconds {
+					t.Errorf("expected delay of %d seconds, got %d", tt.expectedDelaySeconds, *input.DelaySeconds)
+				}
+
+				var envelope Envelope
+				if err := json.Unmarshal([]byte(*input.MessageBody), &envelope); err != nil {
+					t.Fatalf("delay queue message is not a valid envelope: %v", err)
+				}
+				if envelope.EventID != event.EventID() || envelope.EventType != event.Type() || envelope.AggregateID != event.AggregateID() || envelope.Priority != "bulk" {
+					t.Errorf("envelope does not match event: %+v", envelope)
+				}
+			}
+
+			if tt.expectedScheduled > 0 {
+				scheduled := store.scheduled[0]
+				if scheduled.EventID != event.EventID() {
+					t.Errorf("expected scheduled event ID %s, got %s", event.EventID(), scheduled.EventID)
+				}
+				if scheduled.Priority != "bulk" {
+					t.Errorf("expected the bulk priority to be kept, got %q", scheduled.Priority)
+				}
+				if scheduled.DeliverAt.Before(time.Now().Add(tt.delay - time.Minute)) {
+					t.Errorf("scheduled delivery time %s is too early", scheduled.DeliverAt)
+				}
+				if txManager.callCount != 1 {
+					t.Errorf("expected scheduling to run in a transaction")
+				}
+			}
+		})
+	}
+}
+
+func TestDelayedPublisher_PublishAt(t *testing.T) {
+	store := &mockScheduleStore{}
+	delayed := NewDelayedPublisher(&mockPublisher{}, serializer.NewJSONSerializer(), &mockSQSSender{}, "", store, &mockTxManager{})
+
+	at := time.Now().Add(48 * time.Hour)
+	err := delayed.PublishAt(context.Background(), userEvents.NewUserDeletedEvent("user-123"), at)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(store.scheduled) != 1 {
+		t.Fatalf("expected 1 scheduled event, got %d", len(store.scheduled))
+	}
+	if diff := store.scheduled[0].DeliverAt.Sub(at); diff < -time.Second || diff > time.Second {
+		t.Errorf("expected delivery at %s, got %s", at, store.scheduled[0].DeliverAt)
+	}
+}
@@ -0,0 +1,136 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+)
+
+// ErrNoRoute is returned when an event type does not match any route
+var ErrNoRoute = errors.New("no route for event type")
+
+// Route sends events whose type matches Pattern to the topic TopicARN.
+// Patterns use path.Match syntax against the event type, e.g. "user.*" or "*".
+type Route struct {
+	Pattern  string
+	TopicARN string
+}
+
+// RouterOptions configures the routes of a router
+type RouterOptions struct {
+	// Routes are evaluated in order and the first match wins
+	Routes []Route
+
+	// DefaultTopicARN receives every event when no routes are set
+	DefaultTopicARN *string
+}
+
+// Router resolves the topic of an event type. Routes are evaluated in order and the first match wins.
+type Router struct {
+	routes []Route
+}
+
+// NewRouter creates a router, returning an error if a route has an invalid pattern or no topic
+func NewRouter(options RouterOptions) (*Router, error) {
+	routes := options.Routes
+	if len(routes) == 0 && options.DefaultTopicARN != nil {
+		routes = []Route{{Pattern: "*", TopicARN: *options.DefaultTopicARN}}
+	}
+
+	for _, route := range routes {
+		if route.TopicARN == "" {
+			return nil, fmt.Errorf("route %q is missing a topic ARN", route.Pattern)
+		}
+		if _, err := path.Match(route.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("route %q has an invalid pattern: %w", route.Pattern, err)
+		}
+	}
+
+	return &Router{routes: routes}, nil
+}
+
+// TopicARN returns the topic events of the given type are published to
+func (r *Router) TopicARN(eventType string) (string, error) {
+	for _, route := range r.routes {
+		// The pattern was validated in NewRouter, so Match cannot fail
+		if matched, _ := path.Match(route.Pattern, eventType); matched {
+			return route.TopicARN, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrNoRoute, eventType)
+}
+
+// TopicARNs returns every topic routed to, in route order without duplicates
+func (r *Router) TopicARNs() []string {
+	seen := map[string]bool{}
+	topicARNs := []string{}
+	for _, route := range r.routes {
+		if !seen[route.TopicARN] {
+			seen[route.TopicARN] = true
+			topicARNs = append(topicARNs, route.TopicARN)
+		}
+	}
+	return topicARNs
+}
+
+// RoutingPublisher implements Publisher by publishing each event to the topic its type is routed to
+type RoutingPublisher struct {
+	router     *Router
+	publishers map[string]Publisher
+}
+
+// NewRoutingPublisher creates a routing publisher.
+// newPublisher is called once per distinct topic to create the publisher for that topic.
+func NewRoutingPublisher(options RouterOptions, newPublisher func(topicARN string) Publisher) (*RoutingPublisher, error) {
+	router, err := NewRouter(options)
+	if err != nil {
+		return nil, err
+	}
+
+	publishers := map[string]Publisher{}
+	for _, topicARN := range router.TopicARNs() {
+		publishers[topicARN] = newPublisher(topicARN)
+	}
+
+	return &RoutingPublisher{
+		router:     router,
+		publishers: publishers,
+	}, nil
+}
+
+// Publish publishes an event to the topic its type is routed to
+func (p *RoutingPublisher) Publish(ctx context.Context, event events.Event) error {
+	return p.PublishBatch(ctx, []events.Event{event})
+}
+
+// PublishBatch groups the events by topic and publishes one batch per topic.
+// Every event is routed before anything is published, so an unroutable event fails the whole batch.
+// If publishing to one topic fails, batches already published to other topics are not rolled back.
+func (p *RoutingPublisher) PublishBatch(ctx context.Context, eventList []events.Event) error {
+	batches := map[string][]events.Event{}
+	topicARNs := []string{}
+
+	for _, event := range eventList {
+		topicARN, err := p.router.TopicARN(event.Type())
+		if err != nil {
+			return fmt.Errorf("failed to route event (aggregate_id=%s, event_id=%s): %w", event.AggregateID(), event.EventID(), err)
+		}
+		if _, ok := batches[topicARN]; !ok {
+			topicARNs = append(topicARNs, topicARN)
+		}
+		batches[topicARN] = append(batches[topicARN], event)
+	}
+
+	for _, topicARN := range topicARNs {
+		if err := p.publishers[topicARN].PublishBatch(ctx, batches[topicARN]); err != nil {
+			return fmt.Errorf("failed to publish to topic %s: %w", topicARN, err)
+		}
+	}
+	return nil
+}
+
+// Make sure the publisher implements the Publisher interface
+var _ Publisher = &RoutingPublisher{}
@@ -0,0 +1,127 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+
+	userEvents "github.com/cgund98/go-postgres-api-template/internal/domain/user/events"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+)
+
+func TestRoutingPublisher_PublishBatch(t *testing.T) {
+	routes := []Route{
+		{Pattern: "user.deleted", TopicARN: "audit-topic"},
+		{Pattern: "user.*", TopicARN: "user-topic"},
+	}
+
+	tests := []struct {
+		name          string
+		events        []events.Event
+		expected      map[string]int
+		expectedError error
+	}{
+		{
+			name: "routes each event to the first matching topic",
+			events: []events.Event{
+				userEvents.NewUserCreatedEvent("user-123", "test@example.com"),
+				userEvents.NewUserDeletedEvent("user-123"),
+				userEvents.NewUserUpdatedEvent("user-123", map[string]any{"name": "Test"}),
+			},
+			expected: map[string]int{"user-topic": 2, "audit-topic": 1},
+		},
+		{
+			name: "fails the whole batch when an event has no route",
+			events: []events.Event{
+				userEvents.NewUserCreatedEvent("user-123", "test@example.com"),
+				&userEvents.UserCreatedEvent{EventMetadata: events.NewBaseEvent("order.created")},
+			},
+			expected:      map[string]int{},
+			expectedError: ErrNoRoute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publishers := map[string]*mockPublisher{}
+			routing, err := NewRoutingPublisher(RouterOptions{Routes: routes}, func(topicARN string) Publisher {
+				publishers[topicARN] = &mockPublisher{}
+				return publishers[topicARN]
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			err = routing.PublishBatch(context.Background(), tt.events)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Errorf("expected error %v, got %v", tt.expectedError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(publishers) != 2 {
+				t.Errorf("expected one publisher per topic, got %d", len(publishers))
+			}
+			for topicARN, publisher := range publishers {
+				if len(publisher.published) != tt.expected[topicARN] {
+					t.Errorf("expected %d events published to %s, got %d", tt.expected[topicARN], topicARN, len(publisher.published))
+				}
+			}
+		})
+	}
+}
+
+func TestNewRouter(t *testing.T) {
+	tests := []struct {
+		name              string
+		options           RouterOptions
+		expectedTopicARNs []string
+		expectedError     bool
+	}{
+		{
+			name:              "accepts valid routes",
+			options:           RouterOptions{Routes: []Route{{Pattern: "user.*", TopicARN: "user-topic"}}, DefaultTopicARN: aws.String("default-topic")},
+			expectedTopicARNs: []string{"user-topic"},
+		},
+		{
+			name:              "routes every event to the default topic without routes",
+			options:           RouterOptions{DefaultTopicARN: aws.String("default-topic")},
+			expectedTopicARNs: []string{"default-topic"},
+		},
+		{
+			name:          "rejects a default topic without an ARN",
+			options:       RouterOptions{DefaultTopicARN: aws.String("")},
+			expectedError: true,
+		},
+		{
+			name:          "rejects routes without a topic",
+			options:       RouterOptions{Routes: []Route{{Pattern: "user.*"}}},
+			expectedError: true,
+		},
+		{
+			name:          "rejects invalid patterns",
+			options:       RouterOptions{Routes: []Route{{Pattern: "user.[", TopicARN: "user-topic"}}},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, err := NewRouter(tt.options)
+			if tt.expectedError && err == nil {
+				t.Error("expected error but got nil")
+			}
+			if !tt.expectedError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err == nil && !slices.Equal(router.TopicARNs(), tt.expectedTopicARNs) {
+				t.Errorf("expected topics %v, got %v", tt.expectedTopicARNs, router.TopicARNs())
+			}
+		})
+	}
+}
@@ -38,14 +38,13 @@ func (p *SNSPublisher) Publish(ctx context.Context, event events.Event) error {
 }
 
 // PublishBatch publishes a batch of events to SNS
-func (p *SNSPublisher) PublishBatch(_ context.Context, events []events.Event) error {
-
-	batch := make([]*sns.PublishBatchRequestEntry, len(events))
+func (p *SNSPublisher) PublishBatch(ctx context.Context, batchEvents []events.Event) error {
+	batch := make([]*sns.PublishBatchRequestEntry, len(batchEvents))
 
 	// Track unique event types in the batch
 	eventTypes := map[string]bool{}
 
-	for i, event := range events {
+	for i, event := range batchEvents {
 		data, err := p.serializer.Serialize(event)
 		if err != nil {
 			return fmt.Errorf("failed to serialize event (aggregate_id=%s, event_id=%s, event_type=%s): %w",
@@ -62,6 +61,10 @@ func (p *SNSPublisher) PublishBatch(_ context.Context, events []events.Event) er
 					DataType:    aws.String("String"),
 					StringValue: aws.String(event.Type()),
 				},
+				events.PriorityAttribute: {
+					DataType:    aws.String("String"),
+					StringValue: aws.String(events.PriorityOf(ctx, event)),
+				},
 			},
 		}
 	}
@@ -0,0 +1,45 @@
+package events
+
+import "encoding/json"
+
+// RawEvent is an event that has already been serialized.
+// It is used to republish events that were stored or queued for later delivery
+// without decoding them back into their Go type.
+type RawEvent struct {
+	ID        string
+	EventType string
+	Aggregate string
+	Payload   json.RawMessage
+
+	// EventPriority is the priority the event was first published with, kept when it is republished
+	EventPriority string
+}
+
+// Type implements Event interface
+func (e *RawEvent) Type() string {
+	return e.EventType
+}
+
+// EventID implements Event interface
+func (e *RawEvent) EventID() string {
+	return e.ID
+}
+
+// AggregateID implements Event interface
+func (e *RawEvent) AggregateID() string {
+	return e.Aggregate
+}
+
+// Priority implements Prioritized interface
+func (e *RawEvent) Priority() string {
+	return e.EventPriority
+}
+
+// MarshalJSON returns the original payload so serializers emit the event unchanged
+func (e *RawEvent) MarshalJSON() ([]byte, error) {
+	return e.Payload, nil
+}
+
+// Make sure the event implements the Event interface
+var _ Event = &RawEvent{}
+var _ Prioritized = &RawEvent{}
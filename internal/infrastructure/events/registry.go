@@ -0,0 +1,165 @@
+package events
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+var (
+	// ErrEventTypeNotRegistered is returned when looking up an event type that has not been registered
+	ErrEventTypeNotRegistered = errors.New("event type not registered")
+
+	// ErrDuplicateEventType is returned when registering an event type more than once
+	ErrDuplicateEventType = errors.New("event type already registered")
+)
+
+// Registration describes an event type known to the application
+type Registration struct {
+	// Type is the event type identifier (e.g., "user.created")
+	Type string
+
+	// New returns a pointer to a new, empty instance of the event that can be decoded into
+	New func() Event
+
+	// Version is the schema version of the event payload
+	Version int
+
+	// Description explains when the event is emitted
+	Description string
+
+	// Metadata holds additional information for tooling (e.g., the owning domain)
+	Metadata map[string]string
+}
+
+// GoType returns the Go type of the event, dereferencing pointer types
+func (r Registration) GoType() reflect.Type {
+	t := reflect.TypeOf(r.New())
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
+
+// Registry holds the event types registered by each domain
+type Registry struct {
+	registrations registry[string, Registration]
+}
+
+// NewRegistry creates a new, empty event registry
+func NewRegistry() *Registry {
+	return &Registry{
+		registrations: newRegistry[string, Registration](ErrDuplicateEventType, ErrEventTypeNotRegistered),
+	}
+}
+
+// Register adds event types to the registry.
+// Returns an error if a registration is incomplete or its type is already registered.
+func (r *Registry) Register(registrations ...Registration) error {
+	for _, registration := range registrations {
+		if registration.Type == "" {
+			return errors.New("event registration is missing a type")
+		}
+		if registration.New == nil {
+			return fmt.Errorf("event registration %s is missing a constructor", registration.Type)
+		}
+		if reflect.TypeOf(registration.New()).Kind() != reflect.Ptr {
+			return fmt.Errorf("event registration %s constructor must return a pointer", registration.Type)
+		}
+		if err := r.registrations.add(registration.Type, registration); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Lookup returns the registration for an event type
+func (r *Registry) Lookup(eventType string) (Registration, error) {
+	return r.registrations.lookup(eventType)
+}
+
+// Validate checks that all the given event types are registered
+func (r *Registry) Validate(eventTypes ...string) error {
+	for _, eventType := range eventTypes {
+		if _, err := r.Lookup(eventType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Registrations returns all registered event types, sorted by type
+func (r *Registry) Registrations() []Registration {
+	return r.registrations.values()
+}
+
+/** -------------------------------- Helper -------------------------------- */
+
+// registry holds values by key, refusing to register a key twice. The event, handler and filter
+// registries are built on it. It is safe for concurrent use.
+type registry[K cmp.Ordered, V any] struct {
+	mu      sync.RWMutex
+	entries map[K]V
+
+	// errDuplicate and errNotRegistered are wrapped with the key by add and lookup
+	errDuplicate     error
+	errNotRegistered error
+}
+
+func newRegistry[K cmp.Ordered, V any](errDuplicate, errNotRegistered error) registry[K, V] {
+	return registry[K, V]{
+		entries:          map[K]V{},
+		errDuplicate:     errDuplicate,
+		errNotRegistered: errNotRegistered,
+	}
+}
+
+// add registers the value under the key, unless the key is already registered
+func (r *registry[K, V]) add(key K, value V) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.entries[key]; ok {
+		return fmt.Errorf("%w: %v", r.errDuplicate, key)
+	}
+	r.entries[key] = value
+	return nil
+}
+
+// lookup returns the value registered under the key
+func (r *registry[K, V]) lookup(key K) (V, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	value, ok := r.entries[key]
+	if !ok {
+		var zero V
+		return zero, fmt.Errorf("%w: %v", r.errNotRegistered, key)
+	}
+	return value, nil
+}
+
+// keys returns the registered keys, sorted
+func (r *registry[K, V]) keys() []K {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Sorted(maps.Keys(r.entries))
+}
+
+// values returns the registered values, sorted by key
+func (r *registry[K, V]) values() []V {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	values := make([]V, 0, len(r.entries))
+	for _, key := range slices.Sorted(maps.Keys(r.entries)) {
+		values = append(values, r.entries[key])
+	}
+	return values
+}
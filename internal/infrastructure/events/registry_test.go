@@ -0,0 +1,202 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// testEvent is a minimal event used to exercise the registry
+type testEvent struct {
+	EventMetadata
+	ID string `json:"id"`
+}
+
+func (e *testEvent) Type() string        { return e.EventType }
+func (e *testEvent) EventID() string     { return e.EventMetadata.EventID }
+func (e *testEvent) AggregateID() string { return e.ID }
+
+// valueEvent implements Event with a value receiver so it can be returned as a non-pointer
+type valueEvent struct{}
+
+func (e valueEvent) Type() string        { return "test.value" }
+func (e valueEvent) EventID() string     { return "" }
+func (e valueEvent) AggregateID() string { return "" }
+
+func newTestRegistration(eventType string) Registration {
+	return Registration{
+		Type:    eventType,
+		New:     func() Event { return &testEvent{} },
+		Version: 1,
+	}
+}
+
+func TestRegistry_Register(t *testing.T) {
+	tests := []struct {
+		name          string
+		registrations []Registration
+		expectedErr   error
+		expectedError bool
+	}{
+		{
+			name:          "registers event types",
+			registrations: []Registration{newTestRegistration("test.created"), newTestRegistration("test.deleted")},
+		},
+		{
+			name:          "rejects duplicate event types",
+			registrations: []Registration{newTestRegistration("test.created"), newTestRegistration("test.created")},
+			expectedErr:   ErrDuplicateEventType,
+			expectedError: true,
+		},
+		{
+			name:          "rejects registration without a type",
+			registrations: []Registration{newTestRegistration("")},
+			expectedError: true,
+		},
+		{
+			name:          "rejects registration without a constructor",
+			registrations: []Registration{{Type: "test.created"}},
+			expectedError: true,
+		},
+		{
+			name:          "rejects constructor that does not return a pointer",
+			registrations: []Registration{{Type: "test.value", New: func() Event { return valueEvent{} }}},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			err := registry.Register(tt.registrations...)
+
+			if tt.expectedError {
+				if err == nil {
+					t.Fatal("expected error but got nil")
+				}
+				if tt.expectedErr != nil && !errors.Is(err, tt.expectedErr) {
+					t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRegistry_Lookup(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(newTestRegistration("test.created")); err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+
+	registration, err := registry.Lookup("test.created")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if registration.GoType() != reflect.TypeOf(testEvent{}) {
+		t.Errorf("unexpected Go type: %s", registration.GoType())
+	}
+
+	if _, err := registry.Lookup("test.unknown"); !errors.Is(err, ErrEventTypeNotRegistered) {
+		t.Errorf("expected ErrEventTypeNotRegistered, got %v", err)
+	}
+
+	if err := registry.Validate("test.created"); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+	if err := registry.Validate("test.created", "test.unknown"); !errors.Is(err, ErrEventTypeNotRegistered) {
+		t.Errorf("expected ErrEventTypeNotRegistered, got %v", err)
+	}
+}
+
+func TestRegistry_Registrations(t *testing.T) {
+	registry := NewRegistry()
+	err := registry.Register(
+		newTestRegistration("test.updated"),
+		newTestRegistration("test.created"),
+		newTestRegistration("test.deleted"),
+	)
+	if err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+
+	registrations := registry.Registrations()
+	expected := []string{"test.created", "test.deleted", "test.updated"}
+	if len(registrations) != len(expected) {
+		t.Fatalf("expected %d registrations, got %d", len(expected), len(registrations))
+	}
+	for i, eventType := range expected {
+		if registrations[i].Type != eventType {
+			t.Errorf("expected registration %d to be %s, got %s", i, eventType, registrations[i].Type)
+		}
+	}
+}
+
+func TestRegistryHelper(t *testing.T) {
+	errDuplicate := errors.New("duplicate")
+	errNotRegistered := errors.New("not registered")
+
+	tests := []struct {
+		name          string
+		keys          []int
+		lookup        int
+		expectedError error
+		expectedKeys  []int
+	}{
+		{
+			name:         "sorts keys and values by key",
+			keys:         []int{3, 1, 2},
+			lookup:       2,
+			expectedKeys: []int{1, 2, 3},
+		},
+		{
+			name:          "rejects a duplicate key",
+			keys:          []int{1, 1},
+			lookup:        1,
+			expectedError: errDuplicate,
+			expectedKeys:  []int{1},
+		},
+		{
+			name:          "reports a key that isn't registered",
+			keys:          []int{1},
+			lookup:        2,
+			expectedError: errNotRegistered,
+			expectedKeys:  []int{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRegistry[int, string](errDuplicate, errNotRegistered)
+
+			var err error
+			for _, key := range tt.keys {
+				if addErr := r.add(key, fmt.Sprintf("value-%d", key)); addErr != nil {
+					err = addErr
+				}
+			}
+			if value, lookupErr := r.lookup(tt.lookup); lookupErr != nil {
+				err = lookupErr
+			} else if value != fmt.Sprintf("value-%d", tt.lookup) {
+				t.Errorf("expected value-%d, got %s", tt.lookup, value)
+			}
+
+			if !errors.Is(err, tt.expectedError) {
+				t.Errorf("expected error %v, got %v", tt.expectedError, err)
+			}
+			if actual := r.keys(); !reflect.DeepEqual(actual, tt.expectedKeys) {
+				t.Errorf("expected keys %v, got %v", tt.expectedKeys, actual)
+			}
+			values := r.values()
+			for i, key := range tt.expectedKeys {
+				if values[i] != fmt.Sprintf("value-%d", key) {
+					t.Errorf("expected values in key order, got %v", values)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package events
+
+import "errors"
+
+// nonRetryableError marks an error as permanent
+type nonRetryableError struct {
+	err error
+}
+
+func (e *nonRetryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *nonRetryableError) Unwrap() error {
+	return e.err
+}
+
+// NonRetryable marks err as permanent: redelivering the message can never succeed, so consumers
+// dead-letter it straight away instead of retrying it until the redrive policy gives up
+func NonRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &nonRetryableError{err: err}
+}
+
+// IsNonRetryable reports whether err, or any error it wraps, was marked with NonRetryable
+func IsNonRetryable(err error) bool {
+	var target *nonRetryableError
+	return errors.As(err, &target)
+}
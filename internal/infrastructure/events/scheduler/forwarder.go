@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/deserializer"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/publisher"
+)
+
+// EnvelopeDeserializer decodes delay queue messages into raw events
+type EnvelopeDeserializer struct{}
+
+// NewEnvelopeDeserializer creates a new envelope deserializer
+func NewEnvelopeDeserializer() EnvelopeDeserializer {
+	return EnvelopeDeserializer{}
+}
+
+// Deserialize decodes a publisher.Envelope into a raw event
+func (d EnvelopeDeserializer) Deserialize(data []byte) (*events.RawEvent, error) {
+	var envelope publisher.Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, err
+	}
+	if envelope.EventType == "" || len(envelope.Payload) == 0 {
+		return nil, fmt.Errorf("invalid delay queue envelope (event_id=%s)", envelope.EventID)
+	}
+
+	return &events.RawEvent{
+		ID:        envelope.EventID,
+		EventType: envelope.EventType,
+		Aggregate: envelope.AggregateID,
+		Payload:   envelope.Payload,
+
+		EventPriority: envelope.Priority,
+	}, nil
+}
+
+// ForwardHandler publishes events from the delay queue once SQS makes them visible
+type ForwardHandler struct {
+	publisher publisher.Publisher
+}
+
+// NewForwardHandler creates a new forward handler
+func NewForwardHandler(pub publisher.Publisher) *ForwardHandler {
+	return &ForwardHandler{publisher: pub}
+}
+
+// Handle republishes the delayed event
+func (h *ForwardHandler) Handle(ctx context.Context, event *events.RawEvent) error {
+	return h.publisher.Publish(ctx, event)
+}
+
+// Make sure the deserializer and handler implement their interfaces
+var _ deserializer.Deserializer[*events.RawEvent] = EnvelopeDeserializer{}
+var _ events.Handler[*events.RawEvent] = &ForwardHandler{}
@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/db"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/db/postgres"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/publisher"
+)
+
+// scheduleConstraints names the fields protected by the scheduled_events table's constraints
+var scheduleConstraints = postgres.Constraints{
+	"scheduled_events_pkey": "event_id",
+}
+
+// PostgresStore implements publisher.ScheduleStore using the scheduled_events table.
+// Like repositories, it extracts the transaction from context.Context internally.
+type PostgresStore struct {
+}
+
+// NewPostgresStore creates a new PostgreSQL schedule store
+func NewPostgresStore() *PostgresStore {
+	return &PostgresStore{}
+}
+
+// Schedule stores an event for delivery at its DeliverAt time
+func (s *PostgresStore) Schedule(ctx context.Context, event *publisher.ScheduledEvent) error {
+	tx := postgres.GetTXFromContext(ctx)
+	if tx == nil {
+		return db.ErrNoDBContext
+	}
+
+	query := `
+		INSERT INTO scheduled_events (event_id, event_type, aggregate_id, payload, priority, deliver_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (event_id) DO NOTHING
+	`
+
+	_, err := tx.Exec(ctx, query,
+		event.EventID,
+		event.EventType,
+		event.AggregateID,
+		event.Payload,
+		event.Priority,
+		event.DeliverAt,
+	)
+	return postgres.TranslateError(err, scheduleConstraints)
+}
+
+// ClaimDue locks and returns up to limit events that are due at the given time.
+// Rows are locked with SKIP LOCKED so multiple workers can promote concurrently.
+func (s *PostgresStore) ClaimDue(ctx context.Context, now time.Time, limit int) ([]*publisher.ScheduledEvent, error) {
+	tx := postgres.GetTXFromContext(ctx)
+	if tx == nil {
+		return nil, db.ErrNoDBContext
+	}
+
+	query := `
+		SELECT event_id, event_type, aggregate_id, payload, priority, deliver_at
+		FROM scheduled_events
+		WHERE deliver_at <= $1
+		ORDER BY deliver_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`
+
+	rows, err := tx.Query(ctx, query, now.UTC(), limit)
+	if err != nil {
+		return nil, postgres.TranslateError(err, scheduleConstraints)
+	}
+	defer rows.Close()
+
+	var scheduled []*publisher.ScheduledEvent
+	for rows.Next() {
+		e := &publisher.ScheduledEvent{}
+		err := rows.Scan(
+			&e.EventID,
+			&e.EventType,
+			&e.AggregateID,
+			&e.Payload,
+			&e.Priority,
+			&e.DeliverAt,
+		)
+		if err != nil {
+			return nil, postgres.TranslateError(err, scheduleConstraints)
+		}
+		scheduled = append(scheduled, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, postgres.TranslateError(err, scheduleConstraints)
+	}
+
+	return scheduled, nil
+}
+
+// Delete removes delivered events from the store
+func (s *PostgresStore) Delete(ctx context.Context, eventIDs []string) error {
+	tx := postgres.GetTXFromContext(ctx)
+	if tx == nil {
+		return db.ErrNoDBContext
+	}
+
+	query := `DELETE FROM scheduled_events WHERE event_id = ANY($1)`
+	_, err := tx.Exec(ctx, query, eventIDs)
+	return postgres.TranslateError(err, scheduleConstraints)
+}
+
+// Ensure PostgresStore implements publisher.ScheduleStore
+var _ publisher.ScheduleStore = (*PostgresStore)(nil)
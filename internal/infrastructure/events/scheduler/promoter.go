@@ -0,0 +1,127 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/db"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/publisher"
+	"github.com/cgund98/go-postgres-api-template/internal/observability"
+)
+
+var logger = observability.Logger
+
+const (
+	defaultPollInterval = 5 * time.Second
+
+	// maxPublishBatchSize is the maximum number of entries SNS accepts in a single PublishBatch call
+	maxPublishBatchSize = 10
+)
+
+type PromoterOptions struct {
+	PollInterval *time.Duration
+}
+
+// Promoter moves due events from the schedule store to the immediate publisher
+type Promoter struct {
+	store        publisher.ScheduleStore
+	publisher    publisher.Publisher
+	txManager    db.TransactionManager
+	pollInterval time.Duration
+}
+
+// NewPromoter creates a new promoter
+func NewPromoter(store publisher.ScheduleStore, pub publisher.Publisher, txManager db.TransactionManager, options PromoterOptions) *Promoter {
+	var pollInterval = defaultPollInterval
+
+	if options.PollInterval != nil {
+		pollInterval = *options.PollInterval
+	}
+
+	return &Promoter{
+		store:        store,
+		publisher:    pub,
+		txManager:    txManager,
+		pollInterval: pollInterval,
+	}
+}
+
+// PromoteDue publishes one batch of due events and removes them from the store.
+// Claiming, publishing and deleting happen in one transaction, so a failed publish leaves the events scheduled.
+// The transaction isn't retried, since the events were already published; the next poll picks them up again.
+// Returns the number of events promoted.
+func (p *Promoter) PromoteDue(ctx context.Context) (int, error) {
+	var promoted int
+
+	err := p.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+		due, err := p.store.ClaimDue(txCtx, time.Now(), maxPublishBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(due) == 0 {
+			return nil
+		}
+
+		batch := make([]events.Event, len(due))
+		eventIDs := make([]string, len(due))
+		for i, scheduled := range due {
+			batch[i] = &events.RawEvent{
+				ID:        scheduled.EventID,
+				EventType: scheduled.EventType,
+				Aggregate: scheduled.AggregateID,
+				Payload:   scheduled.Payload,
+
+				EventPriority: scheduled.Priority,
+			}
+			eventIDs[i] = scheduled.EventID
+		}
+
+		if err := p.publisher.PublishBatch(ctx, batch); err != nil {
+			return err
+		}
+
+		if err := p.store.Delete(txCtx, eventIDs); err != nil {
+			return err
+		}
+
+		promoted = len(due)
+		return nil
+	}, db.NonRetryable())
+
+	return promoted, err
+}
+
+// Start starts promoting due events. This will begin in a new goroutine and return immediately.
+func (p *Promoter) Start(ctx context.Context) {
+	go func() {
+		logger.Info("starting scheduled event promoter", "poll_interval", p.pollInterval)
+		ticker := time.NewTicker(p.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				logger.Info("scheduled event promoter context canceled, stopping")
+				return
+			case <-ticker.C:
+				p.promoteAllDue(ctx)
+			}
+		}
+	}()
+}
+
+// promoteAllDue promotes batches until no due events remain
+func (p *Promoter) promoteAllDue(ctx context.Context) {
+	for {
+		promoted, err := p.PromoteDue(ctx)
+		if err != nil {
+			logger.Error("failed to promote scheduled events", "error", err)
+			return
+		}
+		if promoted == 0 {
+			return
+		}
+		logger.Info("promoted scheduled events", "count", promoted)
+	}
+}
@@ -0,0 +1,170 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/db"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/publisher"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/serializer"
+)
+
+// mockPublisher is a mock implementation of publisher.Publisher
+type mockPublisher struct {
+	publishErr error
+	published  []events.Event
+}
+
+func (m *mockPublisher) Publish(ctx context.Context, event events.Event) error {
+	return m.PublishBatch(ctx, []events.Event{event})
+}
+
+func (m *mockPublisher) PublishBatch(_ context.Context, eventList []events.Event) error {
+	if m.publishErr != nil {
+		return m.publishErr
+	}
+	m.published = append(m.published, eventList...)
+	return nil
+}
+
+// mockScheduleStore is an in-memory implementation of publisher.ScheduleStore
+type mockScheduleStore struct {
+	events  []*publisher.ScheduledEvent
+	deleted []string
+}
+
+func (m *mockScheduleStore) Schedule(_ context.Context, event *publisher.ScheduledEvent) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *mockScheduleStore) ClaimDue(_ context.Context, now time.Time, limit int) ([]*publisher.ScheduledEvent, error) {
+	var due []*publisher.ScheduledEvent
+	for _, e := range m.events {
+		if !e.DeliverAt.After(now) && len(due) < limit {
+			due = append(due, e)
+		}
+	}
+	return due, nil
+}
+
+func (m *mockScheduleStore) Delete(_ context.Context, eventIDs []string) error {
+	m.deleted = append(m.deleted, eventIDs...)
+	return nil
+}
+
+// mockTxManager runs the function without a real transaction
+type mockTxManager struct{}
+
+func (m *mockTxManager) WithTransaction(ctx context.Context, fn func(ctx context.Context) error, _ ...db.TxOption) error {
+	return fn(ctx)
+}
+
+func TestPromoter_PromoteDue(t *testing.T) {
+	tests := []struct {
+		name             string
+		deliverAt        []time.Duration
+		publishErr       error
+		expectedPromoted int
+		expectedDeleted  int
+		expectedError    bool
+	}{
+		{
+			name:             "promotes due events",
+			deliverAt:        []time.Duration{-time.Hour, -time.Minute},
+			expectedPromoted: 2,
+			expectedDeleted:  2,
+		},
+		{
+			name:             "skips events that are not due",
+			deliverAt:        []time.Duration{-time.Minute, time.Hour},
+			expectedPromoted: 1,
+			expectedDeleted:  1,
+		},
+		{
+			name:             "limits batches to the SNS batch size",
+			deliverAt:        make([]time.Duration, 12),
+			expectedPromoted: 10,
+			expectedDeleted:  10,
+		},
+		{
+			name:          "keeps events scheduled when publishing fails",
+			deliverAt:     []time.Duration{-time.Minute},
+			publishErr:    errors.New("SNS publish failed"),
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &mockScheduleStore{}
+			for i, offset := range tt.deliverAt {
+				store.events = append(store.events, &publisher.ScheduledEvent{
+					EventID:     string(rune('a' + i)),
+					EventType:   "user.created",
+					AggregateID: "user-123",
+					Payload:     []byte(`{"event_type":"user.created"}`),
+					DeliverAt:   time.Now().Add(offset),
+				})
+			}
+			pub := &mockPublisher{publishErr: tt.publishErr}
+
+			promoter := NewPromoter(store, pub, &mockTxManager{}, PromoterOptions{})
+			promoted, err := promoter.PromoteDue(context.Background())
+
+			if tt.expectedError && err == nil {
+				t.Error("expected error but got nil")
+			}
+			if !tt.expectedError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if promoted != tt.expectedPromoted {
+				t.Errorf("expected %d promoted events, got %d", tt.expectedPromoted, promoted)
+			}
+			if len(pub.published) != tt.expectedPromoted {
+				t.Errorf("expected %d published events, got %d", tt.expectedPromoted, len(pub.published))
+			}
+			if len(store.deleted) != tt.expectedDeleted {
+				t.Errorf("expected %d deleted events, got %d", tt.expectedDeleted, len(store.deleted))
+			}
+		})
+	}
+}
+
+func TestEnvelopeDeserializer_Deserialize(t *testing.T) {
+	payload := `{"event_id":"event-1","event_type":"user.created","user_id":"user-123"}`
+	body, err := json.Marshal(publisher.Envelope{
+		EventID:     "event-1",
+		EventType:   "user.created",
+		AggregateID: "user-123",
+		Payload:     json.RawMessage(payload),
+	})
+	if err != nil {
+		t.Fatalf("failed to build envelope: %v", err)
+	}
+
+	event, err := NewEnvelopeDeserializer().Deserialize(body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if event.EventID() != "event-1" || event.Type() != "user.created" || event.AggregateID() != "user-123" {
+		t.Errorf("unexpected event metadata: %+v", event)
+	}
+
+	// The raw event must serialize back to the original payload
+	data, err := serializer.NewJSONSerializer().Serialize(event)
+	if err != nil {
+		t.Fatalf("failed to serialize raw event: %v", err)
+	}
+	if string(data) != payload {
+		t.Errorf("expected payload %s, got %s", payload, string(data))
+	}
+
+	if _, err := NewEnvelopeDeserializer().Deserialize([]byte(`{"event_id":"event-1"}`)); err == nil {
+		t.Error("expected error for envelope without event type")
+	}
+}
@@ -0,0 +1,107 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+)
+
+const schemasPrefix = "#/components/schemas/"
+
+// ValidationError is returned when an event payload does not match the schema of its event type
+type ValidationError struct {
+	EventType string
+	Errors    []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("event %s failed schema validation: %s", e.EventType, strings.Join(e.Errors, "; "))
+}
+
+// ValidatorOptions configures how strictly payloads are validated
+type ValidatorOptions struct {
+	// AllowUnknownFields accepts payloads with fields their schema doesn't declare. Set it when consuming,
+	// so a producer that adds a field during a rolling deploy doesn't dead-letter every message on the
+	// consumers that don't know it yet. Publishing keeps rejecting them, so producers stay in line with
+	// the schemas they document.
+	AllowUnknownFields bool
+}
+
+// Validator checks event payloads against JSON Schemas generated from the registered event structs.
+// Event types can refine their generated schema by implementing huma.SchemaTransformer.
+// It is safe for concurrent use once created.
+type Validator struct {
+	schemas huma.Registry
+	byType  map[string]*huma.Schema
+}
+
+// NewValidator generates a schema for every event type in the registry
+func NewValidator(registry *events.Registry, options ValidatorOptions) *Validator {
+	schemas := huma.NewMapRegistry(schemasPrefix, huma.DefaultSchemaNamer)
+
+	byType := map[string]*huma.Schema{}
+	for _, registration := range registry.Registrations() {
+		byType[registration.Type] = schemas.Schema(registration.GoType(), true, registration.GoType().Name())
+	}
+
+	if options.AllowUnknownFields {
+		for _, schema := range schemas.Map() {
+			allowAdditionalProperties(schema)
+		}
+	}
+
+	return &Validator{
+		schemas: schemas,
+		byType:  byType,
+	}
+}
+
+// allowAdditionalProperties lifts additionalProperties: false from an object schema and the schemas nested
+// in it. Generated struct schemas and the ones written by huma.SchemaTransformer both set it.
+func allowAdditionalProperties(schema *huma.Schema) {
+	if schema == nil {
+		return
+	}
+	if additional, ok := schema.AdditionalProperties.(bool); ok && !additional {
+		schema.AdditionalProperties = true
+	}
+	for _, property := range schema.Properties {
+		allowAdditionalProperties(property)
+	}
+	allowAdditionalProperties(schema.Items)
+	for _, nested := range slices.Concat(schema.OneOf, schema.AnyOf, schema.AllOf) {
+		allowAdditionalProperties(nested)
+	}
+}
+
+// Validate checks the JSON payload against the schema of the event type.
+// Validation failures are returned as a *ValidationError marked with events.NonRetryable,
+// since redelivering the same payload can never succeed.
+func (v *Validator) Validate(eventType string, data []byte) error {
+	schema, ok := v.byType[eventType]
+	if !ok {
+		return events.NonRetryable(fmt.Errorf("%w: %s", events.ErrEventTypeNotRegistered, eventType))
+	}
+
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return events.NonRetryable(&ValidationError{EventType: eventType, Errors: []string{err.Error()}})
+	}
+
+	result := &huma.ValidateResult{}
+	huma.Validate(v.schemas, schema, huma.NewPathBuffer([]byte{}, 0), huma.ModeWriteToServer, value, result)
+	if len(result.Errors) == 0 {
+		return nil
+	}
+
+	validationErr := &ValidationError{EventType: eventType}
+	for _, err := range result.Errors {
+		validationErr.Errors = append(validationErr.Errors, err.Error())
+	}
+	return events.NonRetryable(validationErr)
+}
@@ -0,0 +1,150 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	userEvents "github.com/cgund98/go-postgres-api-template/internal/domain/user/events"
+	"github.com/cgund98/go-postgres-api-template/internal/domain/user/model"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+)
+
+func TestValidator_Validate(t *testing.T) {
+	registry := events.NewRegistry()
+	if err := userEvents.RegisterEvents(registry); err != nil {
+		t.Fatalf("failed to register events: %v", err)
+	}
+
+	snapshots, err := userEvents.NewSnapshotPolicy(true, map[string]string{"email": "hash"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	createdWithSnapshot := userEvents.NewUserCreatedEvent("user-123", "test@example.com")
+	createdWithSnapshot.After = snapshots.Snapshot(&model.User{ID: "user-123", Email: "test@example.com"})
+	createdWithSnapshot.SetSequence(1)
+	snapshots.Redact(createdWithSnapshot)
+
+	redactedUpdate := userEvents.NewUserUpdatedEvent("user-123", map[string]any{
+		"email":      map[string]any{"old": "old@example.com", "new": "new@example.com"},
+		"first_name": map[string]any{"old": "Old", "new": "New"},
+	})
+	snapshots.Redact(redactedUpdate)
+
+	tests := []struct {
+		name              string
+		options           ValidatorOptions
+		eventType         string
+		payload           any
+		expectedError     bool
+		expectedFieldErrs bool
+	}{
+		{
+			name:      "accepts a valid event",
+			eventType: userEvents.EventTypeUserCreated,
+			payload:   userEvents.NewUserCreatedEvent("user-123", "test@example.com"),
+		},
+		{
+			name:      "accepts optional sequence, snapshot and redaction fields",
+			eventType: userEvents.EventTypeUserCreated,
+			payload:   createdWithSnapshot,
+		},
+		{
+			name:      "accepts well formed changes",
+			eventType: userEvents.EventTypeUserUpdated,
+			payload: userEvents.NewUserUpdatedEvent("user-123", map[string]any{
+				"email": map[string]any{"old": "old@example.com", "new": "new@example.com"},
+			}),
+		},
+		{
+			name:      "accepts redacted changes",
+			eventType: userEvents.EventTypeUserUpdated,
+			payload:   redactedUpdate,
+		},
+		{
+			name:              "rejects changes with a bad shape",
+			eventType:         userEvents.EventTypeUserUpdated,
+			payload:           userEvents.NewUserUpdatedEvent("user-123", map[string]any{"email": "new@example.com"}),
+			expectedError:     true,
+			expectedFieldErrs: true,
+		},
+		{
+			name:              "rejects changes to unknown fields",
+			eventType:         userEvents.EventTypeUserUpdated,
+			payload:           userEvents.NewUserUpdatedEvent("user-123", map[string]any{"password": map[string]any{"old": "a", "new": "b"}}),
+			expectedError:     true,
+			expectedFieldErrs: true,
+		},
+		{
+			name:              "rejects unknown fields",
+			eventType:         userEvents.EventTypeUserDeleted,
+			payload:           map[string]any{"event_id": "event-1", "event_type": userEvents.EventTypeUserDeleted, "timestamp": "2026-01-01T00:00:00Z", "user_id": "user-123", "reason": "requested"},
+			expectedError:     true,
+			expectedFieldErrs: true,
+		},
+		{
+			name:      "accepts unknown fields when allowed",
+			options:   ValidatorOptions{AllowUnknownFields: true},
+			eventType: userEvents.EventTypeUserDeleted,
+			payload:   map[string]any{"event_id": "event-1", "event_type": userEvents.EventTypeUserDeleted, "timestamp": "2026-01-01T00:00:00Z", "user_id": "user-123", "reason": "requested"},
+		},
+		{
+			name:      "accepts changes to unknown fields when allowed",
+			options:   ValidatorOptions{AllowUnknownFields: true},
+			eventType: userEvents.EventTypeUserUpdated,
+			payload: userEvents.NewUserUpdatedEvent("user-123", map[string]any{
+				"email":    map[string]any{"old": "old@example.com", "new": "new@example.com", "source": "import"},
+				"nickname": map[string]any{"old": "a", "new": "b"},
+			}),
+		},
+		{
+			name:              "still rejects missing required fields when unknown fields are allowed",
+			options:           ValidatorOptions{AllowUnknownFields: true},
+			eventType:         userEvents.EventTypeUserDeleted,
+			payload:           map[string]any{"event_id": "event-1", "event_type": userEvents.EventTypeUserDeleted, "timestamp": "2026-01-01T00:00:00Z"},
+			expectedError:     true,
+			expectedFieldErrs: true,
+		},
+		{
+			name:              "rejects missing required fields",
+			eventType:         userEvents.EventTypeUserDeleted,
+			payload:           map[string]any{"event_id": "event-1", "event_type": userEvents.EventTypeUserDeleted, "timestamp": "2026-01-01T00:00:00Z"},
+			expectedError:     true,
+			expectedFieldErrs: true,
+		},
+		{
+			name:          "rejects unregistered event types",
+			eventType:     "user.archived",
+			payload:       map[string]any{},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.payload)
+			if err != nil {
+				t.Fatalf("failed to marshal payload: %v", err)
+			}
+
+			err = NewValidator(registry, tt.options).Validate(tt.eventType, data)
+			if !tt.expectedError {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !events.IsNonRetryable(err) {
+				t.Errorf("expected a non-retryable error, got %v", err)
+			}
+			var validationErr *ValidationError
+			if errors.As(err, &validationErr) != tt.expectedFieldErrs {
+				t.Errorf("expected validation error %v, got %v", tt.expectedFieldErrs, err)
+			}
+		})
+	}
+}
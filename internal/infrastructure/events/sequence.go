@@ -0,0 +1,27 @@
+package events
+
+import "context"
+
+// Sequencer hands out monotonically increasing sequence numbers per aggregate.
+// Implementations should take part in the caller's transaction so that a sequence
+// number is only consumed when the change it describes is committed.
+type Sequencer interface {
+	// Next returns the next sequence number for the aggregate, starting at 1
+	Next(ctx context.Context, aggregateID string) (int64, error)
+}
+
+// AssignSequence sets the next sequence number of the event's aggregate on the event.
+// Events that do not implement Sequenced are left unchanged.
+func AssignSequence(ctx context.Context, sequencer Sequencer, event Event) error {
+	sequenced, ok := event.(Sequenced)
+	if !ok {
+		return nil
+	}
+
+	sequence, err := sequencer.Next(ctx, event.AggregateID())
+	if err != nil {
+		return err
+	}
+	sequenced.SetSequence(sequence)
+	return nil
+}
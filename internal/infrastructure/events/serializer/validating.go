@@ -0,0 +1,35 @@
+package serializer
+
+import (
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/schema"
+)
+
+// ValidatingSerializer validates serialized events against their schema,
+// so an event that breaks its contract is rejected before it is published
+type ValidatingSerializer struct {
+	serializer Serializer
+	validator  *schema.Validator
+}
+
+func NewValidatingSerializer(serializer Serializer, validator *schema.Validator) ValidatingSerializer {
+	return ValidatingSerializer{
+		serializer: serializer,
+		validator:  validator,
+	}
+}
+
+func (s ValidatingSerializer) Serialize(event events.Event) ([]byte, error) {
+	data, err := s.serializer.Serialize(event)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validator.Validate(event.Type(), data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Make sure the serializer implements the Serializer interface
+var _ Serializer = ValidatingSerializer{}
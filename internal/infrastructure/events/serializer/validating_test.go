@@ -0,0 +1,75 @@
+package serializer
+
+import (
+	"testing"
+
+	userEvents "github.com/cgund98/go-postgres-api-template/internal/domain/user/events"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/schema"
+)
+
+func TestValidatingSerializer_Serialize(t *testing.T) {
+	registry := events.NewRegistry()
+	if err := userEvents.RegisterEvents(registry); err != nil {
+		t.Fatalf("failed to register events: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		event         events.Event
+		expectedError bool
+	}{
+		{
+			name:  "serializes a valid created event",
+			event: userEvents.NewUserCreatedEvent("user-123", "test@example.com"),
+		},
+		{
+			name: "serializes well formed changes",
+			event: userEvents.NewUserUpdatedEvent("user-123", map[string]any{
+				"email": map[string]any{"old": "old@example.com", "new": "new@example.com"},
+			}),
+		},
+		{
+			name: "rejects changes without old and new values",
+			event: userEvents.NewUserUpdatedEvent("user-123", map[string]any{
+				"email": "new@example.com",
+			}),
+			expectedError: true,
+		},
+		{
+			name: "rejects changes to unknown fields",
+			event: userEvents.NewUserUpdatedEvent("user-123", map[string]any{
+				"password": map[string]any{"old": "a", "new": "b"},
+			}),
+			expectedError: true,
+		},
+	}
+
+	serializer := NewValidatingSerializer(NewJSONSerializer(), schema.NewValidator(registry, schema.ValidatorOptions{}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := serializer.Serialize(tt.event)
+
+			if !tt.expectedError {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(data) == 0 {
+					t.Error("expected serialized data")
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if data != nil {
+				t.Errorf("expected no data for an invalid event, got %s", data)
+			}
+			if !events.IsNonRetryable(err) {
+				t.Errorf("expected a non-retryable error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package observability
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsNamespace is the prefix applied to all application metrics
+const MetricsNamespace = "app"
+
+func newRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return registry
+}
+
+// Registry is the Prometheus registry that all application metrics are registered with.
+// We use a dedicated registry instead of the global default so only our metrics are exported.
+var Registry *prometheus.Registry = newRegistry()
+
+// MetricsHandler returns an HTTP handler that serves the registry in the Prometheus exposition format
+func MetricsHandler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -2,28 +2,30 @@ package presentation
 
 import (
 	"github.com/cgund98/go-postgres-api-template/internal/domain/user"
+	userEvents "github.com/cgund98/go-postgres-api-template/internal/domain/user/events"
 	"github.com/cgund98/go-postgres-api-template/internal/domain/user/repo"
 	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/db/postgres"
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/ordering"
 	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/publisher"
 )
 
 // Dependencies holds all dependencies for the presentation layer
 type Dependencies struct {
 	UserService *user.Service
-	EventPub    publisher.Publisher
+	EventPub    publisher.ScheduledPublisher
 	// Add other dependencies as needed
 }
 
 // NewDependencies creates new dependencies
-func NewDependencies(dbPool *postgres.Pool, eventPub publisher.Publisher) *Dependencies {
-	// Create PostgreSQL transaction manager (takes sql.DB)
-	txManager := postgres.NewTransactionManager(dbPool.DB())
-
+func NewDependencies(txManager *postgres.TransactionManager, eventPub publisher.ScheduledPublisher, snapshots userEvents.SnapshotPolicy) *Dependencies {
 	// Create repository (it extracts DB from context internally)
 	userRepo := repo.NewPostgresRepository()
 
+	// Create sequencer (assigns per-aggregate sequence numbers to events inside the transaction)
+	sequencer := ordering.NewPostgresSequencer()
+
 	// Create service
-	userService := user.NewService(userRepo, txManager, eventPub)
+	userService := user.NewService(userRepo, txManager, eventPub, sequencer, snapshots)
 
 	return &Dependencies{
 		UserService: userService,
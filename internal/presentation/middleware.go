@@ -1,10 +1,14 @@
 package presentation
 
 import (
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/db/postgres"
 )
 
 // RequestLogger returns a middleware that logs HTTP requests
@@ -34,3 +38,79 @@ func RequestLogger() func(next http.Handler) http.Handler {
 		})
 	}
 }
+
+// lastWriteCookie carries when a client last wrote, in Unix nanoseconds, between its requests
+const lastWriteCookie = "last_write"
+
+// ReadYourWrites returns a middleware that sends a client's read-only transactions to the primary database
+// for window after it writes, instead of to a read replica that may lag behind. The time of the client's
+// latest write is kept in a cookie, so reads in the requests that follow a write see it. The cookie only
+// decides where reads are routed, so a client tampering with it can at most send its own reads to the primary.
+func ReadYourWrites(window time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			now := time.Now()
+			lastWrite := lastWriteFromCookie(r, now)
+			ctx := postgres.WithReadYourWrites(r.Context(), window, lastWrite)
+
+			// The cookie has to be set before the handler writes the response headers
+			ww := &writeCookieResponseWriter{ResponseWriter: w, setCookie: func() {
+				if written := postgres.LastWrite(ctx); written.After(lastWrite) {
+					http.SetCookie(w, &http.Cookie{
+						Name:     lastWriteCookie,
+						Value:    strconv.FormatInt(written.UnixNano(), 10),
+						Path:     "/",
+						MaxAge:   int(math.Ceil(window.Seconds())),
+						HttpOnly: true,
+						SameSite: http.SameSiteLaxMode,
+					})
+				}
+			}}
+			next.ServeHTTP(ww, r.WithContext(ctx))
+		})
+	}
+}
+
+// lastWriteFromCookie returns the client's latest write, or zero if the cookie is missing, malformed or in the future
+func lastWriteFromCookie(r *http.Request, now time.Time) time.Time {
+	cookie, err := r.Cookie(lastWriteCookie)
+	if err != nil {
+		return time.Time{}
+	}
+	nanos, err := strconv.ParseInt(cookie.Value, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	lastWrite := time.Unix(0, nanos)
+	if lastWrite.After(now) {
+		return time.Time{}
+	}
+	return lastWrite
+}
+
+// writeCookieResponseWriter calls setCookie once, just before the response headers are written
+type writeCookieResponseWriter struct {
+	http.ResponseWriter
+	setCookie   func()
+	wroteHeader bool
+}
+
+func (w *writeCookieResponseWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.setCookie()
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *writeCookieResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *writeCookieResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -2,6 +2,7 @@ package presentation
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/danielgtaylor/huma/v2/adapters/humachi"
@@ -14,13 +15,25 @@ type Router struct {
 	humaAPI   huma.API
 }
 
-// NewRouter creates a new router with Chi and Huma
-func NewRouter() *Router {
+// RouterOptions configures the middleware of the router
+type RouterOptions struct {
+	// ReadYourWrites sends a client's reads to the primary database for this long after it writes.
+	// Nil disables it, so every read-only transaction may go to a replica.
+	ReadYourWrites *time.Duration
+}
+
+// NewRouter creates a new router with Chi and Huma.
+// Middleware is added here, since Chi doesn't accept more once Huma has registered its routes.
+func NewRouter(options RouterOptions) *Router {
 	chiRouter := chi.NewRouter()
 
 	// Add request logging middleware
 	chiRouter.Use(RequestLogger())
 
+	if options.ReadYourWrites != nil && *options.ReadYourWrites > 0 {
+		chiRouter.Use(ReadYourWrites(*options.ReadYourWrites))
+	}
+
 	// Create Huma API adapter for Chi
 	// DefaultConfig sets up /openapi.json, /docs, and /schemas endpoints
 	config := huma.DefaultConfig("My API", "1.0.0")
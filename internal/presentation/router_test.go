@@ -0,0 +1,82 @@
+package presentation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/db/postgres"
+)
+
+func TestNewRouter_readYourWrites(t *testing.T) {
+	window := 5 * time.Second
+	now := time.Now()
+
+	tests := []struct {
+		name              string
+		options           RouterOptions
+		cookie            string
+		expectedLastWrite time.Time
+	}{
+		{
+			name:    "disabled",
+			options: RouterOptions{},
+			cookie:  strconv.FormatInt(now.UnixNano(), 10),
+		},
+		{
+			name:    "without a cookie",
+			options: RouterOptions{ReadYourWrites: &window},
+		},
+		{
+			name:              "with the client's latest write",
+			options:           RouterOptions{ReadYourWrites: &window},
+			cookie:            strconv.FormatInt(now.UnixNano(), 10),
+			expectedLastWrite: time.Unix(0, now.UnixNano()),
+		},
+		{
+			name:    "ignores a write in the future",
+			options: RouterOptions{ReadYourWrites: &window},
+			cookie:  strconv.FormatInt(now.Add(time.Hour).UnixNano(), 10),
+		},
+		{
+			name:    "ignores a malformed cookie",
+			options: RouterOptions{ReadYourWrites: &window},
+			cookie:  "yesterday",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewRouter(tt.options)
+
+			var lastWrite time.Time
+			huma.Get(router.HumaAPI(), "/last-write", func(ctx context.Context, _ *struct{}) (*struct{}, error) {
+				lastWrite = postgres.LastWrite(ctx)
+				return nil, nil
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/last-write", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: lastWriteCookie, Value: tt.cookie})
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusNoContent {
+				t.Fatalf("expected status %d, got %d", http.StatusNoContent, rec.Code)
+			}
+			if !lastWrite.Equal(tt.expectedLastWrite) {
+				t.Errorf("expected last write %v, got %v", tt.expectedLastWrite, lastWrite)
+			}
+			// Only a request that writes refreshes the cookie
+			if cookies := rec.Result().Cookies(); len(cookies) != 0 {
+				t.Errorf("expected no cookie for a request that did not write, got %v", cookies)
+			}
+		})
+	}
+}
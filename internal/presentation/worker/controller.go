@@ -0,0 +1,147 @@
+package worker
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/cgund98/go-postgres-api-template/internal/infrastructure/events/consumer"
+	"github.com/cgund98/go-postgres-api-template/internal/observability"
+)
+
+var logger = observability.Logger
+
+const defaultMaxPollAge = 60 * time.Second
+
+// ControllerOptions configures the worker's operational endpoints
+type ControllerOptions struct {
+	// MaxPollAge is how long a running consumer may go without a successful poll before /readyz fails.
+	// It must be longer than the consumers' long poll wait time.
+	MaxPollAge *time.Duration
+}
+
+// Controller handles the worker's health, readiness and admin HTTP requests
+type Controller struct {
+	consumers  *consumer.Group
+	maxPollAge time.Duration
+}
+
+// NewController creates a new worker Controller
+func NewController(consumers *consumer.Group, options ControllerOptions) *Controller {
+	maxPollAge := defaultMaxPollAge
+	if options.MaxPollAge != nil {
+		maxPollAge = *options.MaxPollAge
+	}
+
+	return &Controller{
+		consumers:  consumers,
+		maxPollAge: maxPollAge,
+	}
+}
+
+// RegisterRoutes registers the worker routes with the Chi router.
+// The admin routes are unauthenticated, so the worker port must not be exposed publicly.
+func (c *Controller) RegisterRoutes(router chi.Router) {
+	// Probes
+	router.Get("/healthz", c.Healthz)
+	router.Get("/readyz", c.Readyz)
+
+	// Consumer administration
+	router.Get("/admin/consumers", c.ListConsumers)
+	router.Get("/admin/consumers/{name}", c.GetConsumer)
+	router.Post("/admin/consumers/{name}/pause", c.PauseConsumer)
+	router.Post("/admin/consumers/{name}/resume", c.ResumeConsumer)
+}
+
+// HealthResponse is the body of the probe endpoints
+type HealthResponse struct {
+	Status    string            `json:"status"`
+	Consumers []consumer.Status `json:"consumers,omitempty"`
+}
+
+// ConsumerListResponse is the body of GET /admin/consumers
+type ConsumerListResponse struct {
+	Consumers []consumer.Status `json:"consumers"`
+}
+
+// ErrorResponse is the body returned when a request fails
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// Healthz handles GET /healthz
+// The process is healthy as long as it can serve HTTP requests.
+func (c *Controller) Healthz(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// Readyz handles GET /readyz
+// It fails when any running consumer has not polled its queue successfully within the max poll age.
+func (c *Controller) Readyz(w http.ResponseWriter, _ *http.Request) {
+	unready := c.consumers.Unready(time.Now(), c.maxPollAge)
+	if len(unready) > 0 {
+		writeJSON(w, http.StatusServiceUnavailable, HealthResponse{Status: "unready", Consumers: unready})
+		return
+	}
+	writeJSON(w, http.StatusOK, HealthResponse{Status: "ready"})
+}
+
+// ListConsumers handles GET /admin/consumers
+func (c *Controller) ListConsumers(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, ConsumerListResponse{Consumers: c.consumers.Statuses()})
+}
+
+// GetConsumer handles GET /admin/consumers/{name}
+func (c *Controller) GetConsumer(w http.ResponseWriter, r *http.Request) {
+	controller, err := c.consumers.Get(chi.URLParam(r, "name"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, controller.Status())
+}
+
+// PauseConsumer handles POST /admin/consumers/{name}/pause
+func (c *Controller) PauseConsumer(w http.ResponseWriter, r *http.Request) {
+	controller, err := c.consumers.Get(chi.URLParam(r, "name"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	controller.Pause()
+	logger.Info("Consumer paused via admin endpoint", "consumer", controller.Name())
+	writeJSON(w, http.StatusOK, controller.Status())
+}
+
+// ResumeConsumer handles POST /admin/consumers/{name}/resume
+func (c *Controller) ResumeConsumer(w http.ResponseWriter, r *http.Request) {
+	controller, err := c.consumers.Get(chi.URLParam(r, "name"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	controller.Resume()
+	logger.Info("Consumer resumed via admin endpoint", "consumer", controller.Name())
+	writeJSON(w, http.StatusOK, controller.Status())
+}
+
+// writeError writes an error response, mapping unknown consumers to 404
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, consumer.ErrConsumerNotFound) {
+		status = http.StatusNotFound
+	}
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
+
+// writeJSON writes the body as JSON with the given status code
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.Error("Failed to write response", "error", err)
+	}
+}
@@ -0,0 +1,3 @@
+-- Drop scheduled_events table
+DROP INDEX IF EXISTS idx_scheduled_events_deliver_at;
+DROP TABLE IF EXISTS scheduled_events;
@@ -0,0 +1,12 @@
+-- Create scheduled_events table for events delayed beyond the SQS DelaySeconds limit
+CREATE TABLE IF NOT EXISTS scheduled_events (
+    event_id VARCHAR(36) PRIMARY KEY,
+    event_type VARCHAR(255) NOT NULL,
+    aggregate_id VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    deliver_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create index on deliver_at so the worker can find due events quickly
+CREATE INDEX IF NOT EXISTS idx_scheduled_events_deliver_at ON scheduled_events(deliver_at);
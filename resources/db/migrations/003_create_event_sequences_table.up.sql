@@ -0,0 +1,6 @@
+-- Create event_sequences table holding the last sequence number assigned to each aggregate
+CREATE TABLE IF NOT EXISTS event_sequences (
+    aggregate_id VARCHAR(255) PRIMARY KEY,
+    sequence BIGINT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+-- Drop the priority of scheduled events
+ALTER TABLE scheduled_events DROP COLUMN IF EXISTS priority;
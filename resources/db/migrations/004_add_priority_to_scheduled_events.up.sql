@@ -0,0 +1,2 @@
+-- Keep the priority of scheduled events, so they are republished to the same weighted queues
+ALTER TABLE scheduled_events ADD COLUMN IF NOT EXISTS priority VARCHAR(255) NOT NULL DEFAULT 'default';
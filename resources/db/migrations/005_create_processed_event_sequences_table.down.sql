@@ -0,0 +1,2 @@
+-- Drop processed_event_sequences table
+DROP TABLE IF EXISTS processed_event_sequences;
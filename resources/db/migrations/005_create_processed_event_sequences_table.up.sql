@@ -0,0 +1,8 @@
+-- Create processed_event_sequences table holding the last sequence number each subscription handled per aggregate
+CREATE TABLE IF NOT EXISTS processed_event_sequences (
+    subscription VARCHAR(255) NOT NULL,
+    aggregate_id VARCHAR(255) NOT NULL,
+    sequence BIGINT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (subscription, aggregate_id)
+);
@@ -0,0 +1,10 @@
+// Package migrations embeds the SQL migrations so binaries can apply them without the files on disk.
+// Files are named <version>_<title>.up.sql and <version>_<title>.down.sql, as golang-migrate expects.
+package migrations
+
+import "embed"
+
+// FS holds the up and down SQL files of every migration
+//
+//go:embed *.sql
+var FS embed.FS